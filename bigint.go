@@ -0,0 +1,90 @@
+/*
+ * bigint.go - conversions between 128-bit integers and math/big
+ *
+ * goint128 - go int128 library
+ * Copyright (C) 2020  Mateusz Szpakowski
+ *
+ * This library is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU Lesser General Public
+ * License as published by the Free Software Foundation; either
+ * version 2.1 of the License, or (at your option) any later version.
+ *
+ * This library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+ * Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public
+ * License along with this library; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301  USA
+ */
+
+// Package to operate on 128-bit integers
+package goint128
+
+import (
+    "math/big"
+    "strconv"
+)
+
+// size of big.Word in bits (32 or 64)
+const bigWordBits = 32 << (uint64(^big.Word(0))>>63)
+
+// append words of 128-bit unsigned integer (from lowest) to big.Int words
+func appendBigWords(ws []big.Word, a UInt128) []big.Word {
+    if bigWordBits==64 {
+        return append(ws, big.Word(a[0]), big.Word(a[1]))
+    }
+    return append(ws, big.Word(a[0]), big.Word(a[0]>>32),
+                  big.Word(a[1]), big.Word(a[1]>>32))
+}
+
+// get 128-bit unsigned integer from big.Int words starting from word start
+func uint128FromBigWords(ws []big.Word, start int) UInt128 {
+    var a UInt128
+    for i := 0; i<128/bigWordBits && start+i<len(ws); i++ {
+        pos := uint(i*bigWordBits)
+        a[pos>>6] |= uint64(ws[start+i])<<(pos&63)
+    }
+    return a
+}
+
+// set big integer to value of 128-bit unsigned integer (reuses its memory)
+func (a UInt128) FillBigInt(x *big.Int) {
+    x.SetBits(appendBigWords(x.Bits()[:0], a))
+}
+
+// convert 128-bit unsigned integer to big integer
+func (a UInt128) BigInt() *big.Int {
+    x := new(big.Int)
+    a.FillBigInt(x)
+    return x
+}
+
+// convert big integer to 128-bit unsigned integer. return strconv.ErrRange
+// if value is negative or greater than 2^128-1
+func UInt128FromBigInt(x *big.Int) (UInt128, error) {
+    if x.Sign()<0 || x.BitLen()>128 {
+        return UInt128{}, strconv.ErrRange
+    }
+    return uint128FromBigWords(x.Bits(), 0), nil
+}
+
+// convert 256-bit unsigned integer (high and low part like result of MulFull)
+// to big integer
+func UInt128FullToBigInt(hi, lo UInt128) *big.Int {
+    ws := make([]big.Word, 0, 256/bigWordBits)
+    ws = appendBigWords(ws, lo)
+    ws = appendBigWords(ws, hi)
+    return new(big.Int).SetBits(ws)
+}
+
+// convert big integer to 256-bit unsigned integer (high and low part).
+// return strconv.ErrRange if value is negative or greater than 2^256-1
+func UInt128FullFromBigInt(x *big.Int) (UInt128, UInt128, error) {
+    if x.Sign()<0 || x.BitLen()>256 {
+        return UInt128{}, UInt128{}, strconv.ErrRange
+    }
+    ws := x.Bits()
+    return uint128FromBigWords(ws, 128/bigWordBits), uint128FromBigWords(ws, 0), nil
+}
@@ -0,0 +1,145 @@
+/*
+ * bigint_test.go - tests for conversions between 128-bit integers and math/big
+ *
+ * goint128 - go int128 library
+ * Copyright (C) 2020  Mateusz Szpakowski
+ *
+ * This library is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU Lesser General Public
+ * License as published by the Free Software Foundation; either
+ * version 2.1 of the License, or (at your option) any later version.
+ *
+ * This library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+ * Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public
+ * License along with this library; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301  USA
+ */
+
+package goint128
+
+import (
+    "math/big"
+    "strconv"
+    "testing"
+)
+
+type UInt128BigIntTC struct {
+    a UInt128
+    expected string
+}
+
+func TestUInt128BigInt(t *testing.T) {
+    testCases := []UInt128BigIntTC {
+        UInt128BigIntTC{ UInt128{ 0, 0 }, "0" },
+        UInt128BigIntTC{ UInt128{ 1, 0 }, "1" },
+        UInt128BigIntTC{ UInt128{ 0xffffffffffffffff, 0 }, "18446744073709551615" },
+        UInt128BigIntTC{ UInt128{ 0, 1 }, "18446744073709551616" },
+        UInt128BigIntTC{ UInt128{ 0x1c9e66c000000000, 0xe1b1e5f90f944d6e },
+            "300000000000000000000000000000000000000" },
+        UInt128BigIntTC{ MaxUInt128, "340282366920938463463374607431768211455" },
+    }
+    for i, tc := range testCases {
+        a := tc.a
+        result := tc.a.BigInt()
+        if tc.expected!=result.String() {
+            t.Errorf("Result mismatch: %d: BigInt(%v)->%v!=%v",
+                     i, tc.a, tc.expected, result)
+        }
+        // fill big integer that has different value
+        x := new(big.Int).Lsh(big.NewInt(-1234), 300)
+        tc.a.FillBigInt(x)
+        if tc.expected!=x.String() {
+            t.Errorf("Result mismatch: %d: FillBigInt(%v)->%v!=%v",
+                     i, tc.a, tc.expected, x)
+        }
+        if tc.a!=a {
+            t.Errorf("Argument has been modified: %d: %v!=%v", i, a, tc.a)
+        }
+        back, err := UInt128FromBigInt(result)
+        if tc.a!=back || err!=nil {
+            t.Errorf("Result mismatch: %d: FromBigInt(%v)->%v!=%v,%v",
+                     i, result, tc.a, back, err)
+        }
+    }
+}
+
+type UInt128FromBigIntTC struct {
+    str string
+    expected UInt128
+    expError error
+}
+
+func TestUInt128FromBigInt(t *testing.T) {
+    testCases := []UInt128FromBigIntTC {
+        UInt128FromBigIntTC{ "0", UInt128{}, nil },
+        UInt128FromBigIntTC{ "18446744073709551617", UInt128{ 1, 1 }, nil },
+        UInt128FromBigIntTC{ "340282366920938463463374607431768211455", MaxUInt128, nil },
+        UInt128FromBigIntTC{ "340282366920938463463374607431768211456",
+            UInt128{}, strconv.ErrRange },
+        UInt128FromBigIntTC{ "-1", UInt128{}, strconv.ErrRange },
+    }
+    for i, tc := range testCases {
+        x, _ := new(big.Int).SetString(tc.str, 10)
+        result, err := UInt128FromBigInt(x)
+        if tc.expected!=result || tc.expError!=err {
+            t.Errorf("Result mismatch: %d: FromBigInt(%v)->%v,%v!=%v,%v",
+                     i, tc.str, tc.expected, tc.expError, result, err)
+        }
+    }
+}
+
+type UInt128FullBigIntTC struct {
+    hi, lo UInt128
+    str string
+    expError error
+}
+
+func TestUInt128FullBigInt(t *testing.T) {
+    testCases := []UInt128FullBigIntTC {
+        UInt128FullBigIntTC{ UInt128{}, UInt128{}, "0", nil },
+        UInt128FullBigIntTC{ UInt128{}, UInt128{ 5, 7 }, "129127208515966861317", nil },
+        UInt128FullBigIntTC{ UInt128{ 1, 0 }, UInt128{},
+            "340282366920938463463374607431768211456", nil },
+        UInt128FullBigIntTC{ MaxUInt128, MaxUInt128, "11579208923731619542357098500868"+
+            "7907853269984665640564039457584007913129639935", nil },
+        UInt128FullBigIntTC{ UInt128{}, UInt128{}, "11579208923731619542357098500868"+
+            "7907853269984665640564039457584007913129639936", strconv.ErrRange },
+        UInt128FullBigIntTC{ UInt128{}, UInt128{}, "-5", strconv.ErrRange },
+    }
+    for i, tc := range testCases {
+        x, _ := new(big.Int).SetString(tc.str, 10)
+        hi, lo, err := UInt128FullFromBigInt(x)
+        if tc.hi!=hi || tc.lo!=lo || tc.expError!=err {
+            t.Errorf("Result mismatch: %d: FullFromBigInt(%v)->%v,%v,%v!=%v,%v,%v",
+                     i, tc.str, tc.hi, tc.lo, tc.expError, hi, lo, err)
+        }
+        if tc.expError==nil {
+            result := UInt128FullToBigInt(tc.hi, tc.lo)
+            if tc.str!=result.String() {
+                t.Errorf("Result mismatch: %d: FullToBigInt(%v,%v)->%v!=%v",
+                         i, tc.hi, tc.lo, tc.str, result)
+            }
+        }
+    }
+    // compare MulFull with big integer multiplication
+    a := UInt128{ 0x1c9e66c000000000, 0xe1b1e5f90f944d6e }
+    b := UInt128{ 0x8c261ad7409395f0, 0x47a96e }
+    hi, lo := a.MulFull(b)
+    expected := new(big.Int).Mul(a.BigInt(), b.BigInt())
+    if expected.Cmp(UInt128FullToBigInt(hi, lo))!=0 {
+        t.Errorf("Result mismatch: MulFull(%v,%v)->%v!=%v,%v", a, b, expected, hi, lo)
+    }
+}
+
+func BenchmarkUInt128FillBigInt(b *testing.B) {
+    a := UInt128{ 0x1c9e66c000000000, 0xe1b1e5f90f944d6e }
+    x := new(big.Int)
+    b.ReportAllocs()
+    for i := 0; i < b.N; i++ {
+        a.FillBigInt(x)
+    }
+}
@@ -0,0 +1,516 @@
+/*
+ * decimal.go - decimal numbers with 128-bit coefficient
+ *
+ * goint128 - go int128 library
+ * Copyright (C) 2020  Mateusz Szpakowski
+ *
+ * This library is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU Lesser General Public
+ * License as published by the Free Software Foundation; either
+ * version 2.1 of the License, or (at your option) any later version.
+ *
+ * This library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+ * Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public
+ * License along with this library; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301  USA
+ */
+
+// Package to operate on 128-bit integers
+package goint128
+
+import (
+    "math"
+    "strconv"
+)
+
+// decimal number: value is (-1)^Negative * Coef * 10^Exp. negated exponent is
+// number of fractional digits (scale), for example 12.50 is {1250, -2, false}.
+// result of arithmetic operation has exponent of first operand
+type Decimal128 struct {
+    Coef UInt128
+    Exp int32
+    Negative bool
+}
+
+// greatest power of 10 that fits in 256-bit
+const uint256MaxPow10 = 77
+
+// convert 128-bit unsigned integer and exponent to decimal number
+func Decimal128FromUInt128(a UInt128, exp int32) Decimal128 {
+    return Decimal128{ a, exp, false }
+}
+
+// convert 128-bit signed integer and exponent to decimal number
+func Decimal128FromInt128(a Int128, exp int32) Decimal128 {
+    return Decimal128{ a.Abs(), exp, int64(a[1])<0 }
+}
+
+// return 10^n as 256-bit unsigned integer (n must not be greater than 77)
+func uint256Pow10(n int64) UInt256 {
+    last := int64(len(uint128_10powers)-1)
+    if n<=last {
+        return uint128_10powers[n].Widen()
+    }
+    p := uint128_10powers[last].Widen()
+    for n -= last; n>last; n-- {
+        p = p.Mul64(10)
+    }
+    return p.Mul(uint128_10powers[n].Widen())
+}
+
+// multiply 128-bit coefficient by 10^n and return result or ErrOverflow
+func decimalScaleUp(a UInt128, n int64) (UInt128, error) {
+    if a.IsZero() || n==0 {
+        return a, nil
+    }
+    if n>=int64(len(uint128_10powers)) {
+        return UInt128{}, ErrOverflow
+    }
+    return a.MulChecked(uint128_10powers[n])
+}
+
+// round truncated quotient of magnitudes and return result or ErrOverflow if
+// result does not fit in 128 bits. cmpHalf is result of comparison of remainder
+// with half of divisor, inexact is true if remainder is not zero
+func decimalRound(q UInt256, cmpHalf int, inexact, neg bool,
+                mode RoundingMode) (UInt128, error) {
+    up := false
+    if inexact {
+        switch mode {
+        case ToNearestEven:
+            up = cmpHalf>0 || (cmpHalf==0 && q[0]&1!=0)
+        case ToNearestAway:
+            up = cmpHalf>=0
+        case ToNegativeInf:
+            up = neg
+        case ToPositiveInf:
+            up = !neg
+        case AwayFromZero:
+            up = true
+        }
+    }
+    if up {
+        q = q.Add64(1)
+    }
+    return q.Narrow()
+}
+
+// divide magnitude by 10^n and round result. sticky is true if magnitude
+// has lost non-zero digits (actual value is slightly greater than v)
+func decimalShiftRound(v UInt256, n int64, sticky, neg bool,
+                mode RoundingMode) (UInt128, error) {
+    if n>uint256MaxPow10 {
+        // v is lesser than half of 10^n
+        return decimalRound(UInt256{}, -1, sticky || !v.IsZero(), neg, mode)
+    }
+    if n==0 && !sticky {
+        return v.Narrow()
+    }
+    var q, r, d UInt256
+    if v[2]==0 && v[3]==0 && n<int64(len(uint128_10powers)) {
+        // faster 128-bit division
+        d128 := uint128_10powers[n]
+        q128, r128 := UInt128{ v[0], v[1] }.DivMod(d128)
+        q, r, d = q128.Widen(), r128.Widen(), d128.Widen()
+    } else {
+        d = uint256Pow10(n)
+        q, r = v.DivMod(d)
+    }
+    c := r.Cmp(d.Sub(r))
+    if c==0 && sticky {
+        c = 1
+    }
+    return decimalRound(q, c, sticky || !r.IsZero(), neg, mode)
+}
+
+// return true if zero
+func (a Decimal128) IsZero() bool {
+    return a.Coef.IsZero()
+}
+
+// return sign of number: -1 if negative, 0 if zero, 1 if positive
+func (a Decimal128) Sign() int {
+    if a.Coef.IsZero() {
+        return 0
+    } else if a.Negative {
+        return -1
+    }
+    return 1
+}
+
+// negate decimal number
+func (a Decimal128) Neg() Decimal128 {
+    return Decimal128{ a.Coef, a.Exp, !a.Negative }
+}
+
+// return absolute value of decimal number
+func (a Decimal128) Abs() Decimal128 {
+    return Decimal128{ a.Coef, a.Exp, false }
+}
+
+// compare absolute values of non-zero decimal numbers
+func decimalCmpAbs(a, b Decimal128) int {
+    d := int64(a.Exp)-int64(b.Exp)
+    if d<0 {
+        return -decimalCmpAbs(b, a)
+    }
+    if d>=int64(len(uint128_10powers)) {
+        // a*10^d is not lesser than 10^39
+        return 1
+    }
+    return a.Coef.Widen().Mul(uint128_10powers[d].Widen()).Cmp(b.Coef.Widen())
+}
+
+// compare decimal numbers and return 0 if they equal,
+// 1 if first is greater than second, or -1 if first is lesser than second
+func (a Decimal128) Cmp(b Decimal128) int {
+    as, bs := a.Sign(), b.Sign()
+    if as!=bs {
+        if as>bs {
+            return 1
+        }
+        return -1
+    }
+    if as==0 {
+        return 0
+    }
+    if as<0 {
+        return -decimalCmpAbs(a, b)
+    }
+    return decimalCmpAbs(a, b)
+}
+
+// return decimal number with given exponent (rounded with mode if exponent is
+// greater than exponent of number) or ErrOverflow if coefficient is too big
+func (a Decimal128) Rescale(exp int32, mode RoundingMode) (Decimal128, error) {
+    d := int64(exp)-int64(a.Exp)
+    var c UInt128
+    var err error
+    if d<0 {
+        c, err = decimalScaleUp(a.Coef, -d)
+    } else {
+        c, err = decimalShiftRound(a.Coef.Widen(), d, false, a.Negative, mode)
+    }
+    if err!=nil {
+        return Decimal128{}, err
+    }
+    return Decimal128{ c, exp, a.Negative }, nil
+}
+
+// add or subtract decimal numbers (bneg is sign of second operand)
+func (a Decimal128) addSub(b Decimal128, bneg bool,
+                mode RoundingMode) (Decimal128, error) {
+    d := int64(a.Exp)-int64(b.Exp)
+    var av, bv UInt256
+    sticky := false
+    if d<=0 {
+        // scale second operand to exponent of first operand
+        av = a.Coef.Widen()
+        if !b.Coef.IsZero() {
+            if -d>=int64(len(uint128_10powers)) {
+                // second operand is not lesser than 10^39 units
+                return Decimal128{}, ErrOverflow
+            }
+            bv = b.Coef.Widen().Mul(uint256Pow10(-d))
+        }
+        d = 0
+    } else {
+        bc := b.Coef
+        if last := int64(len(uint128_10powers)-1); d>last {
+            // keep 38 fractional digits, rest of digits goes to sticky
+            if k := d-last; k<=last {
+                var r UInt128
+                bc, r = b.Coef.DivMod(uint128_10powers[k])
+                sticky = !r.IsZero()
+            } else {
+                bc, sticky = UInt128{}, !b.Coef.IsZero()
+            }
+            d = last
+        }
+        av, bv = a.Coef.Widen().Mul(uint256Pow10(d)), bc.Widen()
+    }
+    neg := a.Negative
+    var v UInt256
+    if a.Negative==bneg {
+        v = av.Add(bv)
+    } else if av.Cmp(bv)>0 {
+        v = av.Sub(bv)
+        if sticky {
+            // a-(b+e) = (a-b-1)+(1-e)
+            v = v.Sub64(1)
+        }
+    } else {
+        v, neg = bv.Sub(av), bneg
+        if v.IsZero() && !sticky {
+            neg = false
+        }
+    }
+    c, err := decimalShiftRound(v, d, sticky, neg, mode)
+    if err!=nil {
+        return Decimal128{}, err
+    }
+    return Decimal128{ c, a.Exp, neg }, nil
+}
+
+// add decimal numbers and return sum with exponent of first number (rounded
+// with mode) or ErrOverflow if coefficient of result is too big
+func (a Decimal128) Add(b Decimal128, mode RoundingMode) (Decimal128, error) {
+    return a.addSub(b, b.Negative, mode)
+}
+
+// subtract decimal numbers and return difference with exponent of first number
+// (rounded with mode) or ErrOverflow if coefficient of result is too big
+func (a Decimal128) Sub(b Decimal128, mode RoundingMode) (Decimal128, error) {
+    return a.addSub(b, !b.Negative, mode)
+}
+
+// multiply decimal numbers and return product with exponent of first number
+// (rounded with mode) or ErrOverflow if coefficient of result is too big
+func (a Decimal128) Mul(b Decimal128, mode RoundingMode) (Decimal128, error) {
+    neg := a.Negative!=b.Negative
+    var c UInt128
+    var err error
+    if b.Exp>=0 {
+        c, err = a.Coef.MulChecked(b.Coef)
+        if err==nil {
+            c, err = decimalScaleUp(c, int64(b.Exp))
+        }
+    } else {
+        hi, lo := a.Coef.MulFull(b.Coef)
+        c, err = decimalShiftRound(MakeUInt256(hi, lo), -int64(b.Exp), false, neg, mode)
+    }
+    if err!=nil {
+        return Decimal128{}, err
+    }
+    return Decimal128{ c, a.Exp, neg }, nil
+}
+
+// divide decimal numbers and return quotient with exponent of first number
+// (rounded with mode) or error (ErrDivideByZero or ErrOverflow)
+func (a Decimal128) Quo(b Decimal128, mode RoundingMode) (Decimal128, error) {
+    if b.Coef.IsZero() {
+        return Decimal128{}, ErrDivideByZero
+    }
+    neg := a.Negative!=b.Negative
+    // quotient coefficient is a.Coef / (b.Coef*10^b.Exp)
+    num, den := a.Coef.Widen(), b.Coef.Widen()
+    var c UInt128
+    var err error
+    if b.Exp<0 && !num.IsZero() {
+        if -int64(b.Exp)>uint256MaxPow10 {
+            return Decimal128{}, ErrOverflow
+        }
+        // if numerator overflows then quotient is not lesser than 2^128
+        if num, err = num.MulChecked(uint256Pow10(-int64(b.Exp))); err!=nil {
+            return Decimal128{}, ErrOverflow
+        }
+    }
+    if b.Exp>0 {
+        if int64(b.Exp)<=uint256MaxPow10 {
+            den, err = den.MulChecked(uint256Pow10(int64(b.Exp)))
+        }
+        if int64(b.Exp)>uint256MaxPow10 || err!=nil {
+            // divisor is greater than 2^256, numerator is lesser than half of it
+            c, err = decimalRound(UInt256{}, -1, !num.IsZero(), neg, mode)
+            return Decimal128{ c, a.Exp, neg }, err
+        }
+    }
+    q, r := num.DivMod(den)
+    c, err = decimalRound(q, r.Cmp(den.Sub(r)), !r.IsZero(), neg, mode)
+    if err!=nil {
+        return Decimal128{}, err
+    }
+    return Decimal128{ c, a.Exp, neg }, nil
+}
+
+// append decimal form of number to bytes. like to-scientific-string from
+// General Decimal Arithmetic: if exponent is not positive and adjusted exponent
+// (exponent plus number of digits minus 1) is not less than -6 then number is
+// written without exponent (number of fractional digits is negated exponent),
+// otherwise number is written in scientific notation (for example "1.20e+5").
+// coefficient and exponent are kept and length of output is bounded
+func (a Decimal128) AppendFormat(dst []byte) []byte {
+    if a.Negative && !a.Coef.IsZero() {
+        dst = append(dst, '-')
+    }
+    var buf [40]byte
+    digits := a.Coef.AppendFormat(buf[:0])
+    n, scale := int64(len(digits)), -int64(a.Exp)
+    adjusted := int64(a.Exp) + n - 1
+    if a.Exp>0 || adjusted< -6 {
+        dst = append(dst, digits[0])
+        if n>1 {
+            dst = append(dst, '.')
+            dst = append(dst, digits[1:]...)
+        }
+        dst = append(dst, 'e')
+        if adjusted>=0 {
+            dst = append(dst, '+')
+        }
+        return strconv.AppendInt(dst, adjusted, 10)
+    }
+    if scale==0 {
+        return append(dst, digits...)
+    }
+    if n>scale {
+        dst = append(dst, digits[:n-scale]...)
+        dst = append(dst, '.')
+        return append(dst, digits[n-scale:]...)
+    }
+    dst = append(dst, '0', '.')
+    for ; n<scale; n++ {
+        dst = append(dst, '0')
+    }
+    return append(dst, digits...)
+}
+
+// format decimal number to bytes
+func (a Decimal128) FormatBytes() []byte {
+    return a.AppendFormat(nil)
+}
+
+// parse decimal number ([sign][digits][.digits][e[sign]digits]) from string
+// and return value, offset of first bad character and error. exponent of value
+// is exponent part minus number of fractional digits
+func parseDecimal128(str string) (Decimal128, int, error) {
+    slen := len(str)
+    var a Decimal128
+    i := 0
+    if slen!=0 && (str[0]=='-' || str[0]=='+') {
+        a.Negative = str[0]=='-'
+        i = 1
+    }
+    digits := 0
+    frac := int64(0)
+    dot := false
+    for ; i<slen; i++ {
+        if str[i]=='.' && !dot {
+            dot = true
+            continue
+        }
+        d := str[i]-'0'
+        if d>9 {
+            break
+        }
+        var err error
+        if a.Coef, err = a.Coef.Mul64Checked(10); err==nil {
+            a.Coef, err = a.Coef.AddChecked(UInt128{ uint64(d), 0 })
+        }
+        if err!=nil {
+            return Decimal128{}, i, strconv.ErrRange
+        }
+        digits++
+        if dot {
+            frac++
+        }
+    }
+    if digits==0 {
+        return Decimal128{}, i, strconv.ErrSyntax
+    }
+    exp := int64(0)
+    expStart := i
+    if i<slen && (str[i]=='e' || str[i]=='E') {
+        i++
+        eneg := false
+        if i<slen && (str[i]=='-' || str[i]=='+') {
+            eneg = str[i]=='-'
+            i++
+        }
+        start := i
+        for ; i<slen && str[i]-'0'<=9; i++ {
+            exp = exp*10 + int64(str[i]-'0')
+            if exp>math.MaxInt32+int64(slen) {
+                return Decimal128{}, i, strconv.ErrRange
+            }
+        }
+        if i==start {
+            return Decimal128{}, i, strconv.ErrSyntax
+        }
+        if eneg {
+            exp = -exp
+        }
+    }
+    if i!=slen {
+        return Decimal128{}, i, strconv.ErrSyntax
+    }
+    exp -= frac
+    if exp<math.MinInt32 || exp>math.MaxInt32 {
+        return Decimal128{}, expStart, strconv.ErrRange
+    }
+    a.Exp = int32(exp)
+    return a, 0, nil
+}
+
+// parse decimal number from string and return value and error (nil if no error)
+func ParseDecimal128(str string) (Decimal128, error) {
+    out, offset, err := parseDecimal128(str)
+    if err!=nil {
+        return Decimal128{}, numError("ParseDecimal128", str, offset, err)
+    }
+    return out, nil
+}
+
+// parse decimal number from bytes and return value and error (nil if no error)
+func ParseDecimal128Bytes(str []byte) (Decimal128, error) {
+    out, offset, err := parseDecimal128(bytesToString(str))
+    if err!=nil {
+        return Decimal128{}, numError("ParseDecimal128Bytes", string(str), offset, err)
+    }
+    return out, nil
+}
+
+// stringer
+
+func (a Decimal128) String() string {
+    return string(a.FormatBytes())
+}
+
+// marshalling/unmarshaling
+
+func (a Decimal128) MarshalText() (text []byte, err error) {
+    return a.FormatBytes(), nil
+}
+
+func (a *Decimal128) UnmarshalText(text []byte) error {
+    out, offset, err := parseDecimal128(bytesToString(text))
+    if err!=nil {
+        *a = Decimal128{}
+        return numError("UnmarshalText", string(text), offset, err)
+    }
+    *a = out
+    return nil
+}
+
+// append JSON form of decimal number to bytes (always quoted number,
+// because JSON numbers are usually converted to float point values)
+func (a Decimal128) AppendJSON(dst []byte) []byte {
+    dst = append(dst, '"')
+    dst = a.AppendFormat(dst)
+    return append(dst, '"')
+}
+
+func (a Decimal128) MarshalJSON() ([]byte, error) {
+    return a.AppendJSON(nil), nil
+}
+
+func (a *Decimal128) UnmarshalJSON(data []byte) error {
+    dlen := len(data)
+    str := data
+    start := 0
+    if dlen>=2 && (data[0]=='"'||data[0]=='\'') &&
+                    (data[dlen-1]=='"'||data[dlen-1]=='\'') {
+        str = data[1:dlen-1]
+        start = 1
+    }
+    out, offset, err := parseDecimal128(bytesToString(str))
+    if err!=nil {
+        *a = Decimal128{}
+        return numError("UnmarshalJSON", string(data), start+offset, err)
+    }
+    *a = out
+    return nil
+}
@@ -0,0 +1,625 @@
+/*
+ * decimal_test.go - tests for decimal numbers with 128-bit coefficient
+ *
+ * goint128 - go int128 library
+ * Copyright (C) 2020  Mateusz Szpakowski
+ *
+ * This library is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU Lesser General Public
+ * License as published by the Free Software Foundation; either
+ * version 2.1 of the License, or (at your option) any later version.
+ *
+ * This library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+ * Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public
+ * License along with this library; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301  USA
+ */
+
+package goint128
+
+import (
+    "bytes"
+    "encoding/json"
+    "math"
+    "math/big"
+    "math/rand"
+    "strconv"
+    "testing"
+)
+
+var decimalRoundingModes = []RoundingMode{ ToNearestEven, ToNearestAway, ToZero,
+    AwayFromZero, ToNegativeInf, ToPositiveInf }
+
+type Decimal128RescaleTC struct {
+    value Decimal128
+    exp int32
+    expected []Decimal128 // for every rounding mode
+    expError error
+}
+
+func TestDecimal128Rescale(t *testing.T) {
+    d := func(coef uint64, exp int32, neg bool) Decimal128 {
+        return Decimal128{ UInt128{ coef, 0 }, exp, neg }
+    }
+    testCases := []Decimal128RescaleTC {
+        Decimal128RescaleTC{ d(25, -1, false), 0, []Decimal128{ d(2, 0, false),
+            d(3, 0, false), d(2, 0, false), d(3, 0, false), d(2, 0, false),
+            d(3, 0, false) }, nil },
+        Decimal128RescaleTC{ d(25, -1, true), 0, []Decimal128{ d(2, 0, true),
+            d(3, 0, true), d(2, 0, true), d(3, 0, true), d(3, 0, true),
+            d(2, 0, true) }, nil },
+        Decimal128RescaleTC{ d(15, -1, false), 0, []Decimal128{ d(2, 0, false),
+            d(2, 0, false), d(1, 0, false), d(2, 0, false), d(1, 0, false),
+            d(2, 0, false) }, nil },
+        Decimal128RescaleTC{ d(12349, -3, false), -1, []Decimal128{ d(123, -1, false),
+            d(123, -1, false), d(123, -1, false), d(124, -1, false), d(123, -1, false),
+            d(124, -1, false) }, nil },
+        Decimal128RescaleTC{ d(12351, -3, true), -1, []Decimal128{ d(124, -1, true),
+            d(124, -1, true), d(123, -1, true), d(124, -1, true), d(124, -1, true),
+            d(123, -1, true) }, nil },
+        Decimal128RescaleTC{ d(4, -2, true), -1, []Decimal128{ d(0, -1, true),
+            d(0, -1, true), d(0, -1, true), d(1, -1, true), d(1, -1, true),
+            d(0, -1, true) }, nil },
+        Decimal128RescaleTC{ d(7, 0, false), 100, []Decimal128{ d(0, 100, false),
+            d(0, 100, false), d(0, 100, false), d(1, 100, false), d(0, 100, false),
+            d(1, 100, false) }, nil },
+        Decimal128RescaleTC{ d(1250, -2, false), -5, []Decimal128{ d(1250000, -5, false),
+            d(1250000, -5, false), d(1250000, -5, false), d(1250000, -5, false),
+            d(1250000, -5, false), d(1250000, -5, false) }, nil },
+        Decimal128RescaleTC{ d(0, 0, false), -100, []Decimal128{ d(0, -100, false),
+            d(0, -100, false), d(0, -100, false), d(0, -100, false),
+            d(0, -100, false), d(0, -100, false) }, nil },
+        Decimal128RescaleTC{ d(35, 0, false), -37, nil, ErrOverflow },
+        Decimal128RescaleTC{ d(1, 0, false), -39, nil, ErrOverflow },
+        Decimal128RescaleTC{ Decimal128{ MaxUInt128, -1, false }, 0, []Decimal128{
+            Decimal128{ UInt128{ 0x999999999999999a, 0x1999999999999999 }, 0, false },
+            Decimal128{ UInt128{ 0x999999999999999a, 0x1999999999999999 }, 0, false },
+            Decimal128{ UInt128{ 0x9999999999999999, 0x1999999999999999 }, 0, false },
+            Decimal128{ UInt128{ 0x999999999999999a, 0x1999999999999999 }, 0, false },
+            Decimal128{ UInt128{ 0x9999999999999999, 0x1999999999999999 }, 0, false },
+            Decimal128{ UInt128{ 0x999999999999999a, 0x1999999999999999 }, 0, false },
+            }, nil },
+    }
+    for i, tc := range testCases {
+        for j, mode := range decimalRoundingModes {
+            a := tc.value
+            result, err := tc.value.Rescale(tc.exp, mode)
+            var expected Decimal128
+            if tc.expected!=nil {
+                expected = tc.expected[j]
+            }
+            if expected!=result || tc.expError!=err {
+                t.Errorf("Result mismatch: %d: rescale(%v,%d,%v)->%v,%v!=%v,%v",
+                         i, tc.value, tc.exp, mode, expected, tc.expError, result, err)
+            }
+            if tc.value!=a {
+                t.Errorf("Argument has been modified: %d: %v!=%v", i, a, tc.value)
+            }
+        }
+    }
+}
+
+type Decimal128CmpTC struct {
+    a, b Decimal128
+    expected int
+}
+
+func TestDecimal128Cmp(t *testing.T) {
+    testCases := []Decimal128CmpTC {
+        Decimal128CmpTC{ Decimal128{ UInt128{ 1250, 0 }, -2, false },
+            Decimal128{ UInt128{ 125, 0 }, -1, false }, 0 },
+        Decimal128CmpTC{ Decimal128{ UInt128{ 1251, 0 }, -2, false },
+            Decimal128{ UInt128{ 125, 0 }, -1, false }, 1 },
+        Decimal128CmpTC{ Decimal128{ UInt128{ 1251, 0 }, -2, true },
+            Decimal128{ UInt128{ 125, 0 }, -1, true }, -1 },
+        Decimal128CmpTC{ Decimal128{ UInt128{ 0, 0 }, -2, true },
+            Decimal128{ UInt128{ 0, 0 }, 5, false }, 0 },
+        Decimal128CmpTC{ Decimal128{ UInt128{ 1, 0 }, -30, true },
+            Decimal128{ UInt128{ 0, 0 }, 5, false }, -1 },
+        Decimal128CmpTC{ Decimal128{ UInt128{ 1, 0 }, 40, false },
+            Decimal128{ MaxUInt128, 0, false }, 1 },
+        Decimal128CmpTC{ Decimal128{ MaxUInt128, -40, false },
+            Decimal128{ UInt128{ 1, 0 }, 0, false }, -1 },
+        Decimal128CmpTC{ Decimal128{ UInt128{ 1, 0 }, 40, true },
+            Decimal128{ MaxUInt128, 0, true }, -1 },
+    }
+    for i, tc := range testCases {
+        a, b := tc.a, tc.b
+        result := tc.a.Cmp(tc.b)
+        if tc.expected!=result {
+            t.Errorf("Result mismatch: %d: cmp(%v,%v)->%v!=%v",
+                     i, tc.a, tc.b, tc.expected, result)
+        }
+        if tc.a!=a || tc.b!=b {
+            t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
+                     i, a, b, tc.a, tc.b)
+        }
+        if result = tc.b.Cmp(tc.a); -tc.expected!=result {
+            t.Errorf("Result mismatch: %d: cmp(%v,%v)->%v!=%v",
+                     i, tc.b, tc.a, -tc.expected, result)
+        }
+    }
+}
+
+type Decimal128OpTC struct {
+    a, b Decimal128
+    mode RoundingMode
+    expected Decimal128
+    expError error
+}
+
+func TestDecimal128AddSubMulQuo(t *testing.T) {
+    d := func(coef uint64, exp int32, neg bool) Decimal128 {
+        return Decimal128{ UInt128{ coef, 0 }, exp, neg }
+    }
+    type opFunc func(a, b Decimal128, mode RoundingMode) (Decimal128, error)
+    ops := []opFunc{ Decimal128.Add, Decimal128.Sub, Decimal128.Mul, Decimal128.Quo }
+    opNames := []string{ "add", "sub", "mul", "quo" }
+    testCases := [][]Decimal128OpTC {
+        []Decimal128OpTC {
+            Decimal128OpTC{ d(1250, -2, false), d(375, -3, false), ToNearestEven,
+                d(1288, -2, false), nil },
+            Decimal128OpTC{ d(1250, -2, false), d(375, -3, false), ToZero,
+                d(1287, -2, false), nil },
+            Decimal128OpTC{ d(1250, -2, false), d(1300, -2, true), ToZero,
+                d(50, -2, true), nil },
+            Decimal128OpTC{ d(1250, -2, true), d(1250, -2, false), ToZero,
+                d(0, -2, false), nil },
+            Decimal128OpTC{ d(5, 0, false), d(3, 1, false), ToZero,
+                d(35, 0, false), nil },
+            // tiny second operand
+            Decimal128OpTC{ d(5, 0, false), d(1, -60, false), ToPositiveInf,
+                d(6, 0, false), nil },
+            Decimal128OpTC{ d(5, 0, false), d(1, -60, true), ToZero,
+                d(4, 0, false), nil },
+            Decimal128OpTC{ d(5, 0, false), d(1, -60, true), ToNearestEven,
+                d(5, 0, false), nil },
+            Decimal128OpTC{ d(0, 0, false), d(5, -1, true), ToNearestAway,
+                d(1, 0, true), nil },
+            Decimal128OpTC{ Decimal128{ MaxUInt128, 0, false }, d(1, 0, false), ToZero,
+                Decimal128{}, ErrOverflow },
+            Decimal128OpTC{ d(1, 0, false), d(1, 39, false), ToZero,
+                Decimal128{}, ErrOverflow },
+        },
+        []Decimal128OpTC {
+            Decimal128OpTC{ d(1250, -2, false), d(375, -3, false), ToNearestEven,
+                d(1212, -2, false), nil },
+            Decimal128OpTC{ d(1250, -2, false), d(375, -3, false), ToNearestAway,
+                d(1213, -2, false), nil },
+            Decimal128OpTC{ d(1, 0, false), d(3, 0, false), ToZero,
+                d(2, 0, true), nil },
+            Decimal128OpTC{ d(0, 0, false), Decimal128{ MaxUInt128, -1, false },
+                ToNegativeInf, Decimal128{ UInt128{ 0x999999999999999a,
+                    0x1999999999999999 }, 0, true }, nil },
+            Decimal128OpTC{ d(0, 0, false), Decimal128{ MaxUInt128, 0, false },
+                ToNegativeInf, Decimal128{ MaxUInt128, 0, true }, nil },
+        },
+        []Decimal128OpTC {
+            Decimal128OpTC{ d(150, -2, false), d(25, -1, false), ToNearestEven,
+                d(375, -2, false), nil },
+            Decimal128OpTC{ d(150, -2, false), d(125, -3, true), ToNearestEven,
+                d(19, -2, true), nil },
+            Decimal128OpTC{ d(150, -2, false), d(125, -3, true), ToNearestAway,
+                d(19, -2, true), nil },
+            Decimal128OpTC{ d(150, -2, false), d(125, -3, true), ToPositiveInf,
+                d(18, -2, true), nil },
+            Decimal128OpTC{ d(3, 0, false), d(2, 2, false), ToZero,
+                d(600, 0, false), nil },
+            Decimal128OpTC{ d(3, 0, false), d(1, -100, false), AwayFromZero,
+                d(1, 0, false), nil },
+            Decimal128OpTC{ Decimal128{ MaxUInt128, 0, false }, d(10, -1, false), ToZero,
+                Decimal128{ MaxUInt128, 0, false }, nil },
+            Decimal128OpTC{ Decimal128{ MaxUInt128, 0, false }, d(11, -1, false), ToZero,
+                Decimal128{}, ErrOverflow },
+            Decimal128OpTC{ d(1, 0, false), d(1, 39, false), ToZero,
+                Decimal128{}, ErrOverflow },
+        },
+        []Decimal128OpTC {
+            Decimal128OpTC{ d(100, -2, false), d(3, 0, false), ToNearestEven,
+                d(33, -2, false), nil },
+            Decimal128OpTC{ d(200, -2, false), d(3, 0, true), ToNearestEven,
+                d(67, -2, true), nil },
+            Decimal128OpTC{ d(200, -2, false), d(3, 0, true), ToZero,
+                d(66, -2, true), nil },
+            Decimal128OpTC{ d(1, 0, false), d(8, 0, false), ToNearestEven,
+                d(0, 0, false), nil },
+            Decimal128OpTC{ d(5, 0, false), d(2, 0, false), ToNearestEven,
+                d(2, 0, false), nil },
+            Decimal128OpTC{ d(5, 0, false), d(2, 0, false), ToNearestAway,
+                d(3, 0, false), nil },
+            Decimal128OpTC{ d(5, 0, false), d(25, -1, false), ToZero,
+                d(2, 0, false), nil },
+            Decimal128OpTC{ d(5, 0, false), d(1, 100, false), ToPositiveInf,
+                d(1, 0, false), nil },
+            Decimal128OpTC{ d(1, 0, false), d(1, -39, false), ToZero,
+                Decimal128{}, ErrOverflow },
+            Decimal128OpTC{ d(1, 0, false), d(0, 0, false), ToZero,
+                Decimal128{}, ErrDivideByZero },
+        },
+    }
+    for k, op := range ops {
+        for i, tc := range testCases[k] {
+            a, b := tc.a, tc.b
+            result, err := op(tc.a, tc.b, tc.mode)
+            if tc.expected!=result || tc.expError!=err {
+                t.Errorf("Result mismatch: %d: %s(%v,%v,%v)->%v,%v!=%v,%v",
+                         i, opNames[k], tc.a, tc.b, tc.mode, tc.expected,
+                         tc.expError, result, err)
+            }
+            if tc.a!=a || tc.b!=b {
+                t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
+                         i, a, b, tc.a, tc.b)
+            }
+        }
+    }
+}
+
+// return decimal number as big rational number
+func decimalToRat(a Decimal128) *big.Rat {
+    x := new(big.Rat).SetInt(signedBigInt(a.Coef, a.Negative))
+    p := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10),
+                big.NewInt(int64(absInt32(a.Exp))), nil))
+    if a.Exp<0 {
+        return x.Quo(x, p)
+    }
+    return x.Mul(x, p)
+}
+
+func absInt32(a int32) int32 {
+    if a<0 {
+        return -a
+    }
+    return a
+}
+
+// round rational number to decimal number with given exponent
+func decimalRoundReference(x *big.Rat, exp int32, mode RoundingMode) (Decimal128, error) {
+    v := new(big.Rat).Quo(x, decimalToRat(Decimal128{ UInt128{ 1, 0 }, exp, false }))
+    neg := v.Sign()<0
+    num := new(big.Int).Abs(v.Num())
+    q, r := new(big.Int).QuoRem(num, v.Denom(), new(big.Int))
+    c := new(big.Int).Lsh(r, 1).Cmp(v.Denom())
+    up := false
+    if r.Sign()!=0 {
+        switch mode {
+        case ToNearestEven:
+            up = c>0 || (c==0 && q.Bit(0)!=0)
+        case ToNearestAway:
+            up = c>=0
+        case ToNegativeInf:
+            up = neg
+        case ToPositiveInf:
+            up = !neg
+        case AwayFromZero:
+            up = true
+        }
+    }
+    if up {
+        q.Add(q, big.NewInt(1))
+    }
+    coef, err := UInt128FromBigInt(q)
+    if err!=nil {
+        return Decimal128{}, ErrOverflow
+    }
+    return Decimal128{ coef, exp, neg }, nil
+}
+
+func randDecimal128(rnd *rand.Rand) Decimal128 {
+    return Decimal128{ UInt128{ rnd.Uint64(), rnd.Uint64() }.Shr(uint(rnd.Intn(129))),
+        int32(rnd.Intn(101)-50), rnd.Intn(2)==0 }
+}
+
+// compare operations with big rational numbers
+func TestDecimal128Big(t *testing.T) {
+    rnd := rand.New(rand.NewSource(24))
+    for i:=0; i<3000; i++ {
+        a, b := randDecimal128(rnd), randDecimal128(rnd)
+        if i&1==0 {
+            // exponents are close
+            b.Exp = a.Exp+int32(rnd.Intn(9)-4)
+        }
+        ra, rb := decimalToRat(a), decimalToRat(b)
+        if expected, result := ra.Cmp(rb), a.Cmp(b); expected!=result {
+            t.Errorf("Result mismatch: %d: cmp(%v,%v)->%v!=%v", i, a, b, expected, result)
+        }
+        for _, mode := range decimalRoundingModes {
+            expected, expError := decimalRoundReference(ra, b.Exp, mode)
+            result, err := a.Rescale(b.Exp, mode)
+            if expError!=err || (err==nil && expected.Cmp(result)!=0) ||
+                    (err==nil && result.Exp!=b.Exp) {
+                t.Errorf("Result mismatch: %d: rescale(%v,%d,%v)->%v,%v!=%v,%v",
+                         i, a, b.Exp, mode, expected, expError, result, err)
+            }
+            expected, expError = decimalRoundReference(new(big.Rat).Add(ra, rb),
+                        a.Exp, mode)
+            result, err = a.Add(b, mode)
+            if expError!=err || (err==nil && expected.Cmp(result)!=0) {
+                t.Errorf("Result mismatch: %d: add(%v,%v,%v)->%v,%v!=%v,%v",
+                         i, a, b, mode, expected, expError, result, err)
+            }
+            expected, expError = decimalRoundReference(new(big.Rat).Sub(ra, rb),
+                        a.Exp, mode)
+            result, err = a.Sub(b, mode)
+            if expError!=err || (err==nil && expected.Cmp(result)!=0) {
+                t.Errorf("Result mismatch: %d: sub(%v,%v,%v)->%v,%v!=%v,%v",
+                         i, a, b, mode, expected, expError, result, err)
+            }
+            expected, expError = decimalRoundReference(new(big.Rat).Mul(ra, rb),
+                        a.Exp, mode)
+            result, err = a.Mul(b, mode)
+            if expError!=err || (err==nil && expected.Cmp(result)!=0) {
+                t.Errorf("Result mismatch: %d: mul(%v,%v,%v)->%v,%v!=%v,%v",
+                         i, a, b, mode, expected, expError, result, err)
+            }
+            if b.IsZero() {
+                continue
+            }
+            expected, expError = decimalRoundReference(new(big.Rat).Quo(ra, rb),
+                        a.Exp, mode)
+            result, err = a.Quo(b, mode)
+            if expError!=err || (err==nil && expected.Cmp(result)!=0) {
+                t.Errorf("Result mismatch: %d: quo(%v,%v,%v)->%v,%v!=%v,%v",
+                         i, a, b, mode, expected, expError, result, err)
+            }
+        }
+    }
+}
+
+type Decimal128FormatTC struct {
+    value Decimal128
+    expected string
+}
+
+func TestDecimal128Format(t *testing.T) {
+    testCases := []Decimal128FormatTC {
+        Decimal128FormatTC{ Decimal128{ UInt128{ 0, 0 }, 0, false }, "0" },
+        Decimal128FormatTC{ Decimal128{ UInt128{ 0, 0 }, -3, true }, "0.000" },
+        Decimal128FormatTC{ Decimal128{ UInt128{ 0, 0 }, 3, false }, "0e+3" },
+        Decimal128FormatTC{ Decimal128{ UInt128{ 0, 0 }, -7, false }, "0e-7" },
+        Decimal128FormatTC{ Decimal128{ UInt128{ 123456, 0 }, -3, false }, "123.456" },
+        Decimal128FormatTC{ Decimal128{ UInt128{ 123456, 0 }, -3, true }, "-123.456" },
+        Decimal128FormatTC{ Decimal128{ UInt128{ 123456, 0 }, -6, false }, "0.123456" },
+        Decimal128FormatTC{ Decimal128{ UInt128{ 123456, 0 }, -8, true }, "-0.00123456" },
+        Decimal128FormatTC{ Decimal128{ UInt128{ 123456, 0 }, 0, false }, "123456" },
+        Decimal128FormatTC{ Decimal128{ UInt128{ 123456, 0 }, 4, false }, "1.23456e+9" },
+        Decimal128FormatTC{ Decimal128{ UInt128{ 120, 0 }, 3, false }, "1.20e+5" },
+        Decimal128FormatTC{ Decimal128{ UInt128{ 7, 0 }, 1, true }, "-7e+1" },
+        Decimal128FormatTC{ Decimal128{ UInt128{ 123456, 0 }, -11, false }, "0.00000123456" },
+        Decimal128FormatTC{ Decimal128{ UInt128{ 123456, 0 }, -12, false }, "1.23456e-7" },
+        Decimal128FormatTC{ Decimal128{ UInt128{ 1, 0 }, math.MaxInt32, false },
+            "1e+2147483647" },
+        Decimal128FormatTC{ Decimal128{ MaxUInt128, math.MaxInt32, false },
+            "3.40282366920938463463374607431768211455e+2147483685" },
+        Decimal128FormatTC{ Decimal128{ MaxUInt128, math.MinInt32, true },
+            "-3.40282366920938463463374607431768211455e-2147483610" },
+        Decimal128FormatTC{ Decimal128{ UInt128{ 1500000000000000000, 0 }, -18, false },
+            "1.500000000000000000" },
+        Decimal128FormatTC{ Decimal128{ MaxUInt128, -38, false },
+            "3.40282366920938463463374607431768211455" },
+        Decimal128FormatTC{ Decimal128{ MaxUInt128, -40, true },
+            "-0.0340282366920938463463374607431768211455" },
+    }
+    for i, tc := range testCases {
+        a := tc.value
+        result := tc.value.String()
+        if tc.expected!=result {
+            t.Errorf("Result mismatch: %d: format(%v)->%v!=%v",
+                     i, tc.value, tc.expected, result)
+        }
+        if tc.value!=a {
+            t.Errorf("Argument has been modified: %d: %v!=%v", i, a, tc.value)
+        }
+    }
+}
+
+type Decimal128ParseTC struct {
+    str string
+    expected Decimal128
+    expError error
+}
+
+func TestDecimal128Parse(t *testing.T) {
+    testCases := []Decimal128ParseTC {
+        Decimal128ParseTC{ "0", Decimal128{ UInt128{ 0, 0 }, 0, false }, nil },
+        Decimal128ParseTC{ "123.456", Decimal128{ UInt128{ 123456, 0 }, -3, false }, nil },
+        Decimal128ParseTC{ "-123.450", Decimal128{ UInt128{ 123450, 0 }, -3, true }, nil },
+        Decimal128ParseTC{ "+.5", Decimal128{ UInt128{ 5, 0 }, -1, false }, nil },
+        Decimal128ParseTC{ "7.", Decimal128{ UInt128{ 7, 0 }, 0, false }, nil },
+        Decimal128ParseTC{ "0.000", Decimal128{ UInt128{ 0, 0 }, -3, false }, nil },
+        Decimal128ParseTC{ "1.5e3", Decimal128{ UInt128{ 15, 0 }, 2, false }, nil },
+        Decimal128ParseTC{ "-15E-3", Decimal128{ UInt128{ 15, 0 }, -3, true }, nil },
+        Decimal128ParseTC{ "12e+0", Decimal128{ UInt128{ 12, 0 }, 0, false }, nil },
+        Decimal128ParseTC{ "0000000000000000000000000000000000000000001.0",
+            Decimal128{ UInt128{ 10, 0 }, -1, false }, nil },
+        Decimal128ParseTC{ "3.40282366920938463463374607431768211455",
+            Decimal128{ MaxUInt128, -38, false }, nil },
+        Decimal128ParseTC{ "1e2147483647",
+            Decimal128{ UInt128{ 1, 0 }, 2147483647, false }, nil },
+        Decimal128ParseTC{ "1e-2147483648",
+            Decimal128{ UInt128{ 1, 0 }, -2147483648, false }, nil },
+        Decimal128ParseTC{ "3.40282366920938463463374607431768211456",
+            Decimal128{}, strconv.ErrRange },
+        Decimal128ParseTC{ "1e2147483648", Decimal128{}, strconv.ErrRange },
+        Decimal128ParseTC{ "0.1e-2147483648", Decimal128{}, strconv.ErrRange },
+        Decimal128ParseTC{ "1e1000000000000000000000", Decimal128{}, strconv.ErrRange },
+        Decimal128ParseTC{ "", Decimal128{}, strconv.ErrSyntax },
+        Decimal128ParseTC{ "-", Decimal128{}, strconv.ErrSyntax },
+        Decimal128ParseTC{ ".", Decimal128{}, strconv.ErrSyntax },
+        Decimal128ParseTC{ "1.2.3", Decimal128{}, strconv.ErrSyntax },
+        Decimal128ParseTC{ "1e", Decimal128{}, strconv.ErrSyntax },
+        Decimal128ParseTC{ "1e+", Decimal128{}, strconv.ErrSyntax },
+        Decimal128ParseTC{ "--1", Decimal128{}, strconv.ErrSyntax },
+        Decimal128ParseTC{ "1.5x", Decimal128{}, strconv.ErrSyntax },
+    }
+    for i, tc := range testCases {
+        result, err := ParseDecimal128(tc.str)
+        if tc.expected!=result || !errorMatch(tc.expError, err) {
+            t.Errorf("Result mismatch: %d: parse(%v)->%v,%v!=%v,%v",
+                     i, tc.str, tc.expected, tc.expError, result, err)
+        }
+        result, err = ParseDecimal128Bytes([]byte(tc.str))
+        if tc.expected!=result || !errorMatch(tc.expError, err) {
+            t.Errorf("Result mismatch: %d: parseBytes(%v)->%v,%v!=%v,%v",
+                     i, tc.str, tc.expected, tc.expError, result, err)
+        }
+    }
+}
+
+func TestDecimal128ParseNumError(t *testing.T) {
+    _, err := ParseDecimal128("-34028236692093846346337.4607431768211456")
+    expected := NumError{ "ParseDecimal128", "-34028236692093846346337.4607431768211456",
+        40, strconv.ErrRange }
+    if numErr, ok := err.(*NumError); !ok || *numErr!=expected {
+        t.Errorf("Result mismatch: %v!=%v", expected, err)
+    }
+    _, err = ParseDecimal128Bytes([]byte("12.5e4x"))
+    expected = NumError{ "ParseDecimal128Bytes", "12.5e4x", 6, strconv.ErrSyntax }
+    if numErr, ok := err.(*NumError); !ok || *numErr!=expected {
+        t.Errorf("Result mismatch: %v!=%v", expected, err)
+    }
+    _, err = ParseDecimal128("12.5e-2147483648")
+    expected = NumError{ "ParseDecimal128", "12.5e-2147483648", 4, strconv.ErrRange }
+    if numErr, ok := err.(*NumError); !ok || *numErr!=expected {
+        t.Errorf("Result mismatch: %v!=%v", expected, err)
+    }
+}
+
+type Decimal128MarshalTC struct {
+    value Decimal128
+    expected []byte
+}
+
+func TestDecimal128MarshalText(t *testing.T) {
+    testCases := []Decimal128MarshalTC{
+        Decimal128MarshalTC{ Decimal128{ UInt128{ 123456, 0 }, -3, false },
+            []byte("123.456") },
+        Decimal128MarshalTC{ Decimal128{ UInt128{ 1500000000000000000, 0 }, -18, true },
+            []byte("-1.500000000000000000") },
+    }
+    for i, tc := range testCases {
+        result, err := tc.value.MarshalText()
+        if err!=nil {
+            t.Errorf("MarshalText returns error: %v", err)
+        }
+        if !bytes.Equal(tc.expected, result) {
+            t.Errorf("Result mismatch: %d: marshaltext(%v)->%v!=%v",
+                     i, tc.value, tc.expected, result)
+        }
+        var v Decimal128
+        err = v.UnmarshalText(tc.expected)
+        if tc.value!=v || err!=nil {
+            t.Errorf("Result mismatch: %d: unmarshaltext(%v)->%v!=%v,%v",
+                     i, tc.expected, tc.value, v, err)
+        }
+    }
+}
+
+func TestDecimal128MarshalJSON(t *testing.T) {
+    testCases := []Decimal128MarshalTC{
+        Decimal128MarshalTC{ Decimal128{ UInt128{ 123456, 0 }, -3, false },
+            []byte("\"123.456\"") },
+        Decimal128MarshalTC{ Decimal128{ UInt128{ 0x65fe6ed7fbdd8246, 0x73e }, -18, true },
+            []byte("\"-34207.612946173346546246\"") },
+    }
+    for i, tc := range testCases {
+        result, err := tc.value.MarshalJSON()
+        if err!=nil {
+            t.Errorf("MarshalJSON returns error: %v", err)
+        }
+        if !bytes.Equal(tc.expected, result) {
+            t.Errorf("Result mismatch: %d: marshaljson(%v)->%v!=%v",
+                     i, tc.value, tc.expected, result)
+        }
+        var v Decimal128
+        err = v.UnmarshalJSON(tc.expected)
+        if tc.value!=v || err!=nil {
+            t.Errorf("Result mismatch: %d: unmarshaljson(%v)->%v!=%v,%v",
+                     i, tc.expected, tc.value, v, err)
+        }
+    }
+    // JSON number
+    type Data struct {
+        Price Decimal128
+    }
+    var data Data
+    err := json.Unmarshal([]byte("{\"Price\":-12.50}"), &data)
+    expected := Decimal128{ UInt128{ 1250, 0 }, -2, true }
+    if err!=nil || data.Price!=expected {
+        t.Errorf("Result mismatch: unmarshal->%v!=%v,%v", expected, data.Price, err)
+    }
+    out, err := json.Marshal(data)
+    if err!=nil || string(out)!="{\"Price\":\"-12.50\"}" {
+        t.Errorf("Result mismatch: marshal->%v!=%v,%v",
+                 "{\"Price\":\"-12.50\"}", string(out), err)
+    }
+}
+
+// output for huge exponents must be short and parseable to same value
+func TestDecimal128LargeExponent(t *testing.T) {
+    for _, str := range []string { "1e100000000", "-12.5e-100000000", "0e2147483647",
+            "1e2147483647", "9.99e-2147483646" } {
+        a, err := ParseDecimal128(str)
+        if err!=nil {
+            t.Fatalf("Can't parse %v: %v", str, err)
+        }
+        out, err := a.MarshalJSON()
+        if err!=nil || len(out)>64 {
+            t.Errorf("Result mismatch: marshaljson(%v)->%d bytes,%v", str, len(out), err)
+        }
+        var v Decimal128
+        if err = v.UnmarshalJSON(out); v!=a || err!=nil {
+            t.Errorf("Result mismatch: unmarshaljson(%s)->%v!=%v,%v", out, a, v, err)
+        }
+    }
+    rnd := rand.New(rand.NewSource(24))
+    for i:=0; i<2000; i++ {
+        a := Decimal128{ UInt128{ rnd.Uint64(), rnd.Uint64() }.Shr(uint(rnd.Intn(128))),
+                int32(rnd.Uint32()), rnd.Intn(2)==0 }
+        if i&1==0 {
+            a.Exp = int32(rnd.Intn(90)-60)
+        }
+        if a.Coef.IsZero() {
+            a.Negative = false
+        }
+        str := a.String()
+        if result, err := ParseDecimal128(str); result!=a || err!=nil {
+            t.Errorf("Result mismatch: %d: parse(%v)->%v!=%v,%v", i, str, a, result, err)
+        }
+    }
+}
+
+func BenchmarkDecimal128Add(b *testing.B) {
+    a := Decimal128{ UInt128{ 0x65fe6ed7fbdd8246, 0x73e }, -18, false }
+    c := Decimal128{ UInt128{ 0x892f902bd23f0824, 0x5d }, -20, true }
+    for i := 0; i < b.N; i++ {
+        a.Add(c, ToNearestEven)
+    }
+}
+
+func BenchmarkDecimal128Mul(b *testing.B) {
+    a := Decimal128{ UInt128{ 0x65fe6ed7fbdd8246, 0x73e }, -18, false }
+    c := Decimal128{ UInt128{ 0x892f902bd23f0824, 0x5d }, -18, true }
+    for i := 0; i < b.N; i++ {
+        a.Mul(c, ToNearestEven)
+    }
+}
+
+func BenchmarkDecimal128Quo(b *testing.B) {
+    a := Decimal128{ UInt128{ 0x65fe6ed7fbdd8246, 0x73e }, -18, false }
+    c := Decimal128{ UInt128{ 0x892f902bd23f0824, 0x5d }, -18, true }
+    for i := 0; i < b.N; i++ {
+        a.Quo(c, ToNearestEven)
+    }
+}
+
+func BenchmarkDecimal128Format(b *testing.B) {
+    a := Decimal128{ UInt128{ 0x65fe6ed7fbdd8246, 0x73e }, -18, false }
+    var buf [64]byte
+    for i := 0; i < b.N; i++ {
+        a.AppendFormat(buf[:0])
+    }
+}
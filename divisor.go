@@ -0,0 +1,167 @@
+/*
+ * divisor.go - division by precomputed (invariant) divisor
+ *
+ * goint128 - go int128 library
+ * Copyright (C) 2020  Mateusz Szpakowski
+ *
+ * This library is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU Lesser General Public
+ * License as published by the Free Software Foundation; either
+ * version 2.1 of the License, or (at your option) any later version.
+ *
+ * This library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+ * Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public
+ * License along with this library; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301  USA
+ */
+
+// Package to operate on 128-bit integers
+package goint128
+
+import (
+    "math/bits"
+)
+
+// divide 128-bit (u1,u0) by normalized 64-bit divisor d using its reciprocal
+// v = (2^128-1)/d - 2^64 and return quotient and remainder.
+// u1 must be lesser than d (Moller-Granlund algorithm)
+func divWord2by1(u1, u0, d, v uint64) (uint64, uint64) {
+    q1, q0 := Mul64(v, u1)
+    var carry uint64
+    q0, carry = Add64(q0, u0, 0)
+    q1, _ = Add64(q1, u1, carry)
+    q1++
+    r := u0 - q1*d
+    if r>q0 {
+        q1--
+        r += d
+    }
+    if r>=d {
+        q1++
+        r -= d
+    }
+    return q1, r
+}
+
+// divide 192-bit (u2,u1,u0) by normalized 128-bit divisor d using its reciprocal
+// v = (2^192-1)/d - 2^64 and return 64-bit quotient and remainder.
+// (u2,u1) must be lesser than d (Moller-Granlund algorithm)
+func divWord3by2(u2, u1, u0 uint64, d UInt128, v uint64) (uint64, UInt128) {
+    q1, q0 := Mul64(v, u2)
+    var carry uint64
+    q0, carry = Add64(q0, u1, 0)
+    q1, _ = Add64(q1, u2, carry)
+    t1, t0 := Mul64(d[0], q1)
+    // (r1,r0) = (u1-q1*d1,u0) - (t1,t0) - d
+    var r0, r1, borrow uint64
+    r0, borrow = Sub64(u0, t0, 0)
+    r1, _ = Sub64(u1 - q1*d[1], t1, borrow)
+    r0, borrow = Sub64(r0, d[0], 0)
+    r1, _ = Sub64(r1, d[1], borrow)
+    q1++
+    if r1>=q0 {
+        q1--
+        r0, carry = Add64(r0, d[0], 0)
+        r1, _ = Add64(r1, d[1], carry)
+    }
+    if r1>d[1] || (r1==d[1] && r0>=d[0]) {
+        q1++
+        r0, borrow = Sub64(r0, d[0], 0)
+        r1, _ = Sub64(r1, d[1], borrow)
+    }
+    return q1, UInt128{ r0, r1 }
+}
+
+// divisor with precomputed reciprocal. it performs division by multiplications
+// (faster than division by the same divisor by Div64, DivMod or UInt128DivFull)
+type Divisor128 struct {
+    d UInt128 // divisor
+    dn UInt128 // normalized divisor (shifted left to set highest bit)
+    v uint64 // reciprocal of normalized divisor
+    shift uint // shift of normalized divisor
+}
+
+// create divisor with precomputed reciprocal. return ErrDivideByZero
+// if divisor is zero
+func NewDivisor128(d UInt128) (*Divisor128, error) {
+    if d[1]==0 {
+        if d[0]==0 {
+            return nil, ErrDivideByZero
+        }
+        // 64-bit divisor: reciprocal (2^128-1)/dn - 2^64
+        shift := uint(bits.LeadingZeros64(d[0]))
+        dn := d[0]<<shift
+        v, _ := Div64(^dn, 0xffffffffffffffff, dn)
+        return &Divisor128{ d, UInt128{ dn, 0 }, v, shift }, nil
+    }
+    // 128-bit divisor: reciprocal (2^192-1)/dn - 2^64
+    shift := uint(bits.LeadingZeros64(d[1]))
+    dn := d.Shl(shift)
+    v, _ := UInt128DivFull(UInt128{ 0xffffffffffffffff, 0 }, maxUInt128, dn)
+    return &Divisor128{ d, dn, v[0], shift }, nil
+}
+
+// return divisor
+func (dv *Divisor128) Divisor() UInt128 {
+    return dv.d
+}
+
+// divide 128-bit unsigned integer by divisor and return quotient and remainder
+func (dv *Divisor128) DivMod(a UInt128) (UInt128, UInt128) {
+    // normalize dividend (shift by 64 bits gives zero)
+    s := dv.shift
+    u2 := a[1]>>(64-s)
+    u1 := (a[1]<<s) | (a[0]>>(64-s))
+    u0 := a[0]<<s
+    if dv.d[1]==0 {
+        var q UInt128
+        var r uint64
+        q[1], r = divWord2by1(u2, u1, dv.dn[0], dv.v)
+        q[0], r = divWord2by1(r, u0, dv.dn[0], dv.v)
+        return q, UInt128{ r>>s, 0 }
+    }
+    q, r := divWord3by2(u2, u1, u0, dv.dn, dv.v)
+    return UInt128{ q, 0 }, r.Shr(s)
+}
+
+// divide 128-bit unsigned integer by divisor and return quotient
+func (dv *Divisor128) Div(a UInt128) UInt128 {
+    q, _ := dv.DivMod(a)
+    return q
+}
+
+// divide 128-bit unsigned integer by divisor and return remainder
+func (dv *Divisor128) Mod(a UInt128) UInt128 {
+    _, r := dv.DivMod(a)
+    return r
+}
+
+// divide 256-bit unsigned integer (hi,lo) by divisor and return quotient
+// and remainder like UInt128DivFull. panics if quotient overflows 128 bits
+func (dv *Divisor128) DivFull(hi, lo UInt128) (UInt128, UInt128) {
+    if hi.Cmp(dv.d)>=0 {
+        panic("Divide overflow")
+    }
+    // normalize dividend (shift by 64 bits gives zero)
+    s := dv.shift
+    u3 := (hi[1]<<s) | (hi[0]>>(64-s))
+    u2 := (hi[0]<<s) | (lo[1]>>(64-s))
+    u1 := (lo[1]<<s) | (lo[0]>>(64-s))
+    u0 := lo[0]<<s
+    var q UInt128
+    if dv.d[1]==0 {
+        // u3 is zero, because hi is lesser than divisor
+        var r uint64
+        q[1], r = divWord2by1(u2, u1, dv.dn[0], dv.v)
+        q[0], r = divWord2by1(r, u0, dv.dn[0], dv.v)
+        return q, UInt128{ r>>s, 0 }
+    }
+    var r UInt128
+    q[1], r = divWord3by2(u3, u2, u1, dv.dn, dv.v)
+    q[0], r = divWord3by2(r[1], r[0], u0, dv.dn, dv.v)
+    return q, r.Shr(s)
+}
@@ -0,0 +1,151 @@
+/*
+ * divisor_test.go - tests for division by precomputed divisor
+ *
+ * goint128 - go int128 library
+ * Copyright (C) 2020  Mateusz Szpakowski
+ *
+ * This library is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU Lesser General Public
+ * License as published by the Free Software Foundation; either
+ * version 2.1 of the License, or (at your option) any later version.
+ *
+ * This library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+ * Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public
+ * License along with this library; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301  USA
+ */
+
+package goint128
+
+import (
+    "math/rand"
+    "testing"
+)
+
+func TestDivisor128DivMod(t *testing.T) {
+    testCases := []UInt128DivMTC {
+        UInt128DivMTC{ UInt128{ 58, 0 }, UInt128{ 7, 0 }, UInt128{ 8, 0 }, UInt128{ 2, 0 } },
+        UInt128DivMTC{ MaxUInt128, UInt128{ 1, 0 }, MaxUInt128, UInt128{} },
+        UInt128DivMTC{ MaxUInt128, UInt128{ 0xffffffffffffffff, 0 },
+            UInt128{ 1, 1 }, UInt128{} },
+        UInt128DivMTC{ MaxUInt128, UInt128{ 0x8000000000000000, 0 },
+            UInt128{ 0xffffffffffffffff, 1 }, UInt128{ 0x7fffffffffffffff, 0 } },
+        UInt128DivMTC{ MaxUInt128, MaxUInt128, UInt128{ 1, 0 }, UInt128{} },
+        UInt128DivMTC{ MaxUInt128.Sub64(1), MaxUInt128, UInt128{}, MaxUInt128.Sub64(1) },
+        UInt128DivMTC{ MaxUInt128, UInt128{ 0, 1 }, UInt128{ 0xffffffffffffffff, 0 },
+            UInt128{ 0xffffffffffffffff, 0 } },
+        UInt128DivMTC{ UInt128{ 0x892f902bd23f0824, 0x5d9dc9f81818e811 },
+            UInt128{ 0x0ed904759531985d, 0x8e8e25d94 },
+            UInt128{ 0xa81da37, 0 }, UInt128{ 0xb3af5beb1ffb1a29, 0x45b96c315 } },
+    }
+    for i, tc := range testCases {
+        a, b := tc.a, tc.b
+        dv, _ := NewDivisor128(tc.b)
+        result, resultRem := dv.DivMod(tc.a)
+        if tc.expected!=result || tc.expRem!=resultRem {
+            t.Errorf("Result mismatch: %d: divisor.divmod(%v,%v)->%v,%v!=%v,%v",
+                     i, tc.a, tc.b, tc.expected, tc.expRem, result, resultRem)
+        }
+        if tc.a!=a || tc.b!=b {
+            t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
+                     i, a, b, tc.a, tc.b)
+        }
+    }
+}
+
+// compare division by precomputed divisor with Div64, DivMod and UInt128DivFull
+func TestDivisor128Random(t *testing.T) {
+    rnd := rand.New(rand.NewSource(19))
+    for i:=0; i<5000; i++ {
+        a := UInt128{ rnd.Uint64(), rnd.Uint64() }.Shr(uint(rnd.Intn(128)))
+        lo := UInt128{ rnd.Uint64(), rnd.Uint64() }
+        b := UInt128{ rnd.Uint64(), rnd.Uint64() }.Shr(uint(rnd.Intn(128))).Add64(1)
+        if b.IsZero() {
+            b = UInt128{ 1, 0 }
+        }
+        dv, err := NewDivisor128(b)
+        if err!=nil || dv.Divisor()!=b {
+            t.Fatalf("Result mismatch: NewDivisor128(%v)->%v", b, err)
+        }
+        expected, expRem := a.DivMod(b)
+        if b[1]==0 {
+            var rem64 uint64
+            expected, rem64 = a.Div64(b[0])
+            expRem = UInt128{ rem64, 0 }
+        }
+        result, resultRem := dv.DivMod(a)
+        if expected!=result || expRem!=resultRem {
+            t.Errorf("Result mismatch: divisor.divmod(%v,%v)->%v,%v!=%v,%v",
+                     a, b, expected, expRem, result, resultRem)
+        }
+        if dv.Div(a)!=expected || dv.Mod(a)!=expRem {
+            t.Errorf("Result mismatch: divisor.div/mod(%v,%v)->%v,%v!=%v,%v",
+                     a, b, expected, expRem, dv.Div(a), dv.Mod(a))
+        }
+        // high part must be lesser than divisor
+        hi := a.Mod(b)
+        expected, expRem = UInt128DivFull(hi, lo, b)
+        result, resultRem = dv.DivFull(hi, lo)
+        if expected!=result || expRem!=resultRem {
+            t.Errorf("Result mismatch: divisor.divfull(%v,%v,%v)->%v,%v!=%v,%v",
+                     hi, lo, b, expected, expRem, result, resultRem)
+        }
+    }
+}
+
+func TestDivisor128Errors(t *testing.T) {
+    if _, err := NewDivisor128(UInt128{}); err!=ErrDivideByZero {
+        t.Errorf("Result mismatch: NewDivisor128(0)->%v", err)
+    }
+    for i, b := range []UInt128{ UInt128{ 7, 0 }, UInt128{ 7, 1 } } {
+        dv, _ := NewDivisor128(b)
+        paniced, panicStr := getPanic2(func() { dv.DivFull(b, UInt128{}) })
+        if !paniced || panicStr!="Divide overflow" {
+            t.Errorf("Result mismatch: %d: panic->%v,%v", i, paniced, panicStr)
+        }
+    }
+}
+
+func BenchmarkDivisor128DivMod64(b *testing.B) {
+    a := UInt128{ 0x892f902bd23f0824, 0x5d9dc9f81818e811 }
+    dv, _ := NewDivisor128(UInt128{ 0x0ed904759531985d, 0 })
+    for i := 0; i < b.N; i++ {
+        dv.DivMod(a)
+    }
+}
+
+func BenchmarkUInt128Div64Large(b *testing.B) {
+    a := UInt128{ 0x892f902bd23f0824, 0x5d9dc9f81818e811 }
+    for i := 0; i < b.N; i++ {
+        a.Div64(0x0ed904759531985d)
+    }
+}
+
+func BenchmarkDivisor128DivMod(b *testing.B) {
+    a := UInt128{ 0x892f902bd23f0824, 0x5d9dc9f81818e811 }
+    dv, _ := NewDivisor128(UInt128{ 0x0ed904759531985d, 0x8e8e25d94 })
+    for i := 0; i < b.N; i++ {
+        dv.DivMod(a)
+    }
+}
+
+func BenchmarkDivisor128DivFull(b *testing.B) {
+    hi := UInt128{ 0x892f902bd23f0824, 0x5d9dc9f81818e811 }
+    lo := UInt128{ 0x1c9e66c000000000, 0xe1b1e5f90f944d6e }
+    dv, _ := NewDivisor128(prime128)
+    for i := 0; i < b.N; i++ {
+        dv.DivFull(hi, lo)
+    }
+}
+
+func BenchmarkUInt128DivFull(b *testing.B) {
+    hi := UInt128{ 0x892f902bd23f0824, 0x5d9dc9f81818e811 }
+    lo := UInt128{ 0x1c9e66c000000000, 0xe1b1e5f90f944d6e }
+    for i := 0; i < b.N; i++ {
+        UInt128DivFull(hi, lo, prime128)
+    }
+}
@@ -0,0 +1,463 @@
+/*
+ * fixed.go - fixed-point Q64.64 numbers
+ *
+ * goint128 - go int128 library
+ * Copyright (C) 2020  Mateusz Szpakowski
+ *
+ * This library is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU Lesser General Public
+ * License as published by the Free Software Foundation; either
+ * version 2.1 of the License, or (at your option) any later version.
+ *
+ * This library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+ * Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public
+ * License along with this library; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301  USA
+ */
+
+// Package to operate on 128-bit integers
+package goint128
+
+import (
+    "math"
+    "strconv"
+)
+
+// unsigned fixed-point number with 64 fractional bits (Q64.64). value is
+// 128-bit unsigned integer divided by 2^64 (first fraction, second integer part)
+type Fixed64x64 UInt128
+
+// signed fixed-point number with 64 fractional bits (Q64.64). value is
+// 128-bit signed integer divided by 2^64 (first fraction, second integer part)
+type SFixed64x64 Int128
+
+// convert 64-bit unsigned integer to fixed-point number
+func Fixed64x64FromUInt64(a uint64) Fixed64x64 {
+    return Fixed64x64{ 0, a }
+}
+
+// return integer part of fixed-point number (truncated)
+func (a Fixed64x64) IntPart() uint64 {
+    return a[1]
+}
+
+// return fractional part of fixed-point number as 64-bit fraction (fraction*2^64)
+func (a Fixed64x64) FracPart() uint64 {
+    return a[0]
+}
+
+// add fixed-point numbers
+func (a Fixed64x64) Add(b Fixed64x64) Fixed64x64 {
+    return Fixed64x64(UInt128(a).Add(UInt128(b)))
+}
+
+// subtract fixed-point numbers
+func (a Fixed64x64) Sub(b Fixed64x64) Fixed64x64 {
+    return Fixed64x64(UInt128(a).Sub(UInt128(b)))
+}
+
+// add fixed-point numbers and return sum or error if overflow
+func (a Fixed64x64) AddChecked(b Fixed64x64) (Fixed64x64, error) {
+    c, err := UInt128(a).AddChecked(UInt128(b))
+    return Fixed64x64(c), err
+}
+
+// subtract fixed-point numbers and return difference or error if overflow
+func (a Fixed64x64) SubChecked(b Fixed64x64) (Fixed64x64, error) {
+    c, err := UInt128(a).SubChecked(UInt128(b))
+    return Fixed64x64(c), err
+}
+
+// compare fixed-point numbers and return 0 if they equal,
+// 1 if first is greater than second, or -1 if first is lesser than second
+func (a Fixed64x64) Cmp(b Fixed64x64) int {
+    return UInt128(a).Cmp(UInt128(b))
+}
+
+// return true if zero
+func (a Fixed64x64) IsZero() bool {
+    return a[0]==0 && a[1]==0
+}
+
+// multiply fixed-point numbers and return product truncated to 64 fractional
+// bits and lower 64 bits of integer part
+func (a Fixed64x64) Mul(b Fixed64x64) Fixed64x64 {
+    hi, lo := UInt128(a).MulFull(UInt128(b))
+    return Fixed64x64{ lo[1], hi[0] }
+}
+
+// multiply fixed-point numbers and return product truncated to 64 fractional
+// bits or error if overflow
+func (a Fixed64x64) MulChecked(b Fixed64x64) (Fixed64x64, error) {
+    hi, lo := UInt128(a).MulFull(UInt128(b))
+    if hi[1]!=0 {
+        return Fixed64x64{}, ErrOverflow
+    }
+    return Fixed64x64{ lo[1], hi[0] }, nil
+}
+
+// divide fixed-point numbers and return quotient truncated to 64 fractional bits.
+// panics if divisor is zero or if quotient overflows
+func (a Fixed64x64) Div(b Fixed64x64) Fixed64x64 {
+    // (a*2^64)/b
+    q, _ := UInt128DivFull(UInt128{ a[1], 0 }, UInt128{ 0, a[0] }, UInt128(b))
+    return Fixed64x64(q)
+}
+
+// divide fixed-point numbers and return quotient truncated to 64 fractional
+// bits or error (ErrDivideByZero or ErrOverflow)
+func (a Fixed64x64) DivChecked(b Fixed64x64) (Fixed64x64, error) {
+    if b[0]==0 && b[1]==0 {
+        return Fixed64x64{}, ErrDivideByZero
+    }
+    if b[1]==0 && a[1]>=b[0] {
+        return Fixed64x64{}, ErrOverflow
+    }
+    return a.Div(b), nil
+}
+
+// append decimal form of fixed-point number to bytes. if prec is not negative
+// then number is rounded (to nearest, ties to even) to prec fractional digits,
+// otherwise all fractional digits are appended (exact value without trailing zeroes)
+func (a Fixed64x64) AppendFormatPrec(dst []byte, prec int) []byte {
+    ip := UInt128{ a[1], 0 }
+    f := a[0]
+    // every fraction has at most 64 decimal digits
+    var chars [64]byte
+    n := 0
+    for f!=0 && (prec<0 || n<prec) {
+        var d uint64
+        d, f = Mul64(f, 10)
+        chars[n] = byte('0'+d)
+        n++
+    }
+    if prec>=0 {
+        // round remainder (fraction of last digit)
+        odd := ip[0]&1!=0
+        if n!=0 {
+            odd = chars[n-1]&1!=0
+        }
+        if f>1<<63 || (f==1<<63 && odd) {
+            i := n-1
+            for ; i>=0 && chars[i]=='9'; i-- {
+                chars[i] = '0'
+            }
+            if i>=0 {
+                chars[i]++
+            } else {
+                ip = ip.Add64(1)
+            }
+        }
+    }
+    dst = ip.AppendFormat(dst)
+    if n!=0 || prec>0 {
+        dst = append(dst, '.')
+        dst = append(dst, chars[:n]...)
+        for ; n<prec; n++ {
+            dst = append(dst, '0')
+        }
+    }
+    return dst
+}
+
+// format fixed-point number to string. rules are same as in AppendFormatPrec
+func (a Fixed64x64) FormatPrec(prec int) string {
+    return string(a.AppendFormatPrec(nil, prec))
+}
+
+// parse unsigned decimal fixed-point number ([digits][.digits]) from string and
+// return value, offset of first bad character and error. fraction is rounded
+// to nearest (ties to even). value must not be greater than limit
+func parseFixed64x64(str string, limit UInt128) (UInt128, int, error) {
+    slen := len(str)
+    dot := 0
+    for dot<slen && str[dot]!='.' {
+        dot++
+    }
+    if slen==0 || (dot==0 && slen==1) {
+        return UInt128{}, 0, strconv.ErrSyntax
+    }
+    var v UInt128
+    if dot!=0 {
+        var offset int
+        var err error
+        v, offset, err = parseUInt128(str[:dot])
+        if err==nil && (v[1]!=0 || v[0]>limit[1]) {
+            err = strconv.ErrRange
+            offset = decimalRangeOffset(str[:dot], UInt128{ limit[1], 0 })
+        } else if err==strconv.ErrRange {
+            // digits before offset are valid digits
+            offset = decimalRangeOffset(str[:offset+1], UInt128{ limit[1], 0 })
+        }
+        if err!=nil {
+            return UInt128{}, offset, err
+        }
+    }
+    v = UInt128{ 0, v[0] }
+    for i:=dot+1; i<slen; i++ {
+        if str[i]-'0'>9 {
+            return UInt128{}, i, strconv.ErrSyntax
+        }
+    }
+    // compute floor(fraction*2^128) from last digit: f = (d*2^128 + f) / 10
+    var f UInt128
+    var rem uint64
+    sticky := false
+    for i:=slen-1; i>dot; i-- {
+        f[1], rem = Div64(uint64(str[i]-'0'), f[1], 10)
+        f[0], rem = Div64(rem, f[0], 10)
+        sticky = sticky || rem!=0
+    }
+    v[0] = f[1]
+    // round to nearest, ties to even
+    if f[0]>1<<63 || (f[0]==1<<63 && (sticky || v[0]&1!=0)) {
+        var carry uint64
+        v, carry = v.AddC(UInt128{ 1, 0 }, 0)
+        if carry!=0 {
+            return UInt128{}, dot+1, strconv.ErrRange
+        }
+    }
+    if v.Cmp(limit)>0 {
+        return UInt128{}, dot+1, strconv.ErrRange
+    }
+    return v, 0, nil
+}
+
+// parse unsigned decimal fixed-point number from string and return value and
+// error (nil if no error). fraction can have any number of digits and it is
+// rounded to nearest 64-bit fraction (ties to even)
+func ParseFixed64x64(str string) (Fixed64x64, error) {
+    v, offset, err := parseFixed64x64(str, maxUInt128)
+    if err!=nil {
+        return Fixed64x64{}, numError("ParseFixed64x64", str, offset, err)
+    }
+    return Fixed64x64(v), nil
+}
+
+// convert fixed-point number to 64-bit float point value (rounded to nearest)
+func (a Fixed64x64) ToFloat64() float64 {
+    return math.Ldexp(UInt128(a).ToFloat64(), -64)
+}
+
+// convert 64-bit float point value to fixed-point number (rounded to nearest).
+// return strconv.ErrRange if rounded value is negative, out of range or NaN
+func Fixed64x64FromFloat64(a float64) (Fixed64x64, error) {
+    v, _, err := Float64ToUInt128Round(math.Ldexp(a, 64), ToNearestEven)
+    return Fixed64x64(v), err
+}
+
+// stringer
+
+func (a Fixed64x64) String() string {
+    return a.FormatPrec(-1)
+}
+
+// marshalling/unmarshaling
+
+func (a Fixed64x64) MarshalText() (text []byte, err error) {
+    return a.AppendFormatPrec(nil, -1), nil
+}
+
+func (a *Fixed64x64) UnmarshalText(text []byte) error {
+    out, offset, err := parseFixed64x64(bytesToString(text), maxUInt128)
+    if err!=nil {
+        *a = Fixed64x64{}
+        return numError("UnmarshalText", string(text), offset, err)
+    }
+    *a = Fixed64x64(out)
+    return nil
+}
+
+// convert 64-bit signed integer to signed fixed-point number
+func SFixed64x64FromInt64(a int64) SFixed64x64 {
+    return SFixed64x64{ 0, uint64(a) }
+}
+
+// return integer part of signed fixed-point number (rounded toward negative infinity)
+func (a SFixed64x64) IntPart() int64 {
+    return int64(a[1])
+}
+
+// return fractional part of signed fixed-point number as 64-bit fraction
+// (fraction*2^64). fractional part is not negative (value = IntPart + FracPart/2^64)
+func (a SFixed64x64) FracPart() uint64 {
+    return a[0]
+}
+
+// add signed fixed-point numbers
+func (a SFixed64x64) Add(b SFixed64x64) SFixed64x64 {
+    return SFixed64x64(Int128(a).Add(Int128(b)))
+}
+
+// subtract signed fixed-point numbers
+func (a SFixed64x64) Sub(b SFixed64x64) SFixed64x64 {
+    return SFixed64x64(Int128(a).Sub(Int128(b)))
+}
+
+// negate signed fixed-point number
+func (a SFixed64x64) Neg() SFixed64x64 {
+    return SFixed64x64(Int128(a).Neg())
+}
+
+// return absolute value of signed fixed-point number as unsigned number
+func (a SFixed64x64) Abs() Fixed64x64 {
+    return Fixed64x64(Int128(a).Abs())
+}
+
+// return sign of number: -1 if negative, 0 if zero, 1 if positive
+func (a SFixed64x64) Sign() int {
+    return Int128(a).Sign()
+}
+
+// compare signed fixed-point numbers and return 0 if they equal,
+// 1 if first is greater than second, or -1 if first is lesser than second
+func (a SFixed64x64) Cmp(b SFixed64x64) int {
+    return Int128(a).Cmp(Int128(b))
+}
+
+// return true if zero
+func (a SFixed64x64) IsZero() bool {
+    return a[0]==0 && a[1]==0
+}
+
+// return signed number from absolute value and sign with range checking
+func sfixedFromAbs(v Fixed64x64, neg bool) (SFixed64x64, error) {
+    r, err := uint128ToInt128(UInt128(v), neg)
+    if err!=nil {
+        return SFixed64x64{}, ErrOverflow
+    }
+    return SFixed64x64(r), nil
+}
+
+// multiply signed fixed-point numbers and return product truncated toward zero
+// to 64 fractional bits and lower 64 bits of integer part
+func (a SFixed64x64) Mul(b SFixed64x64) SFixed64x64 {
+    c := SFixed64x64(a.Abs().Mul(b.Abs()))
+    if (int64(a[1])<0) != (int64(b[1])<0) {
+        return c.Neg()
+    }
+    return c
+}
+
+// multiply signed fixed-point numbers and return product truncated toward zero
+// to 64 fractional bits or error if overflow
+func (a SFixed64x64) MulChecked(b SFixed64x64) (SFixed64x64, error) {
+    c, err := a.Abs().MulChecked(b.Abs())
+    if err!=nil {
+        return SFixed64x64{}, err
+    }
+    return sfixedFromAbs(c, (int64(a[1])<0) != (int64(b[1])<0))
+}
+
+// divide signed fixed-point numbers and return quotient truncated toward zero
+// to 64 fractional bits. panics if divisor is zero or if quotient overflows
+func (a SFixed64x64) Div(b SFixed64x64) SFixed64x64 {
+    c, err := a.DivChecked(b)
+    if err==ErrDivideByZero {
+        panic("Divide by zero")
+    } else if err!=nil {
+        panic("Divide overflow")
+    }
+    return c
+}
+
+// divide signed fixed-point numbers and return quotient truncated toward zero
+// to 64 fractional bits or error (ErrDivideByZero or ErrOverflow)
+func (a SFixed64x64) DivChecked(b SFixed64x64) (SFixed64x64, error) {
+    c, err := a.Abs().DivChecked(b.Abs())
+    if err!=nil {
+        return SFixed64x64{}, err
+    }
+    return sfixedFromAbs(c, (int64(a[1])<0) != (int64(b[1])<0))
+}
+
+// append decimal form of signed fixed-point number to bytes.
+// rules are same as in Fixed64x64.AppendFormatPrec
+func (a SFixed64x64) AppendFormatPrec(dst []byte, prec int) []byte {
+    if int64(a[1])<0 {
+        dst = append(dst, '-')
+    }
+    return a.Abs().AppendFormatPrec(dst, prec)
+}
+
+// format signed fixed-point number to string. rules are same as
+// in Fixed64x64.AppendFormatPrec
+func (a SFixed64x64) FormatPrec(prec int) string {
+    return string(a.AppendFormatPrec(nil, prec))
+}
+
+// parse signed decimal fixed-point number from string and return value, offset
+// of first bad character and error
+func parseSFixed64x64(str string) (SFixed64x64, int, error) {
+    start := 0
+    neg := false
+    if len(str)!=0 && (str[0]=='-' || str[0]=='+') {
+        neg = str[0]=='-'
+        start = 1
+    }
+    limit := UInt128{ 0xffffffffffffffff, 0x7fffffffffffffff }
+    if neg {
+        limit = UInt128{ 0, 0x8000000000000000 }
+    }
+    v, offset, err := parseFixed64x64(str[start:], limit)
+    if err!=nil {
+        return SFixed64x64{}, start+offset, err
+    }
+    if neg {
+        return SFixed64x64(Int128(v).Neg()), 0, nil
+    }
+    return SFixed64x64(v), 0, nil
+}
+
+// parse signed decimal fixed-point number from string and return value and
+// error (nil if no error). rules are same as in ParseFixed64x64
+func ParseSFixed64x64(str string) (SFixed64x64, error) {
+    v, offset, err := parseSFixed64x64(str)
+    if err!=nil {
+        return SFixed64x64{}, numError("ParseSFixed64x64", str, offset, err)
+    }
+    return v, nil
+}
+
+// convert signed fixed-point number to 64-bit float point value (rounded to nearest)
+func (a SFixed64x64) ToFloat64() float64 {
+    return math.Ldexp(Int128(a).ToFloat64(), -64)
+}
+
+// convert 64-bit float point value to signed fixed-point number (rounded
+// to nearest). return strconv.ErrRange if value is out of range or NaN
+func SFixed64x64FromFloat64(a float64) (SFixed64x64, error) {
+    neg := math.Signbit(a)
+    v, _, err := Float64ToUInt128Round(math.Ldexp(math.Abs(a), 64), ToNearestEven)
+    if err!=nil {
+        return SFixed64x64{}, err
+    }
+    r, err := uint128ToInt128(v, neg)
+    return SFixed64x64(r), err
+}
+
+// stringer
+
+func (a SFixed64x64) String() string {
+    return a.FormatPrec(-1)
+}
+
+// marshalling/unmarshaling
+
+func (a SFixed64x64) MarshalText() (text []byte, err error) {
+    return a.AppendFormatPrec(nil, -1), nil
+}
+
+func (a *SFixed64x64) UnmarshalText(text []byte) error {
+    out, offset, err := parseSFixed64x64(bytesToString(text))
+    if err!=nil {
+        *a = SFixed64x64{}
+        return numError("UnmarshalText", string(text), offset, err)
+    }
+    *a = out
+    return nil
+}
@@ -0,0 +1,603 @@
+/*
+ * fixed_test.go - tests for fixed-point Q64.64 numbers
+ *
+ * goint128 - go int128 library
+ * Copyright (C) 2020  Mateusz Szpakowski
+ *
+ * This library is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU Lesser General Public
+ * License as published by the Free Software Foundation; either
+ * version 2.1 of the License, or (at your option) any later version.
+ *
+ * This library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+ * Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public
+ * License along with this library; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301  USA
+ */
+
+package goint128
+
+import (
+    "bytes"
+    "math"
+    "math/big"
+    "math/rand"
+    "strconv"
+    "testing"
+)
+
+type Fixed64x64FormatTC struct {
+    value Fixed64x64
+    prec int
+    expected string
+}
+
+func TestFixed64x64Format(t *testing.T) {
+    testCases := []Fixed64x64FormatTC {
+        Fixed64x64FormatTC{ Fixed64x64{ 0, 0 }, -1, "0" },
+        Fixed64x64FormatTC{ Fixed64x64{ 0, 0 }, 0, "0" },
+        Fixed64x64FormatTC{ Fixed64x64{ 0, 0 }, 2, "0.00" },
+        Fixed64x64FormatTC{ Fixed64x64{ 0, 7 }, -1, "7" },
+        Fixed64x64FormatTC{ Fixed64x64{ 0x8000000000000000, 1 }, -1, "1.5" },
+        Fixed64x64FormatTC{ Fixed64x64{ 0x8000000000000000, 1 }, 0, "2" },
+        Fixed64x64FormatTC{ Fixed64x64{ 0x8000000000000000, 2 }, 0, "2" },
+        Fixed64x64FormatTC{ Fixed64x64{ 0x4000000000000000, 0 }, 1, "0.2" },
+        Fixed64x64FormatTC{ Fixed64x64{ 0xc000000000000000, 0 }, 1, "0.8" },
+        Fixed64x64FormatTC{ Fixed64x64{ 1, 0 }, -1,
+            "0.0000000000000000000542101086242752217003726400434970855712890625" },
+        Fixed64x64FormatTC{ Fixed64x64{ 1, 0 }, 19, "0.0000000000000000001" },
+        Fixed64x64FormatTC{ Fixed64x64{ 1, 0 }, 18, "0.000000000000000000" },
+        Fixed64x64FormatTC{ Fixed64x64{ 0x1999999999999999, 3 }, 5, "3.10000" },
+        Fixed64x64FormatTC{ Fixed64x64{ 0x243f6a8885a308d3, 3 }, -1,
+            "3.1415926535897932384585988507819109827323700301349163055419921875" },
+        Fixed64x64FormatTC{ Fixed64x64{ 0x243f6a8885a308d3, 3 }, 10, "3.1415926536" },
+        Fixed64x64FormatTC{ Fixed64x64{ 0x8000000000000000, 0 }, 70,
+            "0.5000000000000000000000000000000000000000000000000000000000000000000000" },
+        Fixed64x64FormatTC{ Fixed64x64{ 0xffffffffffffffff, 0xffffffffffffffff }, -1,
+            "18446744073709551615." +
+            "9999999999999999999457898913757247782996273599565029144287109375" },
+        Fixed64x64FormatTC{ Fixed64x64{ 0xffffffffffffffff, 0xffffffffffffffff }, 3,
+            "18446744073709551616.000" },
+    }
+    for i, tc := range testCases {
+        a := tc.value
+        result := tc.value.FormatPrec(tc.prec)
+        if tc.expected!=result {
+            t.Errorf("Result mismatch: %d: format(%v,%d)->%v!=%v",
+                     i, tc.value, tc.prec, tc.expected, result)
+        }
+        if tc.value!=a {
+            t.Errorf("Argument has been modified: %d: %v!=%v", i, a, tc.value)
+        }
+        // signed
+        sa := SFixed64x64(tc.value)
+        if sa[1]<1<<63 {
+            if sresult := sa.FormatPrec(tc.prec); tc.expected!=sresult {
+                t.Errorf("Result mismatch: %d: sformat(%v,%d)->%v!=%v",
+                         i, sa, tc.prec, tc.expected, sresult)
+            }
+            if !sa.IsZero() {
+                if sresult := sa.Neg().FormatPrec(tc.prec); "-"+tc.expected!=sresult {
+                    t.Errorf("Result mismatch: %d: sformat(%v,%d)->%v!=%v",
+                             i, sa.Neg(), tc.prec, "-"+tc.expected, sresult)
+                }
+            }
+        }
+    }
+}
+
+type Fixed64x64ParseTC struct {
+    str string
+    expected Fixed64x64
+    expError error
+}
+
+func TestFixed64x64Parse(t *testing.T) {
+    testCases := []Fixed64x64ParseTC {
+        Fixed64x64ParseTC{ "0", Fixed64x64{ 0, 0 }, nil },
+        Fixed64x64ParseTC{ "1.5", Fixed64x64{ 0x8000000000000000, 1 }, nil },
+        Fixed64x64ParseTC{ ".25", Fixed64x64{ 0x4000000000000000, 0 }, nil },
+        Fixed64x64ParseTC{ "7.", Fixed64x64{ 0, 7 }, nil },
+        Fixed64x64ParseTC{ "0.1", Fixed64x64{ 0x199999999999999a, 0 }, nil },
+        Fixed64x64ParseTC{ "3.14159265358979323846264338327950288",
+            Fixed64x64{ 0x243f6a8885a308d3, 3 }, nil },
+        // below, at and above half of unit
+        Fixed64x64ParseTC{
+            "0.0000000000000000000271050543121376108501863200217485427856445312",
+            Fixed64x64{ 0, 0 }, nil },
+        Fixed64x64ParseTC{
+            "0.00000000000000000002710505431213761085018632002174854278564453125",
+            Fixed64x64{ 0, 0 }, nil },
+        Fixed64x64ParseTC{
+            "0.000000000000000000027105054312137610850186320021748542785644531251",
+            Fixed64x64{ 1, 0 }, nil },
+        Fixed64x64ParseTC{
+            "0.00000000000000000008131516293641283255055896006524562835693359375",
+            Fixed64x64{ 2, 0 }, nil },
+        Fixed64x64ParseTC{ "18446744073709551615." +
+            "9999999999999999999728949456878623891498136799782514572143554687",
+            Fixed64x64{ 0xffffffffffffffff, 0xffffffffffffffff }, nil },
+        Fixed64x64ParseTC{ "18446744073709551615." +
+            "99999999999999999997289494568786238914981367997825145721435546875",
+            Fixed64x64{}, strconv.ErrRange },
+        Fixed64x64ParseTC{ "18446744073709551616", Fixed64x64{}, strconv.ErrRange },
+        Fixed64x64ParseTC{ "340282366920938463463374607431768211456.5",
+            Fixed64x64{}, strconv.ErrRange },
+        Fixed64x64ParseTC{ "", Fixed64x64{}, strconv.ErrSyntax },
+        Fixed64x64ParseTC{ ".", Fixed64x64{}, strconv.ErrSyntax },
+        Fixed64x64ParseTC{ "-1", Fixed64x64{}, strconv.ErrSyntax },
+        Fixed64x64ParseTC{ "1.2.3", Fixed64x64{}, strconv.ErrSyntax },
+        Fixed64x64ParseTC{ "1a.5", Fixed64x64{}, strconv.ErrSyntax },
+        Fixed64x64ParseTC{ "1.5x", Fixed64x64{}, strconv.ErrSyntax },
+    }
+    for i, tc := range testCases {
+        result, err := ParseFixed64x64(tc.str)
+        if tc.expected!=result || !errorMatch(tc.expError, err) {
+            t.Errorf("Result mismatch: %d: parse(%v)->%v,%v!=%v,%v",
+                     i, tc.str, tc.expected, tc.expError, result, err)
+        }
+    }
+}
+
+type SFixed64x64ParseTC struct {
+    str string
+    expected SFixed64x64
+    expError error
+}
+
+func TestSFixed64x64Parse(t *testing.T) {
+    testCases := []SFixed64x64ParseTC {
+        SFixed64x64ParseTC{ "-0", SFixed64x64{ 0, 0 }, nil },
+        SFixed64x64ParseTC{ "+1.5", SFixed64x64{ 0x8000000000000000, 1 }, nil },
+        SFixed64x64ParseTC{ "-1.5",
+            SFixed64x64{ 0x8000000000000000, 0xfffffffffffffffe }, nil },
+        SFixed64x64ParseTC{ "-.5",
+            SFixed64x64{ 0x8000000000000000, 0xffffffffffffffff }, nil },
+        SFixed64x64ParseTC{ "-9223372036854775808",
+            SFixed64x64{ 0, 0x8000000000000000 }, nil },
+        SFixed64x64ParseTC{ "-9223372036854775807.99999999999999999999",
+            SFixed64x64{ 0, 0x8000000000000000 }, nil },
+        SFixed64x64ParseTC{ "9223372036854775807.9999999999999999999",
+            SFixed64x64{ 0xfffffffffffffffe, 0x7fffffffffffffff }, nil },
+        SFixed64x64ParseTC{ "9223372036854775807.99999999999999999999",
+            SFixed64x64{}, strconv.ErrRange },
+        SFixed64x64ParseTC{ "-9223372036854775808.0000000000000000001",
+            SFixed64x64{}, strconv.ErrRange },
+        SFixed64x64ParseTC{ "9223372036854775808", SFixed64x64{}, strconv.ErrRange },
+        SFixed64x64ParseTC{ "-", SFixed64x64{}, strconv.ErrSyntax },
+        SFixed64x64ParseTC{ "-.", SFixed64x64{}, strconv.ErrSyntax },
+        SFixed64x64ParseTC{ "--1.5", SFixed64x64{}, strconv.ErrSyntax },
+    }
+    for i, tc := range testCases {
+        result, err := ParseSFixed64x64(tc.str)
+        if tc.expected!=result || !errorMatch(tc.expError, err) {
+            t.Errorf("Result mismatch: %d: parse(%v)->%v,%v!=%v,%v",
+                     i, tc.str, tc.expected, tc.expError, result, err)
+        }
+    }
+}
+
+func TestFixed64x64ParseNumError(t *testing.T) {
+    _, err := ParseFixed64x64("18446744073709551616.5")
+    expected := NumError{ "ParseFixed64x64", "18446744073709551616.5",
+        19, strconv.ErrRange }
+    if numErr, ok := err.(*NumError); !ok || *numErr!=expected {
+        t.Errorf("Result mismatch: %v!=%v", expected, err)
+    }
+    _, err = ParseFixed64x64("18446744073709551615.99999999999999999999")
+    expected = NumError{ "ParseFixed64x64", "18446744073709551615.99999999999999999999",
+        21, strconv.ErrRange }
+    if numErr, ok := err.(*NumError); !ok || *numErr!=expected {
+        t.Errorf("Result mismatch: %v!=%v", expected, err)
+    }
+    _, err = ParseFixed64x64("1.2x3y")
+    expected = NumError{ "ParseFixed64x64", "1.2x3y", 3, strconv.ErrSyntax }
+    if numErr, ok := err.(*NumError); !ok || *numErr!=expected {
+        t.Errorf("Result mismatch: %v!=%v", expected, err)
+    }
+    _, err = ParseSFixed64x64("-9223372036854775809")
+    expected = NumError{ "ParseSFixed64x64", "-9223372036854775809",
+        19, strconv.ErrRange }
+    if numErr, ok := err.(*NumError); !ok || *numErr!=expected {
+        t.Errorf("Result mismatch: %v!=%v", expected, err)
+    }
+    _, err = ParseSFixed64x64("+12a.5")
+    expected = NumError{ "ParseSFixed64x64", "+12a.5", 3, strconv.ErrSyntax }
+    if numErr, ok := err.(*NumError); !ok || *numErr!=expected {
+        t.Errorf("Result mismatch: %v!=%v", expected, err)
+    }
+}
+
+// format and parse must give same value
+func TestFixed64x64FormatParse(t *testing.T) {
+    rnd := rand.New(rand.NewSource(23))
+    for i:=0; i<2000; i++ {
+        a := Fixed64x64{ rnd.Uint64(), rnd.Uint64()>>uint(rnd.Intn(64)) }
+        str := a.String()
+        result, err := ParseFixed64x64(str)
+        if a!=result || err!=nil {
+            t.Errorf("Result mismatch: %d: parse(format(%v))->%v!=%v,%v",
+                     i, a, a, result, err)
+        }
+        // 20 fractional digits are enough to restore value
+        str = a.FormatPrec(20)
+        result, err = ParseFixed64x64(str)
+        if a!=result || err!=nil {
+            t.Errorf("Result mismatch: %d: parse(format(%v,20))->%v!=%v,%v",
+                     i, a, a, result, err)
+        }
+        sa := SFixed64x64(a)
+        sresult, err := ParseSFixed64x64(sa.String())
+        if sa!=sresult || err!=nil {
+            t.Errorf("Result mismatch: %d: sparse(sformat(%v))->%v!=%v,%v",
+                     i, sa, sa, sresult, err)
+        }
+    }
+}
+
+type Fixed64x64IntFracTC struct {
+    value SFixed64x64
+    expInt int64
+    expFrac uint64
+}
+
+func TestSFixed64x64IntFracPart(t *testing.T) {
+    testCases := []Fixed64x64IntFracTC {
+        Fixed64x64IntFracTC{ SFixed64x64FromInt64(0), 0, 0 },
+        Fixed64x64IntFracTC{ SFixed64x64FromInt64(-45), -45, 0 },
+        Fixed64x64IntFracTC{ SFixed64x64{ 0x8000000000000000, 1 },
+            1, 0x8000000000000000 },
+        Fixed64x64IntFracTC{ SFixed64x64{ 0x4000000000000000, 0xfffffffffffffffe },
+            -2, 0x4000000000000000 },
+    }
+    for i, tc := range testCases {
+        resInt, resFrac := tc.value.IntPart(), tc.value.FracPart()
+        if tc.expInt!=resInt || tc.expFrac!=resFrac {
+            t.Errorf("Result mismatch: %d: intfrac(%v)->%v,%v!=%v,%v",
+                     i, tc.value, tc.expInt, tc.expFrac, resInt, resFrac)
+        }
+    }
+}
+
+type Fixed64x64CheckedTC struct {
+    a, b Fixed64x64
+    expected Fixed64x64
+    expError error
+}
+
+func TestFixed64x64MulChecked(t *testing.T) {
+    testCases := []Fixed64x64CheckedTC {
+        Fixed64x64CheckedTC{ Fixed64x64{ 0x8000000000000000, 1 },
+            Fixed64x64{ 0x8000000000000000, 1 }, Fixed64x64{ 0x4000000000000000, 2 }, nil },
+        Fixed64x64CheckedTC{ Fixed64x64{ 1, 0 }, Fixed64x64{ 0xffffffffffffffff, 0 },
+            Fixed64x64{ 0, 0 }, nil },
+        Fixed64x64CheckedTC{ Fixed64x64{ 0, 1<<31 }, Fixed64x64{ 0, 1<<32 },
+            Fixed64x64{ 0, 1<<63 }, nil },
+        Fixed64x64CheckedTC{ Fixed64x64{ 0, 1<<32 }, Fixed64x64{ 0, 1<<32 },
+            Fixed64x64{}, ErrOverflow },
+    }
+    for i, tc := range testCases {
+        a, b := tc.a, tc.b
+        result, err := tc.a.MulChecked(tc.b)
+        if tc.expected!=result || tc.expError!=err {
+            t.Errorf("Result mismatch: %d: mulc(%v,%v)->%v,%v!=%v,%v",
+                     i, tc.a, tc.b, tc.expected, tc.expError, result, err)
+        }
+        if tc.a!=a || tc.b!=b {
+            t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
+                     i, a, b, tc.a, tc.b)
+        }
+    }
+}
+
+func TestFixed64x64DivChecked(t *testing.T) {
+    testCases := []Fixed64x64CheckedTC {
+        Fixed64x64CheckedTC{ Fixed64x64{ 0, 5 }, Fixed64x64{ 0, 5 },
+            Fixed64x64{ 0, 1 }, nil },
+        Fixed64x64CheckedTC{ Fixed64x64{ 0, 5 }, Fixed64x64{ 0x8000000000000000, 0 },
+            Fixed64x64{ 0, 10 }, nil },
+        Fixed64x64CheckedTC{ Fixed64x64{ 0, 1 }, Fixed64x64{ 0, 3 },
+            Fixed64x64{ 0x5555555555555555, 0 }, nil },
+        Fixed64x64CheckedTC{ Fixed64x64{ 0, 1<<63 }, Fixed64x64{ 1<<62, 0 },
+            Fixed64x64{}, ErrOverflow },
+        Fixed64x64CheckedTC{ Fixed64x64{ 0, 1 }, Fixed64x64{ 0, 0 },
+            Fixed64x64{}, ErrDivideByZero },
+    }
+    for i, tc := range testCases {
+        a, b := tc.a, tc.b
+        result, err := tc.a.DivChecked(tc.b)
+        if tc.expected!=result || tc.expError!=err {
+            t.Errorf("Result mismatch: %d: divc(%v,%v)->%v,%v!=%v,%v",
+                     i, tc.a, tc.b, tc.expected, tc.expError, result, err)
+        }
+        if tc.a!=a || tc.b!=b {
+            t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
+                     i, a, b, tc.a, tc.b)
+        }
+    }
+}
+
+type SFixed64x64CheckedTC struct {
+    a, b SFixed64x64
+    expected SFixed64x64
+    expError error
+}
+
+func TestSFixed64x64MulDivChecked(t *testing.T) {
+    testCases := []SFixed64x64CheckedTC {
+        // -1.5*2.5, -1.5/-0.5
+        SFixed64x64CheckedTC{ SFixed64x64{ 0x8000000000000000, 0xfffffffffffffffe },
+            SFixed64x64{ 0x8000000000000000, 2 },
+            SFixed64x64{ 0x4000000000000000, 0xfffffffffffffffc }, nil },
+        SFixed64x64CheckedTC{ SFixed64x64FromInt64(-1<<62), SFixed64x64FromInt64(2),
+            SFixed64x64FromInt64(-1<<63), nil },
+        SFixed64x64CheckedTC{ SFixed64x64FromInt64(1<<62), SFixed64x64FromInt64(2),
+            SFixed64x64{}, ErrOverflow },
+    }
+    for i, tc := range testCases {
+        a, b := tc.a, tc.b
+        result, err := tc.a.MulChecked(tc.b)
+        if tc.expected!=result || tc.expError!=err {
+            t.Errorf("Result mismatch: %d: mulc(%v,%v)->%v,%v!=%v,%v",
+                     i, tc.a, tc.b, tc.expected, tc.expError, result, err)
+        }
+        if tc.a!=a || tc.b!=b {
+            t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
+                     i, a, b, tc.a, tc.b)
+        }
+    }
+    testCases = []SFixed64x64CheckedTC {
+        SFixed64x64CheckedTC{ SFixed64x64{ 0x8000000000000000, 0xfffffffffffffffe },
+            SFixed64x64{ 0x8000000000000000, 0xffffffffffffffff },
+            SFixed64x64FromInt64(3), nil },
+        SFixed64x64CheckedTC{ SFixed64x64FromInt64(-1), SFixed64x64FromInt64(3),
+            SFixed64x64{ 0xaaaaaaaaaaaaaaab, 0xffffffffffffffff }, nil },
+        SFixed64x64CheckedTC{ SFixed64x64FromInt64(-1<<63), SFixed64x64FromInt64(1),
+            SFixed64x64FromInt64(-1<<63), nil },
+        SFixed64x64CheckedTC{ SFixed64x64FromInt64(-1<<63), SFixed64x64FromInt64(-1),
+            SFixed64x64{}, ErrOverflow },
+        SFixed64x64CheckedTC{ SFixed64x64FromInt64(1), SFixed64x64{},
+            SFixed64x64{}, ErrDivideByZero },
+    }
+    for i, tc := range testCases {
+        a, b := tc.a, tc.b
+        result, err := tc.a.DivChecked(tc.b)
+        if tc.expected!=result || tc.expError!=err {
+            t.Errorf("Result mismatch: %d: divc(%v,%v)->%v,%v!=%v,%v",
+                     i, tc.a, tc.b, tc.expected, tc.expError, result, err)
+        }
+        if tc.a!=a || tc.b!=b {
+            t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
+                     i, a, b, tc.a, tc.b)
+        }
+    }
+}
+
+// return signed fixed-point number as big integer (value*2^64)
+func sfixedToBig(a SFixed64x64) *big.Int {
+    return signedBigInt(UInt128(a.Abs()), a.Sign()<0)
+}
+
+// compare multiplication and division with big integers
+func TestFixed64x64MulDivBig(t *testing.T) {
+    rnd := rand.New(rand.NewSource(64))
+    mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
+    for i:=0; i<3000; i++ {
+        a := Fixed64x64(UInt128{ rnd.Uint64(), rnd.Uint64() }.Shr(uint(rnd.Intn(128))))
+        b := Fixed64x64(UInt128{ rnd.Uint64(), rnd.Uint64() }.Shr(uint(rnd.Intn(128))))
+        ba, bb := UInt128(a).BigInt(), UInt128(b).BigInt()
+        prod := new(big.Int).Mul(ba, bb)
+        prod.Rsh(prod, 64)
+        expected := new(big.Int).And(prod, mask)
+        if result := a.Mul(b); expected.Cmp(UInt128(result).BigInt())!=0 {
+            t.Errorf("Result mismatch: %d: mul(%v,%v)->%v!=%v", i, a, b, expected, result)
+        }
+        result, err := a.MulChecked(b)
+        if prod.Cmp(mask)>0 {
+            if err!=ErrOverflow {
+                t.Errorf("Result mismatch: %d: mulc(%v,%v)->overflow!=%v", i, a, b, err)
+            }
+        } else if err!=nil || expected.Cmp(UInt128(result).BigInt())!=0 {
+            t.Errorf("Result mismatch: %d: mulc(%v,%v)->%v!=%v,%v",
+                     i, a, b, expected, result, err)
+        }
+        // signed
+        sa, sb := SFixed64x64(a), SFixed64x64(b)
+        bsa, bsb := sfixedToBig(sa), sfixedToBig(sb)
+        sprod := new(big.Int).Mul(bsa, bsb)
+        sprod.Quo(sprod, new(big.Int).Lsh(big.NewInt(1), 64))
+        sresult, err := sa.MulChecked(sb)
+        if sprod.BitLen()<128 {
+            if err!=nil || sprod.Cmp(sfixedToBig(sresult))!=0 {
+                t.Errorf("Result mismatch: %d: smulc(%v,%v)->%v!=%v,%v",
+                         i, sa, sb, sprod, sresult, err)
+            }
+        }
+        if b.IsZero() {
+            continue
+        }
+        quo := new(big.Int).Lsh(ba, 64)
+        quo.Quo(quo, bb)
+        result, err = a.DivChecked(b)
+        if quo.Cmp(mask)>0 {
+            if err!=ErrOverflow {
+                t.Errorf("Result mismatch: %d: divc(%v,%v)->overflow!=%v", i, a, b, err)
+            }
+        } else {
+            if err!=nil || quo.Cmp(UInt128(result).BigInt())!=0 {
+                t.Errorf("Result mismatch: %d: divc(%v,%v)->%v!=%v,%v",
+                         i, a, b, quo, result, err)
+            }
+            if result = a.Div(b); quo.Cmp(UInt128(result).BigInt())!=0 {
+                t.Errorf("Result mismatch: %d: div(%v,%v)->%v!=%v", i, a, b, quo, result)
+            }
+        }
+        squo := new(big.Int).Lsh(bsa, 64)
+        squo.Quo(squo, bsb)
+        sresult, err = sa.DivChecked(sb)
+        if squo.BitLen()<128 {
+            if err!=nil || squo.Cmp(sfixedToBig(sresult))!=0 {
+                t.Errorf("Result mismatch: %d: sdivc(%v,%v)->%v!=%v,%v",
+                         i, sa, sb, squo, sresult, err)
+            }
+        } else if squo.Cmp(signedBigInt(UInt128{ 0, 1<<63 }, true))!=0 && err!=ErrOverflow {
+            t.Errorf("Result mismatch: %d: sdivc(%v,%v)->overflow!=%v", i, sa, sb, err)
+        }
+    }
+}
+
+func TestFixed64x64DivPanic(t *testing.T) {
+    paniced, panicStr := getPanic2(func() {
+        Fixed64x64{ 0, 1 }.Div(Fixed64x64{})
+    })
+    if !paniced || panicStr!="Divide by zero" {
+        t.Errorf("Unexpected panic: %v,%v", paniced, panicStr)
+    }
+    paniced, panicStr = getPanic2(func() {
+        SFixed64x64FromInt64(-1<<63).Div(SFixed64x64FromInt64(-1))
+    })
+    if !paniced || panicStr!="Divide overflow" {
+        t.Errorf("Unexpected panic: %v,%v", paniced, panicStr)
+    }
+}
+
+type Fixed64x64FloatTC struct {
+    value float64
+    expected SFixed64x64
+    expError error
+}
+
+func TestFixed64x64Float64(t *testing.T) {
+    testCases := []Fixed64x64FloatTC {
+        Fixed64x64FloatTC{ 0.0, SFixed64x64{ 0, 0 }, nil },
+        Fixed64x64FloatTC{ 1.5, SFixed64x64{ 0x8000000000000000, 1 }, nil },
+        Fixed64x64FloatTC{ 0.1, SFixed64x64{ 0x1999999999999a00, 0 }, nil },
+        Fixed64x64FloatTC{ 5.421010862427522e-20, SFixed64x64{ 1, 0 }, nil },
+        Fixed64x64FloatTC{ 2.7e-20, SFixed64x64{ 0, 0 }, nil },
+        Fixed64x64FloatTC{ -1.5, SFixed64x64{ 0x8000000000000000, 0xfffffffffffffffe },
+            nil },
+        Fixed64x64FloatTC{ -9223372036854775808.0, SFixed64x64{ 0, 0x8000000000000000 },
+            nil },
+        Fixed64x64FloatTC{ 9223372036854775808.0, SFixed64x64{}, strconv.ErrRange },
+        Fixed64x64FloatTC{ 18446744073709551616.0, SFixed64x64{}, strconv.ErrRange },
+        Fixed64x64FloatTC{ math.Inf(1), SFixed64x64{}, strconv.ErrRange },
+        Fixed64x64FloatTC{ math.NaN(), SFixed64x64{}, strconv.ErrRange },
+    }
+    for i, tc := range testCases {
+        result, err := SFixed64x64FromFloat64(tc.value)
+        if tc.expected!=result || tc.expError!=err {
+            t.Errorf("Result mismatch: %d: sfromfloat64(%v)->%v,%v!=%v,%v",
+                     i, tc.value, tc.expected, tc.expError, result, err)
+        }
+        if err==nil {
+            if fresult := result.ToFloat64(); fresult!=tc.value && result.Sign()!=0 {
+                t.Errorf("Result mismatch: %d: stofloat64(%v)->%v!=%v",
+                         i, result, tc.value, fresult)
+            }
+        }
+        // unsigned
+        uresult, err := Fixed64x64FromFloat64(tc.value)
+        if tc.value<0 || math.IsNaN(tc.value) || tc.value>=18446744073709551616.0 {
+            if err!=strconv.ErrRange {
+                t.Errorf("Result mismatch: %d: fromfloat64(%v)->range!=%v",
+                         i, tc.value, err)
+            }
+        } else if tc.expError==nil && (Fixed64x64(tc.expected)!=uresult || err!=nil) {
+            t.Errorf("Result mismatch: %d: fromfloat64(%v)->%v!=%v,%v",
+                     i, tc.value, tc.expected, uresult, err)
+        }
+    }
+    if result := (Fixed64x64{ 0xffffffffffffffff, 0xffffffffffffffff }).ToFloat64();
+            result!=18446744073709551616.0 {
+        t.Errorf("Result mismatch: tofloat64(max)->%v!=%v",
+                 18446744073709551616.0, result)
+    }
+}
+
+type Fixed64x64MarshalTC struct {
+    value SFixed64x64
+    expected []byte
+}
+
+func TestFixed64x64MarshalText(t *testing.T) {
+    testCases := []Fixed64x64MarshalTC{
+        Fixed64x64MarshalTC{ SFixed64x64{ 0x8000000000000000, 1 }, []byte("1.5") },
+        Fixed64x64MarshalTC{ SFixed64x64{ 0x199999999999999a, 3 },
+            []byte("3.100000000000000000021684043449710088680149056017398834228515625") },
+        Fixed64x64MarshalTC{ SFixed64x64{ 0, 34954975929367788 },
+            []byte("34954975929367788") },
+    }
+    for i, tc := range testCases {
+        result, err := Fixed64x64(tc.value).MarshalText()
+        if err!=nil {
+            t.Errorf("MarshalText returns error: %v", err)
+        }
+        if !bytes.Equal(tc.expected, result) {
+            t.Errorf("Result mismatch: %d: marshaltext(%v)->%v!=%v",
+                     i, tc.value, tc.expected, result)
+        }
+        var v Fixed64x64
+        err = v.UnmarshalText(tc.expected)
+        if Fixed64x64(tc.value)!=v || err!=nil {
+            t.Errorf("Result mismatch: %d: unmarshaltext(%v)->%v!=%v,%v",
+                     i, tc.expected, tc.value, v, err)
+        }
+        // signed negative value
+        neg := tc.value.Neg()
+        expected := append([]byte("-"), tc.expected...)
+        result, err = neg.MarshalText()
+        if err!=nil {
+            t.Errorf("MarshalText returns error: %v", err)
+        }
+        if !bytes.Equal(expected, result) {
+            t.Errorf("Result mismatch: %d: smarshaltext(%v)->%v!=%v",
+                     i, neg, expected, result)
+        }
+        var sv SFixed64x64
+        err = sv.UnmarshalText(expected)
+        if neg!=sv || err!=nil {
+            t.Errorf("Result mismatch: %d: sunmarshaltext(%v)->%v!=%v,%v",
+                     i, expected, neg, sv, err)
+        }
+    }
+    var v Fixed64x64
+    err := v.UnmarshalText([]byte("1.x"))
+    expected := NumError{ "UnmarshalText", "1.x", 2, strconv.ErrSyntax }
+    if numErr, ok := err.(*NumError); !ok || *numErr!=expected {
+        t.Errorf("Result mismatch: %v!=%v", expected, err)
+    }
+}
+
+func BenchmarkFixed64x64Mul(b *testing.B) {
+    a := Fixed64x64{ 0x243f6a8885a308d3, 3 }
+    c := Fixed64x64{ 0x892f902bd23f0824, 0x5d9dc9f8 }
+    for i := 0; i < b.N; i++ {
+        a.Mul(c)
+    }
+}
+
+func BenchmarkFixed64x64Div(b *testing.B) {
+    a := Fixed64x64{ 0x892f902bd23f0824, 0x5d9dc9f8 }
+    c := Fixed64x64{ 0x243f6a8885a308d3, 3 }
+    for i := 0; i < b.N; i++ {
+        a.Div(c)
+    }
+}
+
+func BenchmarkFixed64x64Format(b *testing.B) {
+    a := Fixed64x64{ 0x243f6a8885a308d3, 3 }
+    var buf [128]byte
+    for i := 0; i < b.N; i++ {
+        a.AppendFormatPrec(buf[:0], -1)
+    }
+}
+
+func BenchmarkFixed64x64Parse(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        ParseFixed64x64("3.14159265358979323846264338327950288")
+    }
+}
@@ -0,0 +1,273 @@
+/*
+ * fmt.go - fmt.Formatter and fmt.Scanner support
+ *
+ * goint128 - go int128 library
+ * Copyright (C) 2020  Mateusz Szpakowski
+ *
+ * This library is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU Lesser General Public
+ * License as published by the Free Software Foundation; either
+ * version 2.1 of the License, or (at your option) any later version.
+ *
+ * This library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+ * Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public
+ * License along with this library; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301  USA
+ */
+
+// Package to operate on 128-bit integers
+package goint128
+
+import (
+    "errors"
+    "fmt"
+    "io"
+    "strconv"
+    "unicode/utf8"
+)
+
+// unsigned integer that can be formatted by formatInteger
+type baseFormatter interface {
+    IsZero() bool
+    AppendBaseWidth(dst []byte, base int, upper bool, width int) []byte
+}
+
+// format absolute value and sign of integer like fmt formats builtin integers.
+// signed - true if type is signed, typeName - name of type used in %!verb(...)
+func formatInteger(f fmt.State, verb rune, signed, neg bool, a baseFormatter,
+                   typeName string) {
+    var buf [320]byte
+    out := buf[:0]
+    sharp := f.Flag('#')
+    base, upper := 10, false
+    switch verb {
+    case 'd':
+    case 'v':
+        // %#v prints unsigned integers in hexadecimal like fmt
+        if sharp && !signed {
+            base = 16
+        } else {
+            sharp = false
+        }
+    case 'x':
+        base = 16
+    case 'X':
+        base, upper = 16, true
+    case 'o', 'O':
+        base = 8
+    case 'b':
+        base = 2
+    default:
+        out = append(out, "%!"...)
+        out = append(out, string(verb)...)
+        out = append(out, "(goint128."...)
+        out = append(out, typeName...)
+        out = append(out, '=')
+        if neg { out = append(out, '-') }
+        out = a.AppendBaseWidth(out, 10, false, 0)
+        out = append(out, ')')
+        f.Write(out)
+        return
+    }
+    wid, widOk := f.Width()
+    prec, precOk := f.Precision()
+    minus := f.Flag('-')
+    sign := ""
+    if neg {
+        sign = "-"
+    } else if f.Flag('+') {
+        sign = "+"
+    } else if f.Flag(' ') {
+        sign = " "
+    }
+    var dbuf [256]byte
+    var digits []byte
+    if precOk {
+        // precision 0 and value 0 means "print nothing" except padding
+        if prec!=0 || !a.IsZero() {
+            digits = a.AppendBaseWidth(dbuf[:0], base, upper, prec)
+        }
+    } else if f.Flag('0') && !minus && widOk {
+        // zero padding to width (leave room for sign)
+        digits = a.AppendBaseWidth(dbuf[:0], base, upper, wid-len(sign))
+    } else {
+        digits = a.AppendBaseWidth(dbuf[:0], base, upper, 0)
+    }
+    if precOk && len(digits)==0 {
+        sign = ""
+    }
+    prefix := ""
+    if sharp && len(digits)!=0 {
+        switch base {
+        case 2:
+            prefix = "0b"
+        case 8:
+            if digits[0]!='0' { prefix = "0" }
+        case 16:
+            if upper {
+                prefix = "0X"
+            } else {
+                prefix = "0x"
+            }
+        }
+    }
+    if verb=='O' && len(digits)!=0 {
+        prefix = "0o" + prefix
+    }
+    pad := 0
+    if widOk {
+        pad = wid - len(sign) - len(prefix) - len(digits)
+    }
+    if !minus {
+        for ; pad>0; pad-- { out = append(out, ' ') }
+    }
+    out = append(out, sign...)
+    out = append(out, prefix...)
+    out = append(out, digits...)
+    for ; pad>0; pad-- { out = append(out, ' ') }
+    f.Write(out)
+}
+
+// implements fmt.Formatter. supported verbs: d, v (decimal), x, X (hexadecimal),
+// o, O (octal) and b (binary) with width, precision and flags '+', '-', ' ',
+// '0' and '#' (prefix 0x, 0X, 0 or 0b) as for builtin integers. other verbs
+// (also s) give %!verb(goint128.UInt128=value) like for builtin integers
+func (a UInt128) Format(f fmt.State, verb rune) {
+    formatInteger(f, verb, false, false, a, "UInt128")
+}
+
+// implements fmt.Formatter. verbs and flags are same as in UInt128.Format
+func (a Int128) Format(f fmt.State, verb rune) {
+    formatInteger(f, verb, true, int64(a[1])<0, a.Abs(), "Int128")
+}
+
+// return true if character is digit in base or underscore (if underscore is true)
+func isScanDigit(r rune, base int, underscore bool) bool {
+    var digit rune
+    switch {
+    case r>='0' && r<='9':
+        digit = r-'0'
+    case (r|0x20)>='a' && (r|0x20)<='z':
+        digit = (r|0x20)-'a'+10
+    case r=='_':
+        return underscore
+    default:
+        return false
+    }
+    return digit<rune(base)
+}
+
+// read token of integer for fmt.Scanner. for verb v base is determined by prefix
+// and only characters valid in that base are read (like for builtin integers)
+func scanInteger(state fmt.ScanState, verb rune, signed bool) (string, int, error) {
+    base := 0
+    switch verb {
+    case 'v':
+    case 'd':
+        base = 10
+    case 'x', 'X':
+        base = 16
+    case 'o', 'O':
+        base = 8
+    case 'b':
+        base = 2
+    default:
+        return "", 0, errors.New("bad verb '%" + string(verb) + "' for integer")
+    }
+    state.SkipSpace()
+    var buf [64]byte
+    tok := buf[:0]
+    eof := false
+    start := 0 // start of digits (after sign)
+    digitBase := base
+    for {
+        r, _, err := state.ReadRune()
+        if err==io.EOF {
+            eof = true
+            break
+        } else if err!=nil {
+            return "", 0, err
+        }
+        ok := true
+        ndigits := len(tok)-start
+        switch {
+        case signed && len(tok)==0 && (r=='+' || r=='-'):
+            start = 1
+        case base==0 && ndigits==0:
+            // first digit: 0 - octal or prefix, otherwise decimal
+            ok = r>='0' && r<='9'
+            digitBase = 10
+            if r=='0' { digitBase = 8 }
+        case base==0 && ndigits==1 && tok[start]=='0' && (r|0x20)=='b':
+            digitBase = 2
+        case base==0 && ndigits==1 && tok[start]=='0' && (r|0x20)=='o':
+            digitBase = 8
+        case base==0 && ndigits==1 && tok[start]=='0' && (r|0x20)=='x':
+            digitBase = 16
+        default:
+            ok = isScanDigit(r, digitBase, base==0)
+        }
+        if !ok {
+            state.UnreadRune()
+            if len(tok)==0 {
+                // no number: report bad character
+                return "", 0, numError("Scan", string(r), 0, strconv.ErrSyntax)
+            }
+            break
+        }
+        var rbuf [utf8.UTFMax]byte
+        tok = append(tok, rbuf[:utf8.EncodeRune(rbuf[:], r)]...)
+    }
+    if eof && len(tok)==0 {
+        return "", 0, io.ErrUnexpectedEOF
+    }
+    return string(tok), base, nil
+}
+
+// implements fmt.Scanner. supported verbs: d (decimal), x, X (hexadecimal),
+// o, O (octal), b (binary) and v (base determined by prefix as in ParseUInt128Base)
+func (a *UInt128) Scan(state fmt.ScanState, verb rune) error {
+    str, base, err := scanInteger(state, verb, false)
+    if err!=nil {
+        return err
+    }
+    out, offset, err := parseUInt128Base(str, base, maxUInt128)
+    if err!=nil {
+        return numError("Scan", str, offset, err)
+    }
+    *a = out
+    return nil
+}
+
+// implements fmt.Scanner. verbs are same as in UInt128.Scan and
+// number can be preceded by sign
+func (a *Int128) Scan(state fmt.ScanState, verb rune) error {
+    str, base, err := scanInteger(state, verb, true)
+    if err!=nil {
+        return err
+    }
+    start := 0
+    neg := false
+    if str[0]=='-' || str[0]=='+' {
+        neg = str[0]=='-'
+        start = 1
+    }
+    limit := UInt128{ 0xffffffffffffffff, 0x7fffffffffffffff }
+    if neg {
+        limit = UInt128{ 0, 0x8000000000000000 }
+    }
+    v, offset, err := parseUInt128Base(str[start:], base, limit)
+    if err!=nil {
+        return numError("Scan", str, start+offset, err)
+    }
+    if neg {
+        *a = Int128(v).Neg()
+    } else {
+        *a = Int128(v)
+    }
+    return nil
+}
@@ -0,0 +1,260 @@
+/*
+ * fmt_test.go - tests for fmt.Formatter and fmt.Scanner support
+ *
+ * goint128 - go int128 library
+ * Copyright (C) 2020  Mateusz Szpakowski
+ *
+ * This library is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU Lesser General Public
+ * License as published by the Free Software Foundation; either
+ * version 2.1 of the License, or (at your option) any later version.
+ *
+ * This library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+ * Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public
+ * License along with this library; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301  USA
+ */
+
+package goint128
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+    "testing"
+)
+
+type UInt128FormatterTC struct {
+    format string
+    a UInt128
+    expected string
+}
+
+func TestUInt128Formatter(t *testing.T) {
+    testCases := []UInt128FormatterTC {
+        UInt128FormatterTC{ "%d", UInt128{ 255, 1<<36 },
+            "1267650600228229401496703205631" },
+        UInt128FormatterTC{ "%v", UInt128{ 255, 1<<36 },
+            "1267650600228229401496703205631" },
+        UInt128FormatterTC{ "%s", UInt128{ 255, 1<<36 },
+            "%!s(goint128.UInt128=1267650600228229401496703205631)" },
+        UInt128FormatterTC{ "%x", UInt128{ 255, 1<<36 },
+            "100000000000000000000000ff" },
+        UInt128FormatterTC{ "%X", UInt128{ 255, 1<<36 },
+            "100000000000000000000000FF" },
+        UInt128FormatterTC{ "%#x", UInt128{ 255, 1<<36 },
+            "0x100000000000000000000000ff" },
+        UInt128FormatterTC{ "%#X", UInt128{ 255, 1<<36 },
+            "0X100000000000000000000000FF" },
+        UInt128FormatterTC{ "%#v", UInt128{ 255, 1<<36 },
+            "0x100000000000000000000000ff" },
+        UInt128FormatterTC{ "%o", UInt128{ 255, 1<<36 },
+            "2000000000000000000000000000000377" },
+        UInt128FormatterTC{ "%#o", UInt128{ 255, 1<<36 },
+            "02000000000000000000000000000000377" },
+        UInt128FormatterTC{ "%O", UInt128{ 255, 1<<36 },
+            "0o2000000000000000000000000000000377" },
+        UInt128FormatterTC{ "%b", UInt128{ 0xf, 1<<1 }, "100000000000000000000000000000000"+
+            "000000000000000000000000000001111" },
+        UInt128FormatterTC{ "%#b", UInt128{ 5, 0 }, "0b101" },
+        UInt128FormatterTC{ "%40d", UInt128{ 255, 1<<36 },
+            "         1267650600228229401496703205631" },
+        UInt128FormatterTC{ "%-40d|", UInt128{ 255, 1<<36 },
+            "1267650600228229401496703205631         |" },
+        UInt128FormatterTC{ "%040d", UInt128{ 255, 1<<36 },
+            "0000000001267650600228229401496703205631" },
+        UInt128FormatterTC{ "%+040d", UInt128{ 255, 1<<36 },
+            "+000000001267650600228229401496703205631" },
+        UInt128FormatterTC{ "% d", UInt128{ 255, 1<<36 },
+            " 1267650600228229401496703205631" },
+        UInt128FormatterTC{ "%.35d", UInt128{ 255, 1<<36 },
+            "00001267650600228229401496703205631" },
+        UInt128FormatterTC{ "%40.35d", UInt128{ 255, 1<<36 },
+            "     00001267650600228229401496703205631" },
+        UInt128FormatterTC{ "%#32x", UInt128{ 255, 1<<36 },
+            "    0x100000000000000000000000ff" },
+        UInt128FormatterTC{ "%#032x", UInt128{ 255, 1<<36 },
+            "0x000000100000000000000000000000ff" },
+        UInt128FormatterTC{ "%.0d", UInt128{ 0, 0 }, "" },
+        UInt128FormatterTC{ "%5.0d", UInt128{ 0, 0 }, "     " },
+        UInt128FormatterTC{ "%d", MaxUInt128,
+            "340282366920938463463374607431768211455" },
+        UInt128FormatterTC{ "%x", MaxUInt128, "ffffffffffffffffffffffffffffffff" },
+        UInt128FormatterTC{ "%z", UInt128{ 12, 0 }, "%!z(goint128.UInt128=12)" },
+    }
+    for i, tc := range testCases {
+        a := tc.a
+        result := fmt.Sprintf(tc.format, tc.a)
+        if tc.expected!=result {
+            t.Errorf("Result mismatch: %d: Sprintf(%q,%v)->%q!=%q",
+                     i, tc.format, tc.a, tc.expected, result)
+        }
+        if tc.a!=a {
+            t.Errorf("Argument has been modified: %d: %v!=%v", i, a, tc.a)
+        }
+    }
+}
+
+type Int128FormatterTC struct {
+    format string
+    a Int128
+    expected string
+}
+
+func TestInt128Formatter(t *testing.T) {
+    testCases := []Int128FormatterTC {
+        Int128FormatterTC{ "%d", Int128{ 0, 0x8000000000000000 },
+            "-170141183460469231731687303715884105728" },
+        Int128FormatterTC{ "%x", Int128{ 0, 0x8000000000000000 },
+            "-80000000000000000000000000000000" },
+        Int128FormatterTC{ "%#X", Int128{ 0xffffffffffffffff, 0x7fffffffffffffff },
+            "0X7FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF" },
+        Int128FormatterTC{ "%+d", Int128{ 0xffffffffffffffff, 0x7fffffffffffffff },
+            "+170141183460469231731687303715884105727" },
+        Int128FormatterTC{ "%#v", Int128{ 0xfffffffffffffff6, 0xffffffffffffffff }, "-10" },
+        // signed integers are printed in decimal also if nonnegative
+        Int128FormatterTC{ "%#v", Int128{ 10, 0 }, "10" },
+        Int128FormatterTC{ "%06d", Int128{ 0xfffffffffffffff6, 0xffffffffffffffff },
+            "-00010" },
+        Int128FormatterTC{ "%-6d|", Int128{ 0xfffffffffffffff6, 0xffffffffffffffff },
+            "-10   |" },
+        Int128FormatterTC{ "%#o", Int128{ 0xfffffffffffffff8, 0xffffffffffffffff },
+            "-010" },
+        Int128FormatterTC{ "%z", Int128{ 0xfffffffffffffff6, 0xffffffffffffffff },
+            "%!z(goint128.Int128=-10)" },
+    }
+    for i, tc := range testCases {
+        a := tc.a
+        result := fmt.Sprintf(tc.format, tc.a)
+        if tc.expected!=result {
+            t.Errorf("Result mismatch: %d: Sprintf(%q,%v)->%q!=%q",
+                     i, tc.format, tc.a, tc.expected, result)
+        }
+        if tc.a!=a {
+            t.Errorf("Argument has been modified: %d: %v!=%v", i, a, tc.a)
+        }
+    }
+}
+
+// compare formatting of values that fit in 64 bits with formatting builtin integers
+func TestFormatterBuiltin(t *testing.T) {
+    formats := []string { "%d", "%v", "%s", "%x", "%X", "%o", "%O", "%b", "%#x", "%#X",
+        "%#o", "%#b", "%#O", "%8d", "%-8d|", "%08d", "%+08d", "% 08d", "%+d", "% d",
+        "%.5d", "%8.5d", "%08.5d", "%.0d", "%3.0x", "%#012x", "%#-12x|", "%+.3o",
+        "%-+8d|", "%#.4x", "%#.0x", "%#08b" }
+    values := []int64 { 0, 1, -1, 7, -8, 10, 255, -255, 12345678,
+        -9876543210, 1<<62, -1<<63, 1<<63-1 }
+    for _, format := range formats {
+        for _, v := range values {
+            expected := fmt.Sprintf(format, v)
+            result := fmt.Sprintf(format, Int128{ uint64(v), uint64(v>>63) })
+            if format=="%s" {
+                // type name differs
+                expected = strings.Replace(expected, "int64", "goint128.Int128", 1)
+            }
+            if expected!=result {
+                t.Errorf("Result mismatch: Sprintf(%q,%d)->%q!=%q",
+                         format, v, expected, result)
+            }
+            expected = fmt.Sprintf(format, uint64(v))
+            result = fmt.Sprintf(format, UInt128{ uint64(v), 0 })
+            if format=="%s" {
+                expected = strings.Replace(expected, "uint64", "goint128.UInt128", 1)
+            }
+            if expected!=result {
+                t.Errorf("Result mismatch: Sprintf(%q,%d)->%q!=%q",
+                         format, uint64(v), expected, result)
+            }
+        }
+    }
+}
+
+type UInt128ScanTC struct {
+    format string
+    str string
+    expected UInt128
+    expError error
+}
+
+func TestUInt128Scan(t *testing.T) {
+    testCases := []UInt128ScanTC {
+        UInt128ScanTC{ "%d", "1267650600228229401496703205631",
+            UInt128{ 255, 1<<36 }, nil },
+        UInt128ScanTC{ "%x", "100000000000000000000000ff",
+            UInt128{ 255, 1<<36 }, nil },
+        UInt128ScanTC{ "%X", "100000000000000000000000FF",
+            UInt128{ 255, 1<<36 }, nil },
+        UInt128ScanTC{ "%o", "2000000000000000000000000000000377",
+            UInt128{ 255, 1<<36 }, nil },
+        UInt128ScanTC{ "%b", "101", UInt128{ 5, 0 }, nil },
+        UInt128ScanTC{ "%v", "0x100000000000000000000000ff",
+            UInt128{ 255, 1<<36 }, nil },
+        UInt128ScanTC{ "%v", "0b1_0001", UInt128{ 17, 0 }, nil },
+        UInt128ScanTC{ "%v", "   1234", UInt128{ 1234, 0 }, nil },
+        UInt128ScanTC{ "%d", "340282366920938463463374607431768211455",
+            MaxUInt128, nil },
+        UInt128ScanTC{ "%d", "340282366920938463463374607431768211456",
+            UInt128{}, strconv.ErrRange },
+        UInt128ScanTC{ "%d", "-1", UInt128{}, strconv.ErrSyntax },
+        UInt128ScanTC{ "%v", "0x", UInt128{}, strconv.ErrSyntax },
+    }
+    for i, tc := range testCases {
+        var result UInt128
+        _, err := fmt.Sscanf(tc.str, tc.format, &result)
+        if tc.expected!=result || !errorMatch(tc.expError, err) {
+            t.Errorf("Result mismatch: %d: Sscanf(%q,%q)->%v,%v!=%v,%v",
+                     i, tc.str, tc.format, tc.expected, tc.expError, result, err)
+        }
+    }
+}
+
+func TestInt128Scan(t *testing.T) {
+    var a, b Int128
+    var c UInt128
+    n, err := fmt.Sscan("-170141183460469231731687303715884105728 +0x7f 17",
+                        &a, &b, &c)
+    if n!=3 || err!=nil || a!=(Int128{ 0, 0x8000000000000000 }) ||
+        b!=(Int128{ 127, 0 }) || c!=(UInt128{ 17, 0 }) {
+        t.Errorf("Result mismatch: Sscan->%d,%v,%v,%v,%v", n, err, a, b, c)
+    }
+    n, err = fmt.Sscanf("-80000000000000000000000000000000 abc", "%x %x", &a, &b)
+    if n!=2 || err!=nil || a!=(Int128{ 0, 0x8000000000000000 }) ||
+        b!=(Int128{ 0xabc, 0 }) {
+        t.Errorf("Result mismatch: Sscanf->%d,%v,%v,%v", n, err, a, b)
+    }
+    _, err = fmt.Sscanf("80000000000000000000000000000000", "%x", &a)
+    if !errorMatch(strconv.ErrRange, err) {
+        t.Errorf("Result mismatch: Sscanf->%v", err)
+    }
+    _, err = fmt.Sscanf("-170141183460469231731687303715884105729", "%d", &a)
+    if numErr, ok := err.(*NumError); !ok || numErr.Offset!=39 ||
+        !errorMatch(strconv.ErrRange, err) {
+        t.Errorf("Result mismatch: Sscanf->%v", err)
+    }
+}
+
+// compare scanning with scanning of builtin integers
+func TestScanBuiltin(t *testing.T) {
+    inputs := []string { "12abc", "0x1fg", "0X1F_F", "0b102", "0o778", "017", "019",
+        "-12a", "+0x7fz", "1_000x", "09", "0_", "7-" }
+    formats := []string { "%v", "%d", "%x", "%o", "%b" }
+    for _, format := range formats {
+        for _, str := range inputs {
+            var ev int64
+            var er string
+            en, eerr := fmt.Sscanf(str, format+"%s", &ev, &er)
+            var rv Int128
+            var rr string
+            rn, rerr := fmt.Sscanf(str, format+"%s", &rv, &rr)
+            if en!=rn || (eerr==nil)!=(rerr==nil) ||
+                (eerr==nil && (rv!=Int128{ uint64(ev), uint64(ev>>63) } || er!=rr)) {
+                t.Errorf("Result mismatch: Sscanf(%q,%q)->%d,%v,%q,%v!=%d,%v,%q,%v",
+                         str, format, en, ev, er, eerr, rn, rv, rr, rerr)
+            }
+        }
+    }
+}
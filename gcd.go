@@ -0,0 +1,86 @@
+/*
+ * gcd.go - greatest common divisor and least common multiple
+ *
+ * goint128 - go int128 library
+ * Copyright (C) 2020  Mateusz Szpakowski
+ *
+ * This library is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU Lesser General Public
+ * License as published by the Free Software Foundation; either
+ * version 2.1 of the License, or (at your option) any later version.
+ *
+ * This library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+ * Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public
+ * License along with this library; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301  USA
+ */
+
+// Package to operate on 128-bit integers
+package goint128
+
+// return greatest common divisor of 128-bit unsigned integers
+// (binary GCD algorithm). GCD(0,0) is zero
+func GCD(a, b UInt128) UInt128 {
+    if a[0]==0 && a[1]==0 {
+        return b
+    }
+    if b[0]==0 && b[1]==0 {
+        return a
+    }
+    // common power of two
+    k := uint(a.Or(b).TrailingZeros())
+    a = a.Shr(uint(a.TrailingZeros()))
+    for {
+        // a and b are odd after shifting
+        b = b.Shr(uint(b.TrailingZeros()))
+        if a[1]>b[1] || (a[1]==b[1] && a[0]>b[0]) {
+            a, b = b, a
+        }
+        b = b.Sub(a)
+        if b[0]==0 && b[1]==0 {
+            break
+        }
+    }
+    return a.Shl(k)
+}
+
+// return least common multiple of 128-bit unsigned integers or error
+// if it overflows. LCM is zero if any argument is zero
+func LCM(a, b UInt128) (UInt128, error) {
+    if (a[0]==0 && a[1]==0) || (b[0]==0 && b[1]==0) {
+        return UInt128{}, nil
+    }
+    return a.Div(GCD(a, b)).MulChecked(b)
+}
+
+// return greatest common divisor g of 128-bit unsigned integers and
+// magnitudes of Bezout coefficients x and y with their signs (true if negative)
+// such that a*x + b*y = g. magnitudes are not greater than b/g and a/g.
+// if a and b are zero then g, x and y are zero
+func ExtendedGCD(a, b UInt128) (UInt128, UInt128, UInt128, bool, bool) {
+    if a[0]==0 && a[1]==0 {
+        if b[0]==0 && b[1]==0 {
+            return UInt128{}, UInt128{}, UInt128{}, false, false
+        }
+        return b, UInt128{}, UInt128{ 1, 0 }, false, false
+    }
+    // extended Euclid algorithm. signs of coefficients alternate, thus
+    // only magnitudes are stored: x(k+1) = x(k-1) + q*x(k)
+    r0, r1 := a, b
+    x0, x1 := UInt128{ 1, 0 }, UInt128{}
+    y0, y1 := UInt128{}, UInt128{ 1, 0 }
+    odd := false
+    for r1[0]!=0 || r1[1]!=0 {
+        q, r := r0.DivMod(r1)
+        r0, r1 = r1, r
+        x0, x1 = x1, x0.Add(q.Mul(x1))
+        y0, y1 = y1, y0.Add(q.Mul(y1))
+        odd = !odd
+    }
+    // x is negative at odd step, y is negative at even step
+    return r0, x0, y0, odd && !x0.IsZero(), !odd && !y0.IsZero()
+}
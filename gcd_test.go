@@ -0,0 +1,167 @@
+/*
+ * gcd_test.go - tests for greatest common divisor and least common multiple
+ *
+ * goint128 - go int128 library
+ * Copyright (C) 2020  Mateusz Szpakowski
+ *
+ * This library is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU Lesser General Public
+ * License as published by the Free Software Foundation; either
+ * version 2.1 of the License, or (at your option) any later version.
+ *
+ * This library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+ * Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public
+ * License along with this library; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301  USA
+ */
+
+package goint128
+
+import (
+    "math/big"
+    "math/rand"
+    "testing"
+)
+
+type UInt128GCDTC struct {
+    a, b UInt128
+    expected UInt128
+}
+
+func TestGCD(t *testing.T) {
+    testCases := []UInt128GCDTC {
+        UInt128GCDTC{ UInt128{}, UInt128{}, UInt128{} },
+        UInt128GCDTC{ UInt128{}, UInt128{ 15, 0 }, UInt128{ 15, 0 } },
+        UInt128GCDTC{ UInt128{ 15, 0 }, UInt128{}, UInt128{ 15, 0 } },
+        UInt128GCDTC{ UInt128{ 12, 0 }, UInt128{ 18, 0 }, UInt128{ 6, 0 } },
+        UInt128GCDTC{ UInt128{ 17, 0 }, UInt128{ 5, 0 }, UInt128{ 1, 0 } },
+        UInt128GCDTC{ UInt128{ 0, 1 }, UInt128{ 0, 1<<20 }, UInt128{ 0, 1 } },
+        UInt128GCDTC{ MaxUInt128, MaxUInt128, MaxUInt128 },
+        UInt128GCDTC{ MaxUInt128, UInt128{ 0, 1 }, UInt128{ 1, 0 } },
+        // 2^128-1 = 3*5*17*257*641*65537*274177*6700417*67280421310721
+        UInt128GCDTC{ MaxUInt128, UInt128{ 3*5*17*257*641<<30, 3*5*17*257*641>>34 },
+            UInt128{ 3*5*17*257*641, 0 } },
+        UInt128GCDTC{ MaxUInt128, UInt128{ 3*5*17*257*641, 3*5*17*257*641 },
+            UInt128{ 0x280fd7f, 0x280fd7f } },
+        UInt128GCDTC{ prime128, prime127, UInt128{ 1, 0 } },
+        UInt128GCDTC{ prime127.Shr(64).Mul64(6), prime127.Shr(64).Mul64(10),
+            prime127.Shr(64).Mul64(2) },
+    }
+    for i, tc := range testCases {
+        a, b := tc.a, tc.b
+        result := GCD(tc.a, tc.b)
+        if tc.expected!=result {
+            t.Errorf("Result mismatch: %d: gcd(%v,%v)->%v!=%v",
+                     i, tc.a, tc.b, tc.expected, result)
+        }
+        if tc.a!=a || tc.b!=b {
+            t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
+                     i, a, b, tc.a, tc.b)
+        }
+    }
+}
+
+type UInt128LCMTC struct {
+    a, b UInt128
+    expected UInt128
+    expError error
+}
+
+func TestLCM(t *testing.T) {
+    testCases := []UInt128LCMTC {
+        UInt128LCMTC{ UInt128{}, UInt128{ 15, 0 }, UInt128{}, nil },
+        UInt128LCMTC{ UInt128{ 12, 0 }, UInt128{ 18, 0 }, UInt128{ 36, 0 }, nil },
+        UInt128LCMTC{ UInt128{ 0, 1 }, UInt128{ 0xffffffffffffffff, 0 },
+            UInt128{ 0, 0xffffffffffffffff }, nil },
+        UInt128LCMTC{ MaxUInt128, UInt128{ 3*5*17*257*641, 0 }, MaxUInt128, nil },
+        UInt128LCMTC{ prime127.Mul64(2), UInt128{ 4, 0 }, UInt128{}, ErrOverflow },
+        UInt128LCMTC{ prime128, prime127, UInt128{}, ErrOverflow },
+    }
+    for i, tc := range testCases {
+        a, b := tc.a, tc.b
+        result, err := LCM(tc.a, tc.b)
+        if tc.expected!=result || tc.expError!=err {
+            t.Errorf("Result mismatch: %d: lcm(%v,%v)->%v,%v!=%v,%v",
+                     i, tc.a, tc.b, tc.expected, tc.expError, result, err)
+        }
+        if tc.a!=a || tc.b!=b {
+            t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
+                     i, a, b, tc.a, tc.b)
+        }
+    }
+}
+
+// return signed big integer from magnitude and sign
+func signedBigInt(a UInt128, neg bool) *big.Int {
+    x := a.BigInt()
+    if neg {
+        x.Neg(x)
+    }
+    return x
+}
+
+// compare GCD, LCM and ExtendedGCD with big integers
+func TestGCDBig(t *testing.T) {
+    rnd := rand.New(rand.NewSource(20))
+    for i:=0; i<3000; i++ {
+        a := UInt128{ rnd.Uint64(), rnd.Uint64() }.Shr(uint(rnd.Intn(129)))
+        b := UInt128{ rnd.Uint64(), rnd.Uint64() }.Shr(uint(rnd.Intn(129)))
+        if i&1==0 {
+            // common factor
+            c := UInt128{ rnd.Uint64(), 0 }.Shr(uint(rnd.Intn(64)))
+            a, b = a.Shr(64).Mul(c), b.Shr(64).Mul(c)
+        }
+        ba, bb := a.BigInt(), b.BigInt()
+        expected := new(big.Int).GCD(nil, nil, ba, bb)
+        if result := GCD(a, b); expected.Cmp(result.BigInt())!=0 {
+            t.Errorf("Result mismatch: gcd(%v,%v)->%v!=%v", a, b, expected, result)
+        }
+        g, x, y, xneg, yneg := ExtendedGCD(a, b)
+        bx, by := signedBigInt(x, xneg), signedBigInt(y, yneg)
+        sum := new(big.Int).Add(new(big.Int).Mul(ba, bx), new(big.Int).Mul(bb, by))
+        // magnitudes of coefficients are bounded by b/g and a/g (or 1)
+        xmax, ymax := UInt128{ 1, 0 }, UInt128{ 1, 0 }
+        if !g.IsZero() {
+            if q := b.Div(g); q.Cmp(xmax)>0 { xmax = q }
+            if q := a.Div(g); q.Cmp(ymax)>0 { ymax = q }
+        }
+        if expected.Cmp(g.BigInt())!=0 || sum.Cmp(expected)!=0 ||
+            x.Cmp(xmax)>0 || y.Cmp(ymax)>0 ||
+            (x.IsZero() && xneg) || (y.IsZero() && yneg) {
+            t.Errorf("Result mismatch: extgcd(%v,%v)->%v!=%v,%v,%v,%v,%v",
+                     a, b, expected, g, x, y, xneg, yneg)
+        }
+        lcm := new(big.Int)
+        if expected.Sign()!=0 {
+            lcm.Mul(ba, bb).Quo(lcm, expected)
+        }
+        result, err := LCM(a, b)
+        if lcm.BitLen()>128 {
+            if err!=ErrOverflow {
+                t.Errorf("Result mismatch: lcm(%v,%v)->%v!=%v,%v", a, b, lcm, result, err)
+            }
+        } else if err!=nil || lcm.Cmp(result.BigInt())!=0 {
+            t.Errorf("Result mismatch: lcm(%v,%v)->%v!=%v,%v", a, b, lcm, result, err)
+        }
+    }
+}
+
+func BenchmarkGCD(b *testing.B) {
+    x := UInt128{ 0x1c9e66c000000000, 0xe1b1e5f90f944d6e }
+    y := UInt128{ 0x8c261ad7409395f0, 0xf7a96e0000000000 }
+    for i := 0; i < b.N; i++ {
+        GCD(x, y)
+    }
+}
+
+func BenchmarkExtendedGCD(b *testing.B) {
+    x := UInt128{ 0x1c9e66c000000000, 0xe1b1e5f90f944d6e }
+    y := UInt128{ 0x8c261ad7409395f0, 0xf7a96e0000000000 }
+    for i := 0; i < b.N; i++ {
+        ExtendedGCD(x, y)
+    }
+}
@@ -0,0 +1,459 @@
+/*
+ * ieeedecimal.go - IEEE 754-2008 decimal128 encodings (BID and DPD)
+ *
+ * goint128 - go int128 library
+ * Copyright (C) 2020  Mateusz Szpakowski
+ *
+ * This library is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU Lesser General Public
+ * License as published by the Free Software Foundation; either
+ * version 2.1 of the License, or (at your option) any later version.
+ *
+ * This library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+ * Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public
+ * License along with this library; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301  USA
+ */
+
+// Package to operate on 128-bit integers
+package goint128
+
+import (
+    "strconv"
+)
+
+// class of IEEE 754-2008 decimal floating point number
+type DecimalClass int
+
+const (
+    DecimalFinite DecimalClass = iota // finite number (also zero)
+    DecimalInf // infinity
+    DecimalQNaN // quiet NaN
+    DecimalSNaN // signaling NaN
+)
+
+// IEEE 754-2008 decimal128 number in binary integer decimal (BID) encoding
+type BID128 UInt128
+
+// IEEE 754-2008 decimal128 number in densely packed decimal (DPD) encoding
+type DPD128 UInt128
+
+const (
+    decimal128Bias = 6176
+    decimal128MinExp = -6176
+    decimal128MaxExp = 6111
+)
+
+// greatest coefficient of decimal128 number (10^34-1)
+var decimal128MaxCoef = UInt128{ 0x378d8e63ffffffff, 0x1ed09bead87c0 }
+// greatest payload of decimal128 NaN (10^33-1)
+var decimal128MaxPayload = UInt128{ 0x38c15b09ffffffff, 0x314dc6448d93 }
+
+const pow10_15 uint64 = 1000000000000000
+const pow10_18 uint64 = 1000000000000000000
+
+// return coefficient and exponent of decimal number in range of decimal128.
+// number is not rounded, strconv.ErrRange is returned if it is not possible
+func (a Decimal128) ieeeNormalize() (UInt128, int, error) {
+    c, e := a.Coef, int(a.Exp)
+    for c.Cmp(decimal128MaxCoef)>0 {
+        // remove trailing zeroes
+        q, r := c.Div64(10)
+        if r!=0 {
+            return UInt128{}, 0, strconv.ErrRange
+        }
+        c, e = q, e+1
+    }
+    if c.IsZero() {
+        if e>decimal128MaxExp {
+            e = decimal128MaxExp
+        } else if e<decimal128MinExp {
+            e = decimal128MinExp
+        }
+        return c, e, nil
+    }
+    if e>decimal128MaxExp {
+        // clamp exponent: append zeroes to coefficient
+        k := e-decimal128MaxExp
+        if k>=len(uint128_10powers) {
+            return UInt128{}, 0, strconv.ErrRange
+        }
+        c2, err := c.MulChecked(uint128_10powers[k])
+        if err!=nil || c2.Cmp(decimal128MaxCoef)>0 {
+            return UInt128{}, 0, strconv.ErrRange
+        }
+        c, e = c2, decimal128MaxExp
+    } else if e<decimal128MinExp {
+        // remove trailing zeroes
+        k := decimal128MinExp-e
+        if k>=len(uint128_10powers) {
+            return UInt128{}, 0, strconv.ErrRange
+        }
+        q, r := c.DivMod(uint128_10powers[k])
+        if !r.IsZero() {
+            return UInt128{}, 0, strconv.ErrRange
+        }
+        c, e = q, decimal128MinExp
+    }
+    return c, e, nil
+}
+
+// return class of number from high 64 bits of decimal128 (same in both encodings)
+func decimal128Class(hi uint64) DecimalClass {
+    switch hi>>58&0x1f {
+    case 0x1e:
+        return DecimalInf
+    case 0x1f:
+        if hi>>57&1!=0 {
+            return DecimalSNaN
+        }
+        return DecimalQNaN
+    }
+    return DecimalFinite
+}
+
+// return high 64 bits of special value (infinity or NaN)
+func decimal128SpecialHi(class DecimalClass, neg bool) uint64 {
+    var hi uint64
+    switch class {
+    case DecimalInf:
+        hi = 0x7800000000000000
+    case DecimalQNaN:
+        hi = 0x7c00000000000000
+    case DecimalSNaN:
+        hi = 0x7e00000000000000
+    }
+    if neg {
+        hi |= 1<<63
+    }
+    return hi
+}
+
+// encode decimal number to decimal128 in BID encoding. return strconv.ErrRange
+// if number can not be represented exactly (it should be rescaled before)
+func (a Decimal128) BID() (BID128, error) {
+    c, e, err := a.ieeeNormalize()
+    if err!=nil {
+        return BID128{}, err
+    }
+    v := BID128{ c[0], c[1] | uint64(e+decimal128Bias)<<49 }
+    if a.Negative {
+        v[1] |= 1<<63
+    }
+    return v, nil
+}
+
+// return special value (infinity or NaN) in BID encoding. payload of NaN
+// must be lesser than 10^33, otherwise it is replaced by zero.
+// if class is DecimalFinite then zero is returned
+func MakeBID128Special(class DecimalClass, neg bool, payload UInt128) BID128 {
+    if class==DecimalFinite {
+        v, _ := Decimal128{ Negative: neg }.BID()
+        return v
+    }
+    v := BID128{ 0, decimal128SpecialHi(class, neg) }
+    if class!=DecimalInf && payload.Cmp(decimal128MaxPayload)<=0 {
+        v[0], v[1] = payload[0], v[1] | payload[1]
+    }
+    return v
+}
+
+// return class of number
+func (a BID128) Class() DecimalClass {
+    return decimal128Class(a[1])
+}
+
+// return true if number is negative (sign bit is set)
+func (a BID128) Signbit() bool {
+    return a[1]>>63!=0
+}
+
+// return payload of NaN or zero if number is not NaN
+func (a BID128) Payload() UInt128 {
+    if a.Class()!=DecimalQNaN && a.Class()!=DecimalSNaN {
+        return UInt128{}
+    }
+    p := UInt128{ a[0], a[1]&(1<<46-1) }
+    if p.Cmp(decimal128MaxPayload)>0 {
+        // non-canonical payload
+        return UInt128{}
+    }
+    return p
+}
+
+// decode number in BID encoding to decimal number. return ErrNotFinite
+// if number is infinity or NaN. non-canonical coefficient is decoded as zero
+func (a BID128) Decimal128() (Decimal128, error) {
+    if a.Class()!=DecimalFinite {
+        return Decimal128{}, ErrNotFinite
+    }
+    var c UInt128
+    var be uint64
+    if a[1]>>61&3!=3 {
+        be = a[1]>>49&0x3fff
+        c = UInt128{ a[0], a[1]&(1<<49-1) }
+        if c.Cmp(decimal128MaxCoef)>0 {
+            c = UInt128{}
+        }
+    } else {
+        // coefficient is not lesser than 2^113 (non-canonical)
+        be = a[1]>>47&0x3fff
+    }
+    return Decimal128{ c, int32(int64(be)-decimal128Bias), a.Signbit() }, nil
+}
+
+// convert number to DPD encoding
+func (a BID128) DPD() DPD128 {
+    class := a.Class()
+    if class!=DecimalFinite {
+        return MakeDPD128Special(class, a.Signbit(), a.Payload())
+    }
+    d, _ := a.Decimal128()
+    v, _ := d.DPD()
+    return v
+}
+
+// encode 3 decimal digits (number from 0 to 999) to DPD declet
+func dpdEncode(d uint64) uint64 {
+    d2, d1, d0 := d/100, d/10%10, d%10
+    // digits greater than 7 have highest bit (8) set
+    switch d2>>3<<2 | d1>>3<<1 | d0>>3 {
+    case 0:
+        return d2<<7 | d1<<4 | d0
+    case 1:
+        return d2<<7 | d1<<4 | 0x8 | d0&1
+    case 2:
+        return d2<<7 | (d0&6)<<4 | (d1&1)<<4 | 0xa | d0&1
+    case 3:
+        return d2<<7 | 0x40 | (d1&1)<<4 | 0xe | d0&1
+    case 4:
+        return (d0&6)<<7 | (d2&1)<<7 | d1<<4 | 0xc | d0&1
+    case 5:
+        return (d1&6)<<7 | (d2&1)<<7 | 0x20 | (d1&1)<<4 | 0xe | d0&1
+    case 6:
+        return (d0&6)<<7 | (d2&1)<<7 | (d1&1)<<4 | 0xe | d0&1
+    default:
+        return (d2&1)<<7 | 0x60 | (d1&1)<<4 | 0xe | d0&1
+    }
+}
+
+// decode DPD declet to number from 0 to 999 (also non-canonical declets)
+func dpdDecode(b uint64) uint64 {
+    var d2, d1, d0 uint64
+    if b&8==0 {
+        d2, d1, d0 = b>>7&7, b>>4&7, b&7
+    } else {
+        switch b>>1&3 {
+        case 0:
+            d2, d1, d0 = b>>7&7, b>>4&7, 8|b&1
+        case 1:
+            d2, d1, d0 = b>>7&7, 8|b>>4&1, b>>4&6|b&1
+        case 2:
+            d2, d1, d0 = 8|b>>7&1, b>>4&7, b>>7&6|b&1
+        default:
+            switch b>>5&3 {
+            case 0:
+                d2, d1, d0 = 8|b>>7&1, 8|b>>4&1, b>>7&6|b&1
+            case 1:
+                d2, d1, d0 = 8|b>>7&1, b>>7&6|b>>4&1, 8|b&1
+            case 2:
+                d2, d1, d0 = b>>7&7, 8|b>>4&1, 8|b&1
+            default:
+                d2, d1, d0 = 8|b>>7&1, 8|b>>4&1, 8|b&1
+            }
+        }
+    }
+    return d2*100 + d1*10 + d0
+}
+
+// encode coefficient lesser than 10^34 to most significant digit and
+// 11 declets of trailing significand (110 bits)
+func dpdEncodeCoef(c UInt128) (uint64, UInt128) {
+    q, lo := c.Div64(pow10_18)
+    msd, hi := q[0]/pow10_15, q[0]%pow10_15
+    var tlo, thi uint64
+    for i:=uint(0); i<60; i+=10 {
+        tlo |= dpdEncode(lo%1000)<<i
+        lo /= 1000
+    }
+    for i:=uint(0); i<50; i+=10 {
+        thi |= dpdEncode(hi%1000)<<i
+        hi /= 1000
+    }
+    return msd, UInt128{ tlo | thi<<60, thi>>4 }
+}
+
+// decode coefficient from most significant digit and trailing significand
+func dpdDecodeCoef(msd uint64, t UInt128) UInt128 {
+    tlo, thi := t[0]&(1<<60-1), t[0]>>60 | (t[1]&(1<<46-1))<<4
+    var lo, hi uint64
+    for i:=int(50); i>=0; i-=10 {
+        lo = lo*1000 + dpdDecode(tlo>>uint(i)&0x3ff)
+    }
+    for i:=int(40); i>=0; i-=10 {
+        hi = hi*1000 + dpdDecode(thi>>uint(i)&0x3ff)
+    }
+    var c UInt128
+    var carry uint64
+    c[1], c[0] = Mul64(msd*pow10_15 + hi, pow10_18)
+    c[0], carry = Add64(c[0], lo, 0)
+    c[1] += carry
+    return c
+}
+
+// encode decimal number to decimal128 in DPD encoding. return strconv.ErrRange
+// if number can not be represented exactly (it should be rescaled before)
+func (a Decimal128) DPD() (DPD128, error) {
+    c, e, err := a.ieeeNormalize()
+    if err!=nil {
+        return DPD128{}, err
+    }
+    msd, t := dpdEncodeCoef(c)
+    be := uint64(e+decimal128Bias)
+    // combination field: 2 highest bits of exponent and most significant digit
+    var g uint64
+    if msd<8 {
+        g = be>>12<<3 | msd
+    } else {
+        g = 0x18 | be>>12<<1 | msd&1
+    }
+    v := DPD128{ t[0], g<<58 | (be&0xfff)<<46 | t[1] }
+    if a.Negative {
+        v[1] |= 1<<63
+    }
+    return v, nil
+}
+
+// return special value (infinity or NaN) in DPD encoding. payload of NaN
+// must be lesser than 10^33, otherwise it is replaced by zero.
+// if class is DecimalFinite then zero is returned
+func MakeDPD128Special(class DecimalClass, neg bool, payload UInt128) DPD128 {
+    if class==DecimalFinite {
+        v, _ := Decimal128{ Negative: neg }.DPD()
+        return v
+    }
+    v := DPD128{ 0, decimal128SpecialHi(class, neg) }
+    if class!=DecimalInf && payload.Cmp(decimal128MaxPayload)<=0 {
+        _, t := dpdEncodeCoef(payload)
+        v[0], v[1] = t[0], v[1] | t[1]
+    }
+    return v
+}
+
+// return class of number
+func (a DPD128) Class() DecimalClass {
+    return decimal128Class(a[1])
+}
+
+// return true if number is negative (sign bit is set)
+func (a DPD128) Signbit() bool {
+    return a[1]>>63!=0
+}
+
+// return payload of NaN or zero if number is not NaN
+func (a DPD128) Payload() UInt128 {
+    if a.Class()!=DecimalQNaN && a.Class()!=DecimalSNaN {
+        return UInt128{}
+    }
+    return dpdDecodeCoef(0, UInt128(a))
+}
+
+// decode number in DPD encoding to decimal number. return ErrNotFinite
+// if number is infinity or NaN
+func (a DPD128) Decimal128() (Decimal128, error) {
+    if a.Class()!=DecimalFinite {
+        return Decimal128{}, ErrNotFinite
+    }
+    g := a[1]>>58&0x1f
+    var msd, be uint64
+    if g>>3!=3 {
+        msd, be = g&7, g>>3
+    } else {
+        msd, be = 8|g&1, g>>1&3
+    }
+    be = be<<12 | a[1]>>46&0xfff
+    c := dpdDecodeCoef(msd, UInt128(a))
+    return Decimal128{ c, int32(int64(be)-decimal128Bias), a.Signbit() }, nil
+}
+
+// convert number to BID encoding
+func (a DPD128) BID() BID128 {
+    class := a.Class()
+    if class!=DecimalFinite {
+        return MakeBID128Special(class, a.Signbit(), a.Payload())
+    }
+    d, _ := a.Decimal128()
+    v, _ := d.BID()
+    return v
+}
+
+// marshalling/unmarshaling (little-endian as UInt128)
+
+func (a BID128) MarshalBinary() (data []byte, err error) {
+    return UInt128(a).MarshalBinary()
+}
+
+func (a *BID128) UnmarshalBinary(data []byte) error {
+    return (*UInt128)(a).UnmarshalBinary(data)
+}
+
+func (a DPD128) MarshalBinary() (data []byte, err error) {
+    return UInt128(a).MarshalBinary()
+}
+
+func (a *DPD128) UnmarshalBinary(data []byte) error {
+    return (*UInt128)(a).UnmarshalBinary(data)
+}
+
+// marshal decimal number to 16 bytes of decimal128 in BID encoding
+// (little-endian, as in BSON)
+func (a Decimal128) MarshalBID() ([]byte, error) {
+    v, err := a.BID()
+    if err!=nil {
+        return nil, err
+    }
+    return v.MarshalBinary()
+}
+
+// unmarshal decimal number from 16 bytes of decimal128 in BID encoding.
+// decimal number is not modified if error is returned
+func (a *Decimal128) UnmarshalBID(data []byte) error {
+    var v BID128
+    if err := v.UnmarshalBinary(data); err!=nil {
+        return err
+    }
+    out, err := v.Decimal128()
+    if err!=nil {
+        return err
+    }
+    *a = out
+    return nil
+}
+
+// marshal decimal number to 16 bytes of decimal128 in DPD encoding (little-endian)
+func (a Decimal128) MarshalDPD() ([]byte, error) {
+    v, err := a.DPD()
+    if err!=nil {
+        return nil, err
+    }
+    return v.MarshalBinary()
+}
+
+// unmarshal decimal number from 16 bytes of decimal128 in DPD encoding.
+// decimal number is not modified if error is returned
+func (a *Decimal128) UnmarshalDPD(data []byte) error {
+    var v DPD128
+    if err := v.UnmarshalBinary(data); err!=nil {
+        return err
+    }
+    out, err := v.Decimal128()
+    if err!=nil {
+        return err
+    }
+    *a = out
+    return nil
+}
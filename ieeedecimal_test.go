@@ -0,0 +1,339 @@
+/*
+ * ieeedecimal_test.go - tests for IEEE 754-2008 decimal128 encodings
+ *
+ * goint128 - go int128 library
+ * Copyright (C) 2020  Mateusz Szpakowski
+ *
+ * This library is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU Lesser General Public
+ * License as published by the Free Software Foundation; either
+ * version 2.1 of the License, or (at your option) any later version.
+ *
+ * This library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+ * Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public
+ * License along with this library; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301  USA
+ */
+
+package goint128
+
+import (
+    "bytes"
+    "math/rand"
+    "strconv"
+    "testing"
+)
+
+func TestDPDDeclets(t *testing.T) {
+    for d:=uint64(0); d<1000; d++ {
+        b := dpdEncode(d)
+        if b>=1024 {
+            t.Errorf("Result mismatch: dpdencode(%v)->%v>=1024", d, b)
+        }
+        if result := dpdDecode(b); d!=result {
+            t.Errorf("Result mismatch: dpddecode(%v)->%v!=%v", b, d, result)
+        }
+    }
+    for b:=uint64(0); b<1024; b++ {
+        expected := b
+        if b&0x6e==0x6e {
+            // non-canonical declets: digits 8 or 9 in all places
+            expected &= 0xff
+        }
+        if result := dpdEncode(dpdDecode(b)); expected!=result {
+            t.Errorf("Result mismatch: dpdencode(dpddecode(%v))->%v!=%v",
+                     b, expected, result)
+        }
+    }
+}
+
+type Decimal128IEEETC struct {
+    str string
+    bid BID128
+    dpd DPD128
+}
+
+func TestDecimal128IEEEEncode(t *testing.T) {
+    testCases := []Decimal128IEEETC {
+        Decimal128IEEETC{ "0", BID128{ 0, 0x3040000000000000 },
+            DPD128{ 0, 0x2208000000000000 } },
+        Decimal128IEEETC{ "-0", BID128{ 0, 0xb040000000000000 },
+            DPD128{ 0, 0xa208000000000000 } },
+        Decimal128IEEETC{ "1", BID128{ 1, 0x3040000000000000 },
+            DPD128{ 1, 0x2208000000000000 } },
+        Decimal128IEEETC{ "0.000001", BID128{ 1, 0x3034000000000000 },
+            DPD128{ 1, 0x2206800000000000 } },
+        Decimal128IEEETC{ "-7.50", BID128{ 0x2ee, 0xb03c000000000000 },
+            DPD128{ 0x3d0, 0xa207800000000000 } },
+        Decimal128IEEETC{ "1234567890123456789012345678901234",
+            BID128{ 0xde825cd07e96aff2, 0x30403cde6fff9732 },
+            DPD128{ 0x6f3c127177823534, 0x2608134b9c1e28e5 } },
+        Decimal128IEEETC{ "-1234567890123456789012345678901234e-6176",
+            BID128{ 0xde825cd07e96aff2, 0x80003cde6fff9732 },
+            DPD128{ 0x6f3c127177823534, 0x8400134b9c1e28e5 } },
+        Decimal128IEEETC{ "9999999999999999999999999999999999e6111",
+            BID128{ 0x378d8e63ffffffff, 0x5fffed09bead87c0 },
+            DPD128{ 0xf3fcff3fcff3fcff, 0x77ffcff3fcff3fcf } },
+        Decimal128IEEETC{ "9e6111", BID128{ 9, 0x5ffe000000000000 },
+            DPD128{ 9, 0x43ffc00000000000 } },
+        Decimal128IEEETC{ "1e-6176", BID128{ 1, 0 }, DPD128{ 1, 0 } },
+    }
+    for i, tc := range testCases {
+        value, err := ParseDecimal128(tc.str)
+        if err!=nil {
+            t.Fatalf("Can't parse %v: %v", tc.str, err)
+        }
+        bid, err := value.BID()
+        if tc.bid!=bid || err!=nil {
+            t.Errorf("Result mismatch: %d: bid(%v)->%v!=%v,%v", i, tc.str, tc.bid, bid, err)
+        }
+        dpd, err := value.DPD()
+        if tc.dpd!=dpd || err!=nil {
+            t.Errorf("Result mismatch: %d: dpd(%v)->%v!=%v,%v", i, tc.str, tc.dpd, dpd, err)
+        }
+        result, err := tc.bid.Decimal128()
+        if value!=result || err!=nil {
+            t.Errorf("Result mismatch: %d: frombid(%v)->%v!=%v,%v",
+                     i, tc.bid, value, result, err)
+        }
+        result, err = tc.dpd.Decimal128()
+        if value!=result || err!=nil {
+            t.Errorf("Result mismatch: %d: fromdpd(%v)->%v!=%v,%v",
+                     i, tc.dpd, value, result, err)
+        }
+        if dpd = tc.bid.DPD(); tc.dpd!=dpd {
+            t.Errorf("Result mismatch: %d: bidtodpd(%v)->%v!=%v", i, tc.bid, tc.dpd, dpd)
+        }
+        if bid = tc.dpd.BID(); tc.bid!=bid {
+            t.Errorf("Result mismatch: %d: dpdtobid(%v)->%v!=%v", i, tc.dpd, tc.bid, bid)
+        }
+        if tc.bid.Class()!=DecimalFinite || tc.dpd.Class()!=DecimalFinite {
+            t.Errorf("Result mismatch: %d: class(%v)->finite!=%v,%v",
+                     i, tc.str, tc.bid.Class(), tc.dpd.Class())
+        }
+    }
+}
+
+type Decimal128NormalizeTC struct {
+    value Decimal128
+    expected BID128
+    expError error
+}
+
+func TestDecimal128IEEENormalize(t *testing.T) {
+    testCases := []Decimal128NormalizeTC {
+        // trailing zeroes are removed from coefficient
+        Decimal128NormalizeTC{ Decimal128{ UInt128{ 0x38c15b0a00000000, 0x314dc6448d93 },
+            0, false }, BID128{ 0x38c15b0a00000000, 0x3040314dc6448d93 }, nil },
+        Decimal128NormalizeTC{ Decimal128{ UInt128{ 0x098a224000000000, 0x4b3b4ca85a86c47a },
+            -5, false }, BID128{ 0x38c15b0a00000000, 0x3040314dc6448d93 }, nil },
+        Decimal128NormalizeTC{ Decimal128{ MaxUInt128, 0, false }, BID128{},
+            strconv.ErrRange },
+        // clamping of exponent
+        Decimal128NormalizeTC{ Decimal128{ UInt128{ 1, 0 }, 6144, false },
+            BID128{ 0x38c15b0a00000000, 0x5ffe314dc6448d93 }, nil },
+        Decimal128NormalizeTC{ Decimal128{ UInt128{ 1, 0 }, 6145, false },
+            BID128{}, strconv.ErrRange },
+        Decimal128NormalizeTC{ Decimal128{ UInt128{ 0, 0 }, 9000, false },
+            BID128{ 0, 0x5ffe000000000000 }, nil },
+        Decimal128NormalizeTC{ Decimal128{ UInt128{ 0, 0 }, -9000, true },
+            BID128{ 0, 0x8000000000000000 }, nil },
+        Decimal128NormalizeTC{ Decimal128{ UInt128{ 100, 0 }, -6178, false },
+            BID128{ 1, 0 }, nil },
+        Decimal128NormalizeTC{ Decimal128{ UInt128{ 101, 0 }, -6178, false },
+            BID128{}, strconv.ErrRange },
+        Decimal128NormalizeTC{ Decimal128{ UInt128{ 1, 0 }, -7000, false },
+            BID128{}, strconv.ErrRange },
+    }
+    for i, tc := range testCases {
+        a := tc.value
+        result, err := tc.value.BID()
+        if tc.expected!=result || tc.expError!=err {
+            t.Errorf("Result mismatch: %d: bid(%v)->%v,%v!=%v,%v",
+                     i, tc.value, tc.expected, tc.expError, result, err)
+        }
+        if tc.value!=a {
+            t.Errorf("Argument has been modified: %d: %v!=%v", i, a, tc.value)
+        }
+        dresult, err := tc.value.DPD()
+        if tc.expError!=err || (err==nil && tc.expected.DPD()!=dresult) {
+            t.Errorf("Result mismatch: %d: dpd(%v)->%v,%v!=%v,%v",
+                     i, tc.value, tc.expected.DPD(), tc.expError, dresult, err)
+        }
+    }
+}
+
+type Decimal128SpecialTC struct {
+    class DecimalClass
+    neg bool
+    payload UInt128
+    bid BID128
+    dpd DPD128
+    expPayload UInt128
+}
+
+func TestDecimal128IEEESpecial(t *testing.T) {
+    testCases := []Decimal128SpecialTC {
+        Decimal128SpecialTC{ DecimalInf, false, UInt128{},
+            BID128{ 0, 0x7800000000000000 }, DPD128{ 0, 0x7800000000000000 }, UInt128{} },
+        Decimal128SpecialTC{ DecimalInf, true, UInt128{ 5, 0 },
+            BID128{ 0, 0xf800000000000000 }, DPD128{ 0, 0xf800000000000000 }, UInt128{} },
+        Decimal128SpecialTC{ DecimalQNaN, false, UInt128{},
+            BID128{ 0, 0x7c00000000000000 }, DPD128{ 0, 0x7c00000000000000 }, UInt128{} },
+        Decimal128SpecialTC{ DecimalQNaN, true, UInt128{ 123456, 0 },
+            BID128{ 0x1e240, 0xfc00000000000000 }, DPD128{ 0x28e56, 0xfc00000000000000 },
+            UInt128{ 123456, 0 } },
+        Decimal128SpecialTC{ DecimalSNaN, false, UInt128{ 123456, 0 },
+            BID128{ 0x1e240, 0x7e00000000000000 }, DPD128{ 0x28e56, 0x7e00000000000000 },
+            UInt128{ 123456, 0 } },
+        // too big payload
+        Decimal128SpecialTC{ DecimalSNaN, false, UInt128{ 0x38c15b0a00000000, 0x314dc6448d93 },
+            BID128{ 0, 0x7e00000000000000 }, DPD128{ 0, 0x7e00000000000000 }, UInt128{} },
+        Decimal128SpecialTC{ DecimalFinite, true, UInt128{},
+            BID128{ 0, 0xb040000000000000 }, DPD128{ 0, 0xa208000000000000 }, UInt128{} },
+    }
+    for i, tc := range testCases {
+        bid := MakeBID128Special(tc.class, tc.neg, tc.payload)
+        dpd := MakeDPD128Special(tc.class, tc.neg, tc.payload)
+        if tc.bid!=bid || tc.dpd!=dpd {
+            t.Errorf("Result mismatch: %d: special(%v,%v,%v)->%v,%v!=%v,%v",
+                     i, tc.class, tc.neg, tc.payload, tc.bid, tc.dpd, bid, dpd)
+        }
+        if bid.Class()!=tc.class || dpd.Class()!=tc.class ||
+            bid.Signbit()!=tc.neg || dpd.Signbit()!=tc.neg {
+            t.Errorf("Result mismatch: %d: class(%v,%v)->%v,%v!=%v,%v,%v,%v",
+                     i, bid, dpd, tc.class, tc.neg, bid.Class(), dpd.Class(),
+                     bid.Signbit(), dpd.Signbit())
+        }
+        if bid.Payload()!=tc.expPayload || dpd.Payload()!=tc.expPayload {
+            t.Errorf("Result mismatch: %d: payload(%v,%v)->%v!=%v,%v",
+                     i, bid, dpd, tc.expPayload, bid.Payload(), dpd.Payload())
+        }
+        if tc.bid.DPD()!=tc.dpd || tc.dpd.BID()!=tc.bid {
+            t.Errorf("Result mismatch: %d: convert(%v,%v)->%v,%v",
+                     i, tc.bid, tc.dpd, tc.bid.DPD(), tc.dpd.BID())
+        }
+        if tc.class!=DecimalFinite {
+            if _, err := bid.Decimal128(); err!=ErrNotFinite {
+                t.Errorf("Result mismatch: %d: frombid(%v)->%v!=%v",
+                         i, bid, ErrNotFinite, err)
+            }
+            if _, err := dpd.Decimal128(); err!=ErrNotFinite {
+                t.Errorf("Result mismatch: %d: fromdpd(%v)->%v!=%v",
+                         i, dpd, ErrNotFinite, err)
+            }
+        }
+    }
+}
+
+type Decimal128NonCanonicalTC struct {
+    bid BID128
+    expected Decimal128
+}
+
+func TestBID128NonCanonical(t *testing.T) {
+    testCases := []Decimal128NonCanonicalTC {
+        // coefficient greater than 10^34-1
+        Decimal128NonCanonicalTC{ BID128{ 0x378d8e6400000000, 0x3041ed09bead87c0 },
+            Decimal128{ UInt128{}, 0, false } },
+        // coefficient greater than 2^113
+        Decimal128NonCanonicalTC{ BID128{ 0, 0x6c10000000000000 },
+            Decimal128{ UInt128{}, 0, false } },
+        Decimal128NonCanonicalTC{ BID128{ 5, 0xeffffff000000000 },
+            Decimal128{ UInt128{}, 2015, true } },
+    }
+    for i, tc := range testCases {
+        result, err := tc.bid.Decimal128()
+        if tc.expected!=result || err!=nil {
+            t.Errorf("Result mismatch: %d: frombid(%v)->%v!=%v,%v",
+                     i, tc.bid, tc.expected, result, err)
+        }
+    }
+}
+
+// encoding and decoding must give same number
+func TestDecimal128IEEERandom(t *testing.T) {
+    rnd := rand.New(rand.NewSource(25))
+    for i:=0; i<5000; i++ {
+        coef := UInt128{ rnd.Uint64(), rnd.Uint64() }.Shr(uint(15+rnd.Intn(114)))
+        if coef.Cmp(decimal128MaxCoef)>0 {
+            coef = coef.Shr(1)
+        }
+        a := Decimal128{ coef, int32(rnd.Intn(decimal128MaxExp-decimal128MinExp+1)+
+                decimal128MinExp), rnd.Intn(2)==0 }
+        bid, err := a.BID()
+        if err!=nil {
+            t.Errorf("Result mismatch: %d: bid(%v)->%v", i, a, err)
+        }
+        dpd, err := a.DPD()
+        if err!=nil {
+            t.Errorf("Result mismatch: %d: dpd(%v)->%v", i, a, err)
+        }
+        if result, err := bid.Decimal128(); a!=result || err!=nil {
+            t.Errorf("Result mismatch: %d: frombid(%v)->%v!=%v,%v", i, bid, a, result, err)
+        }
+        if result, err := dpd.Decimal128(); a!=result || err!=nil {
+            t.Errorf("Result mismatch: %d: fromdpd(%v)->%v!=%v,%v", i, dpd, a, result, err)
+        }
+        if bid.DPD()!=dpd || dpd.BID()!=bid {
+            t.Errorf("Result mismatch: %d: convert(%v,%v)->%v,%v",
+                     i, bid, dpd, bid.DPD(), dpd.BID())
+        }
+    }
+}
+
+func TestDecimal128MarshalBID(t *testing.T) {
+    a := Decimal128{ UInt128{ 1, 0 }, -6, false }
+    expected := []byte{ 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x34, 0x30 }
+    result, err := a.MarshalBID()
+    if !bytes.Equal(expected, result) || err!=nil {
+        t.Errorf("Result mismatch: marshalbid(%v)->%v!=%v,%v", a, expected, result, err)
+    }
+    var v Decimal128
+    if err = v.UnmarshalBID(expected); v!=a || err!=nil {
+        t.Errorf("Result mismatch: unmarshalbid(%v)->%v!=%v,%v", expected, a, v, err)
+    }
+    expected = []byte{ 0xd0, 3, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x80, 0x07, 0xa2 }
+    a = Decimal128{ UInt128{ 750, 0 }, -2, true }
+    result, err = a.MarshalDPD()
+    if !bytes.Equal(expected, result) || err!=nil {
+        t.Errorf("Result mismatch: marshaldpd(%v)->%v!=%v,%v", a, expected, result, err)
+    }
+    if err = v.UnmarshalDPD(expected); v!=a || err!=nil {
+        t.Errorf("Result mismatch: unmarshaldpd(%v)->%v!=%v,%v", expected, a, v, err)
+    }
+    // errors
+    if _, err = (Decimal128{ UInt128{ 1, 0 }, 7000, false }).MarshalBID();
+            err!=strconv.ErrRange {
+        t.Errorf("Result mismatch: marshalbid->%v!=%v", strconv.ErrRange, err)
+    }
+    inf := []byte{ 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x78 }
+    if err = v.UnmarshalBID(inf); err!=ErrNotFinite || v!=a {
+        t.Errorf("Result mismatch: unmarshalbid(inf)->%v!=%v,%v", ErrNotFinite, err, v)
+    }
+    infDPD := []byte{ 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0xf8 }
+    if err = v.UnmarshalDPD(infDPD); err!=ErrNotFinite || v!=a {
+        t.Errorf("Result mismatch: unmarshaldpd(inf)->%v!=%v,%v", ErrNotFinite, err, v)
+    }
+    if err = v.UnmarshalDPD(inf[:15]); err!=ErrDataTooSmall {
+        t.Errorf("Result mismatch: unmarshaldpd->%v!=%v", ErrDataTooSmall, err)
+    }
+}
+
+func BenchmarkDecimal128DPD(b *testing.B) {
+    a := Decimal128{ UInt128{ 0x7e96aff2, 0x3cde6fff9732 }, -18, false }
+    for i := 0; i < b.N; i++ {
+        a.DPD()
+    }
+}
+
+func BenchmarkDPD128Decimal128(b *testing.B) {
+    a := DPD128{ 0x6f3c127177823534, 0x2608134b9c1e28e5 }
+    for i := 0; i < b.N; i++ {
+        a.Decimal128()
+    }
+}
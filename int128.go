@@ -23,7 +23,6 @@
 package goint128
 
 import (
-    "bytes"
     "encoding/binary"
     "errors"
     "math"
@@ -34,6 +33,15 @@ import (
 
 type UInt128 [2]uint64
 
+// the greatest and the smallest 128-bit unsigned integer
+var MaxUInt128 UInt128 = maxUInt128
+var MinUInt128 UInt128 = minUInt128
+
+// copies used by library code (changing exported variables does not break
+// saturating arithmetic and parsing)
+var maxUInt128 = UInt128{ 0xffffffffffffffff, 0xffffffffffffffff }
+var minUInt128 = UInt128{ 0, 0 }
+
 // add 128-bit unsigned integers
 func (a UInt128) Add(b UInt128) UInt128 {
     var c UInt128
@@ -88,6 +96,60 @@ func (a UInt128) Sub64(b uint64) UInt128 {
     return c
 }
 
+// add 128-bit unsigned integers and return sum or error if overflow
+func (a UInt128) AddChecked(b UInt128) (UInt128, error) {
+    c, carry := a.AddC(b, 0)
+    if carry!=0 {
+        return UInt128{}, ErrOverflow
+    }
+    return c, nil
+}
+
+// subtract 128-bit unsigned integers and return difference or error if overflow
+func (a UInt128) SubChecked(b UInt128) (UInt128, error) {
+    c, borrow := a.SubB(b, 0)
+    if borrow!=0 {
+        return UInt128{}, ErrOverflow
+    }
+    return c, nil
+}
+
+// add 128-bit unsigned integers with saturation (return max value if overflow)
+func (a UInt128) SatAdd(b UInt128) UInt128 {
+    c, carry := a.AddC(b, 0)
+    if carry!=0 {
+        return maxUInt128
+    }
+    return c
+}
+
+// add 128-bit unsigned integer and 64-bit unsigned integer with saturation
+func (a UInt128) SatAdd64(b uint64) UInt128 {
+    c, carry := a.AddC(UInt128{ b, 0 }, 0)
+    if carry!=0 {
+        return maxUInt128
+    }
+    return c
+}
+
+// subtract 128-bit unsigned integers with saturation (return zero if overflow)
+func (a UInt128) SatSub(b UInt128) UInt128 {
+    c, borrow := a.SubB(b, 0)
+    if borrow!=0 {
+        return minUInt128
+    }
+    return c
+}
+
+// subtract 64-bit unsigned from 128-bit unsigned integer with saturation
+func (a UInt128) SatSub64(b uint64) UInt128 {
+    c, borrow := a.SubB(UInt128{ b, 0 }, 0)
+    if borrow!=0 {
+        return minUInt128
+    }
+    return c
+}
+
 // compare 128-bit unsigned integer and return 0 if they equal,
 // 1 if first is greater than second, or -1 if first is lesser than second
 func (a UInt128) Cmp(b UInt128) int {
@@ -154,6 +216,112 @@ func (a UInt128) Shl(b uint) UInt128 {
     return UInt128{ a[0]<<b, (a[1]<<b) | (a[0]>>(64-b)) }
 }
 
+// multiply 128-bit unsigned integers and return product or error if overflow
+func (a UInt128) MulChecked(b UInt128) (UInt128, error) {
+    if a[1]!=0 && b[1]!=0 {
+        return UInt128{}, ErrOverflow
+    }
+    var c UInt128
+    var carry uint64
+    c[1], c[0] = Mul64(a[0], b[0])
+    hi1, lo1 := Mul64(a[1], b[0])
+    hi2, lo2 := Mul64(a[0], b[1])
+    if hi1!=0 || hi2!=0 {
+        return UInt128{}, ErrOverflow
+    }
+    c[1], carry = Add64(c[1], lo1, 0)
+    if carry!=0 {
+        return UInt128{}, ErrOverflow
+    }
+    c[1], carry = Add64(c[1], lo2, 0)
+    if carry!=0 {
+        return UInt128{}, ErrOverflow
+    }
+    return c, nil
+}
+
+// multiply 128-bit unsigned integer and 64-bit unsigned integer and
+// return product or error if overflow
+func (a UInt128) Mul64Checked(b uint64) (UInt128, error) {
+    var c UInt128
+    var carry uint64
+    c[1], c[0] = Mul64(a[0], b)
+    hi, lo := Mul64(a[1], b)
+    if hi!=0 {
+        return UInt128{}, ErrOverflow
+    }
+    c[1], carry = Add64(c[1], lo, 0)
+    if carry!=0 {
+        return UInt128{}, ErrOverflow
+    }
+    return c, nil
+}
+
+// multiply 128-bit unsigned integers with saturation (return max value if overflow)
+func (a UInt128) SatMul(b UInt128) UInt128 {
+    hi, lo := a.MulFull(b)
+    if hi[0]!=0 || hi[1]!=0 {
+        return maxUInt128
+    }
+    return lo
+}
+
+// multiply 128-bit unsigned integer and 64-bit unsigned integer with saturation
+func (a UInt128) SatMul64(b uint64) UInt128 {
+    hi, lo := a.MulFull(UInt128{ b, 0 })
+    if hi[0]!=0 || hi[1]!=0 {
+        return maxUInt128
+    }
+    return lo
+}
+
+// raise 128-bit unsigned integer to power exp and return result or
+// error if overflow
+func (a UInt128) PowChecked(exp uint) (UInt128, error) {
+    c := UInt128{ 1, 0 }
+    var err error
+    for exp!=0 {
+        if exp&1!=0 {
+            if c, err = c.MulChecked(a); err!=nil {
+                return UInt128{}, err
+            }
+        }
+        exp >>= 1
+        if exp!=0 {
+            if a, err = a.MulChecked(a); err!=nil {
+                return UInt128{}, err
+            }
+        }
+    }
+    return c, nil
+}
+
+// raise 128-bit unsigned integer to power exp and return lower 128 bits of result
+func (a UInt128) Pow(exp uint) UInt128 {
+    c := UInt128{ 1, 0 }
+    for exp!=0 {
+        if exp&1!=0 {
+            c = c.Mul(a)
+        }
+        exp >>= 1
+        if exp!=0 {
+            a = a.Mul(a)
+        }
+    }
+    return c
+}
+
+// shift 128-bit unsigned integer left by b bits and return result or
+// error if any non-zero bit has been shifted out
+func (a UInt128) ShlChecked(b uint) (UInt128, error) {
+    if b==0 { return a, nil }
+    if a[0]==0 && a[1]==0 { return a, nil }
+    if b>=128 || !a.Shr(128-b).IsZero() {
+        return UInt128{}, ErrOverflow
+    }
+    return a.Shl(b), nil
+}
+
 // shift 128-bit unsigned integer right by b bits
 func (a UInt128) Shr(b uint) UInt128 {
     if b==0 { return a }
@@ -163,6 +331,100 @@ func (a UInt128) Shr(b uint) UInt128 {
     return UInt128{ (a[0]>>b) | (a[1]<<(64-b)), a[1]>>b }
 }
 
+// bitwise AND of 128-bit unsigned integers
+func (a UInt128) And(b UInt128) UInt128 {
+    return UInt128{ a[0]&b[0], a[1]&b[1] }
+}
+
+// bitwise OR of 128-bit unsigned integers
+func (a UInt128) Or(b UInt128) UInt128 {
+    return UInt128{ a[0]|b[0], a[1]|b[1] }
+}
+
+// bitwise XOR of 128-bit unsigned integers
+func (a UInt128) Xor(b UInt128) UInt128 {
+    return UInt128{ a[0]^b[0], a[1]^b[1] }
+}
+
+// bitwise AND NOT of 128-bit unsigned integers (a & ^b)
+func (a UInt128) AndNot(b UInt128) UInt128 {
+    return UInt128{ a[0]&^b[0], a[1]&^b[1] }
+}
+
+// bitwise NOT of 128-bit unsigned integer
+func (a UInt128) Not() UInt128 {
+    return UInt128{ ^a[0], ^a[1] }
+}
+
+// return number of leading zero bits (128 for zero)
+func (a UInt128) LeadingZeros() int {
+    if a[1]!=0 {
+        return bits.LeadingZeros64(a[1])
+    }
+    return bits.LeadingZeros64(a[0])+64
+}
+
+// return number of trailing zero bits (128 for zero)
+func (a UInt128) TrailingZeros() int {
+    if a[0]!=0 {
+        return bits.TrailingZeros64(a[0])
+    }
+    return bits.TrailingZeros64(a[1])+64
+}
+
+// return number of one bits
+func (a UInt128) OnesCount() int {
+    return bits.OnesCount64(a[0]) + bits.OnesCount64(a[1])
+}
+
+// return minimum number of bits required to represent integer (0 for zero)
+func (a UInt128) Len() int {
+    if a[1]!=0 {
+        return bits.Len64(a[1])+64
+    }
+    return bits.Len64(a[0])
+}
+
+// rotate 128-bit unsigned integer left by (k mod 128) bits.
+// to rotate right by k bits, call RotateLeft(-k)
+func (a UInt128) RotateLeft(k int) UInt128 {
+    n := uint(k) & 127
+    if n>=64 {
+        // swap words
+        a[0], a[1] = a[1], a[0]
+        n -= 64
+    }
+    if n==0 { return a }
+    return UInt128{ (a[0]<<n) | (a[1]>>(64-n)), (a[1]<<n) | (a[0]>>(64-n)) }
+}
+
+// return integer with reversed order of bits
+func (a UInt128) Reverse() UInt128 {
+    return UInt128{ bits.Reverse64(a[1]), bits.Reverse64(a[0]) }
+}
+
+// return integer with reversed order of bytes
+func (a UInt128) ReverseBytes() UInt128 {
+    return UInt128{ bits.ReverseBytes64(a[1]), bits.ReverseBytes64(a[0]) }
+}
+
+// return value of i-th bit (0 or 1)
+func (a UInt128) Bit(i uint) uint {
+    if i>=128 { return 0 }
+    return uint(a[i>>6]>>(i&63)) & 1
+}
+
+// return integer with i-th bit set to v (v must be 0 or 1)
+func (a UInt128) SetBit(i uint, v uint) UInt128 {
+    if i>=128 { return a }
+    if v!=0 {
+        a[i>>6] |= 1<<(i&63)
+    } else {
+        a[i>>6] &^= 1<<(i&63)
+    }
+    return a
+}
+
 // divide 128-bit unsigned integer by 64-bit unsigned integer and
 // return quotient and 64-bit remainder
 func (a UInt128) Div64(b uint64) (UInt128, uint64) {
@@ -205,9 +467,8 @@ func (a UInt128) Div64(b uint64) (UInt128, uint64) {
 // divide 256-bit (lo, hi) unsigned integer by 128-bit unsigned integer and return
 // 128-bit quotient and remainder
 func UInt128DivFull(hi, lo, b UInt128) (UInt128, UInt128) {
-    if b[1]==0 && hi[0]==0 && hi[1]==0 {
-        c, rem := lo.Div64(b[0])
-        return c, UInt128{rem, 0}
+    if hi[0]==0 && hi[1]==0 {
+        return lo.DivMod(b)
     }
     if b[0]==0 && b[1]==0 {
         panic("Divide by zero")
@@ -255,17 +516,19 @@ func UInt128DivFull(hi, lo, b UInt128) (UInt128, UInt128) {
     }
     // main loop
     var tmp UInt128
+    var top uint64 // bit shifted out of thi (if 1 then thi>=b)
     c := UInt128{0,0}
     for ; pos>0; pos-- {
         tmp[0], borrow = Sub64(thi[0], b[0], 0)
         tmp[1], borrow = Sub64(thi[1], b[1], borrow)
         c[1] = (c[0]>>63) | (c[1]<<1) // shift
         c[0] <<= 1
-        if borrow==0 {
+        if borrow==0 || top!=0 {
             thi = tmp
             c[0] |= 1
         }
         // shift T (shifted copy of A)
+        top = thi[1]>>63
         thi[1] = (thi[0]>>63) | (thi[1]<<1) // shift
         thi[0] = (tlo[1]>>63) | (thi[0]<<1)
         tlo[1] = (tlo[0]>>63) | (tlo[1]<<1)
@@ -276,13 +539,87 @@ func UInt128DivFull(hi, lo, b UInt128) (UInt128, UInt128) {
     tmp[1], borrow = Sub64(thi[1], b[1], borrow)
     c[1] = (c[0]>>63) | (c[1]<<1) // shift
     c[0] <<= 1
-    if borrow==0 {
+    if borrow==0 || top!=0 {
         thi = tmp
         c[0] |= 1
     }
     return c, thi
 }
 
+// divide 128-bit unsigned integers and return quotient and remainder
+func (a UInt128) DivMod(b UInt128) (UInt128, UInt128) {
+    if b[1]==0 {
+        if b[0]==0 {
+            panic("Divide by zero")
+        }
+        // divisor fits in 64-bit - divide by two 64-bit divisions
+        var q UInt128
+        var rem uint64
+        if a[1]<b[0] {
+            q[0], rem = Div64(a[1], a[0], b[0])
+        } else {
+            q[1], rem = Div64(0, a[1], b[0])
+            q[0], rem = Div64(rem, a[0], b[0])
+        }
+        return q, UInt128{ rem, 0 }
+    }
+    if a[1]<b[1] {
+        return UInt128{}, a
+    }
+    // normalize divisor (move highest bit to 127 position) and
+    // estimate quotient from highest 64 bits of divisor.
+    // estimated quotient can be greater than real quotient only by one.
+    n := uint(bits.LeadingZeros64(b[1]))
+    bn := b.Shl(n)
+    a1 := a.Shr(1)
+    q, _ := Div64(a1[1], a1[0], bn[1])
+    q >>= 63-n
+    if q!=0 {
+        q--
+    }
+    rem := a.Sub(b.Mul64(q))
+    // correct quotient
+    if rem.Cmp(b)>=0 {
+        q++
+        rem = rem.Sub(b)
+    }
+    return UInt128{ q, 0 }, rem
+}
+
+// divide 128-bit unsigned integers and return quotient and remainder,
+// or error if divisor is zero
+func (a UInt128) DivModChecked(b UInt128) (UInt128, UInt128, error) {
+    if b[0]==0 && b[1]==0 {
+        return UInt128{}, UInt128{}, ErrDivideByZero
+    }
+    q, r := a.DivMod(b)
+    return q, r, nil
+}
+
+// divide 128-bit unsigned integers and return quotient
+func (a UInt128) Div(b UInt128) UInt128 {
+    q, _ := a.DivMod(b)
+    return q
+}
+
+// divide 128-bit unsigned integers and return remainder
+func (a UInt128) Mod(b UInt128) UInt128 {
+    _, r := a.DivMod(b)
+    return r
+}
+
+// divide 128-bit unsigned integers and return quotient (same as Div)
+func (a UInt128) Quo(b UInt128) UInt128 {
+    q, _ := a.DivMod(b)
+    return q
+}
+
+// divide 128-bit unsigned integers and return remainder (same as Mod)
+func (a UInt128) Rem(b UInt128) UInt128 {
+    _, r := a.DivMod(b)
+    return r
+}
+
 var uint128_10powers []UInt128 = []UInt128{
     UInt128{1, 0},
     UInt128{10, 0},
@@ -325,230 +662,512 @@ var uint128_10powers []UInt128 = []UInt128{
     UInt128{687399551400673280,   5421010862427522170},
 }
 
+// return floor of binary logarithm of 128-bit unsigned integer or -1 if zero
+func (a UInt128) Log2() int {
+    return a.Len()-1
+}
+
+// return floor of decimal logarithm of 128-bit unsigned integer or -1 if zero
+func (a UInt128) Log10() int {
+    return sort.Search(len(uint128_10powers), func(i int) bool {
+        return a.Cmp(uint128_10powers[i])<0
+    })-1
+}
+
+// return floor of logarithm of 128-bit unsigned integer in base b
+// or -1 if zero. panics if base is lesser than 2
+func (a UInt128) LogBase(b uint64) int {
+    if b<2 {
+        panic("Illegal base")
+    }
+    if a.IsZero() {
+        return -1
+    }
+    if b&(b-1)==0 {
+        // power of two
+        return a.Log2()/bits.TrailingZeros64(b)
+    }
+    p := UInt128{ 1, 0 }
+    k := 0
+    for {
+        np, err := p.Mul64Checked(b)
+        if err!=nil || np.Cmp(a)>0 {
+            return k
+        }
+        p = np
+        k++
+    }
+}
+
+// return number of decimal digits of 128-bit unsigned integer
+// (length of string returned by FormatBytes)
+func (a UInt128) DecimalDigits() int {
+    if a[0]==0 && a[1]==0 {
+        return 1
+    }
+    return a.Log10()+1
+}
+
+// decimal digits of numbers from 0 to 99
+const decimalDigitPairs = "00010203040506070809" +
+    "10111213141516171819" +
+    "20212223242526272829" +
+    "30313233343536373839" +
+    "40414243444546474849" +
+    "50515253545556575859" +
+    "60616263646566676869" +
+    "70717273747576777879" +
+    "80818283848586878889" +
+    "90919293949596979899"
+
+// greatest power of 10 that fits in 64-bit
+const pow10_19 uint64 = 10000000000000000000
+
+// put decimal digits of 64-bit value before position i in chars
+// (two digits at once) and pad by zeroes to n digits. return new position
+func putUint64Digits(chars []byte, i int, v uint64, n int) int {
+    start := i
+    for v>=100 {
+        q := v/100
+        d := (v-q*100)<<1
+        i -= 2
+        chars[i] = decimalDigitPairs[d]
+        chars[i+1] = decimalDigitPairs[d+1]
+        v = q
+    }
+    if v>=10 {
+        i -= 2
+        chars[i] = decimalDigitPairs[v<<1]
+        chars[i+1] = decimalDigitPairs[(v<<1)+1]
+    } else {
+        i--
+        chars[i] = byte('0'+v)
+    }
+    for start-i<n {
+        i--
+        chars[i] = '0'
+    }
+    return i
+}
+
+// append decimal form of 128-bit unsigned integer to bytes
+func (a UInt128) AppendFormat(dst []byte) []byte {
+    var chars [40]byte
+    i := len(chars)
+    // split into 19-digit chunks (at most 3 chunks)
+    for a[1]!=0 {
+        var rem uint64
+        a[1], rem = Div64(0, a[1], pow10_19)
+        a[0], rem = Div64(rem, a[0], pow10_19)
+        i = putUint64Digits(chars[:], i, rem, 19)
+    }
+    i = putUint64Digits(chars[:], i, a[0], 0)
+    return append(dst, chars[i:]...)
+}
+
 // format 128-bit unsigned integer to bytes
 func (a UInt128) FormatBytes() []byte {
-    if a[0]==0 && a[1]==0 { return []byte{ '0' } }
-    var borrow uint64
-    var chars [41]byte
-    i := sort.Search(len(uint128_10powers), func(ii int) bool {
-        _, borrow = Sub64(a[0], uint128_10powers[ii][0], 0)
-        _, borrow = Sub64(a[1], uint128_10powers[ii][1], borrow)
-        return borrow!=0 // a<uint128_10powers[ii]
-    })-1
-    end := i
-    if i<19 {
-        var tmpa, tmp, x, x1 uint64
-        tmp = a[0]
-        for ; i>=0; i-- {
-            // calculate digit
-            x = uint128_10powers[i][0]
-            var digit byte = '0'
-            {
-                x1 = x<<3
-                // check if 3 bit of digit - 8
-                tmpa, borrow = Sub64(tmp, x1, 0)
-                if borrow==0 {
-                    digit += 8
-                    tmp = tmpa
-                }
-                x1 = x<<2
-                // check if 2 bit of digit - 4
-                tmpa, borrow = Sub64(tmp, x1, 0)
-                if borrow==0 {
-                    digit += 4
-                    tmp = tmpa
-                }
-            }
-            // check if 1 bit of digit - 2
-            x1 = x<<1
-            tmpa, borrow = Sub64(tmp, x1, 0)
-            if borrow==0 {
-                digit += 2
-                tmp = tmpa
-            }
-            // check if 0 bit of digit - 1
-            tmpa, borrow = Sub64(tmp, x, 0)
-            if borrow==0 {
-                digit++
-                tmp = tmpa
-            }
-            chars[40-i] = digit
+    return a.AppendFormat(nil)
+}
+
+// format 128-bit unsigned integer to decimal string (replaces former Format() string)
+func (a UInt128) FormatString() string {
+    return string(a.FormatBytes())
+}
+
+const lowerDigits = "0123456789abcdefghijklmnopqrstuvwxyz"
+const upperDigits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// append 128-bit unsigned integer in given base (2..36) to bytes.
+// if upper is true then digits above 9 are upper-case letters.
+// if number has fewer digits than width then it is padded by zeroes.
+func (a UInt128) AppendBaseWidth(dst []byte, base int, upper bool, width int) []byte {
+    if base<2 || base>36 {
+        panic("Illegal base")
+    }
+    digits := lowerDigits
+    if upper { digits = upperDigits }
+    var chars [128]byte
+    i := len(chars)
+    b := uint64(base)
+    if base&(base-1)==0 {
+        // power of two - use shifts
+        shift := uint(bits.TrailingZeros64(b))
+        mask := b-1
+        for a[1]!=0 || a[0]>=b {
+            i--
+            chars[i] = digits[a[0]&mask]
+            a = a.Shr(shift)
         }
     } else {
-        var tmpa, tmp, x, x1 UInt128
-        var borrow uint64
-        tmp = a
-        for ; i>=19; i-- {
-            // calculate digit
-            x = uint128_10powers[i]
-            var digit byte = '0'
-            if i<38 {
-                x1[1] = (x[1]<<3) | (x[0]>>61)
-                x1[0] = x[0]<<3
-                // check if 3 bit of digit - 8
-                tmpa[0], borrow = Sub64(tmp[0], x1[0], 0)
-                tmpa[1], borrow = Sub64(tmp[1], x1[1], borrow)
-                if borrow==0 {
-                    digit += 8
-                    tmp = tmpa
-                }
-                x1[1] = (x[1]<<2) | (x[0]>>62)
-                x1[0] = x[0]<<2
-                // check if 2 bit of digit - 4
-                tmpa[0], borrow = Sub64(tmp[0], x1[0], 0)
-                tmpa[1], borrow = Sub64(tmp[1], x1[1], borrow)
-                if borrow==0 {
-                    digit += 4
-                    tmp = tmpa
-                }
-            }
-            // check if 1 bit of digit - 2
-            x1[1] = (x[1]<<1) | (x[0]>>63)
-            x1[0] = x[0]<<1
-            tmpa[0], borrow = Sub64(tmp[0], x1[0], 0)
-            tmpa[1], borrow = Sub64(tmp[1], x1[1], borrow)
-            if borrow==0 {
-                digit += 2
-                tmp = tmpa
-            }
-            // check if 0 bit of digit - 1
-            tmpa[0], borrow = Sub64(tmp[0], x[0], 0)
-            tmpa[1], borrow = Sub64(tmp[1], x[1], borrow)
-            if borrow==0 {
-                digit++
-                tmp = tmpa
-            }
-            chars[40-i] = digit
-        }
-        for ; i>=0; i-- {
-            // calculate digit
-            x[0] = uint128_10powers[i][0]
-            var digit byte = '0'
-            {
-                x1[0] = x[0]<<3
-                // check if 3 bit of digit - 8
-                tmpa[0], borrow = Sub64(tmp[0], x1[0], 0)
-                if borrow==0 {
-                    digit += 8
-                    tmp[0] = tmpa[0]
-                }
-                x1[0] = x[0]<<2
-                // check if 2 bit of digit - 4
-                tmpa[0], borrow = Sub64(tmp[0], x1[0], 0)
-                if borrow==0 {
-                    digit += 4
-                    tmp[0] = tmpa[0]
-                }
-            }
-            // check if 1 bit of digit - 2
-            x1[0] = x[0]<<1
-            tmpa[0], borrow = Sub64(tmp[0], x1[0], 0)
-            if borrow==0 {
-                digit += 2
-                tmp[0] = tmpa[0]
-            }
-            // check if 0 bit of digit - 1
-            tmpa[0], borrow = Sub64(tmp[0], x[0], 0)
-            if borrow==0 {
-                digit++
-                tmp[0] = tmpa[0]
+        // divide by greatest power of base that fits in 64-bit
+        bb, n := b, 1
+        for bb <= math.MaxUint64/b {
+            bb *= b
+            n++
+        }
+        for a[1]!=0 {
+            var rem uint64
+            a[1], rem = Div64(0, a[1], bb)
+            a[0], rem = Div64(rem, a[0], bb)
+            for j:=0; j<n; j++ {
+                i--
+                chars[i] = digits[rem%b]
+                rem /= b
             }
-            chars[40-i] = digit
+        }
+        for a[0]>=b {
+            i--
+            chars[i] = digits[a[0]%b]
+            a[0] /= b
         }
     }
-    return chars[40-end:]
+    i--
+    chars[i] = digits[a[0]]
+    for pad := width-(len(chars)-i); pad>0; pad-- {
+        dst = append(dst, '0')
+    }
+    return append(dst, chars[i:]...)
 }
 
-// format 128-bit unsigned integer to string
-func (a UInt128) Format() string {
-    return string(a.FormatBytes())
+// append 128-bit unsigned integer in given base (2..36) to bytes
+func (a UInt128) AppendBase(dst []byte, base int) []byte {
+    return a.AppendBaseWidth(dst, base, false, 0)
 }
 
-// parse unsigned integer from string and return value and error (nil if no error)
-func ParseUInt128(str string) (UInt128, error) {
-    lastDigitValue := UInt128{ 11068046444225730969, 1844674407370955161 }
+// format 128-bit unsigned integer to string in given base (2..36)
+func (a UInt128) FormatBase(base int) string {
+    return string(a.AppendBaseWidth(nil, base, false, 0))
+}
+
+// format 128-bit unsigned integer to string in given base (2..36)
+// with upper-case digits option and zero-padding to width
+func (a UInt128) FormatBaseWidth(base int, upper bool, width int) string {
+    return string(a.AppendBaseWidth(nil, base, upper, width))
+}
+
+// error of parsing number. contains name of function, input
+// and byte offset of first bad character in input
+type NumError struct {
+    Func string // function name
+    Num string // input
+    Offset int // offset of first bad character (or first digit out of range)
+    Err error // reason (strconv.ErrSyntax, strconv.ErrRange or other)
+}
+
+func (e *NumError) Error() string {
+    return "goint128." + e.Func + ": parsing " + strconv.Quote(e.Num) + " at offset " +
+            strconv.Itoa(e.Offset) + ": " + e.Err.Error()
+}
+
+// return reason of error (for errors.Is and errors.As since Go 1.13)
+func (e *NumError) Unwrap() error {
+    return e.Err
+}
+
+func numError(fn, str string, offset int, err error) error {
+    return &NumError{ fn, str, offset, err }
+}
+
+// return offset of first digit for which decimal number is greater than limit
+// or -1 if number is not greater than limit. digits must be decimal digits
+func decimalRangeOffset(digits string, limit UInt128) int {
+    var v UInt128
+    var err error
+    for i:=0; i<len(digits); i++ {
+        if v, err = v.Mul64Checked(10); err!=nil {
+            return i
+        }
+        v, err = v.AddChecked(UInt128{ uint64(digits[i]-'0'), 0 })
+        if err!=nil || v.Cmp(limit)>0 {
+            return i
+        }
+    }
+    return -1
+}
+
+// parse unsigned decimal integer from string and return value, offset of
+// first bad character (or first digit out of range) and error.
+// digits are accumulated in 19-digit chunks that fit in 64-bit
+func parseUInt128(str string) (UInt128, int, error) {
     slen := len(str)
+    if slen==0 {
+        return UInt128{}, 0, strconv.ErrSyntax
+    }
+    // first chunk is shorter, next chunks have 19 digits
+    n := slen%19
+    if n==0 { n = 19 }
     var out UInt128
-    var carry uint64
-    var i int
-    for i=0; i<19 && i<slen && str[i]>='0' && str[i]<='9'; i++ {
-        digit := byte(str[i])-'0'
-        // multiply by 10
-        out[0] *= 10
-        // add digit
-        out[0] += uint64(digit)
-    }
-    
-    for ; i<slen && str[i]>='0' && str[i]<='9'; i++ {
-        if out[1]>lastDigitValue[1] ||
-            (out[1]==lastDigitValue[1] && out[0] > lastDigitValue[0]) {
-            return UInt128{}, strconv.ErrRange
-        }
-        digit := byte(str[i])-'0'
-        temp := out
-        // multiply by 10
-        out[1] = (temp[1]<<3) | (temp[0]>>61)
-        out[0] = temp[0]<<3
-        out[0], carry = Add64(out[0], temp[0]<<1, 0)
-        out[1], _ = Add64(out[1], (temp[1]<<1) | (temp[0]>>63), carry)
-        // add digit
-        out[0], carry = Add64(out[0], uint64(digit), 0)
+    var hi, carry uint64
+    for i:=0; i<slen; n=19 {
+        var chunk uint64
+        for end:=i+n; i<end; i++ {
+            digit := str[i]-'0'
+            if digit>9 {
+                // number can be out of range before bad character
+                if offset := decimalRangeOffset(str[:i], maxUInt128); offset>=0 {
+                    return UInt128{}, offset, strconv.ErrRange
+                }
+                return UInt128{}, i, strconv.ErrSyntax
+            }
+            chunk = chunk*10 + uint64(digit)
+        }
+        // out = out*10^19 + chunk
+        hi, out[1] = Mul64(out[1], pow10_19)
+        if hi!=0 {
+            return UInt128{}, decimalRangeOffset(str, maxUInt128), strconv.ErrRange
+        }
+        hi, out[0] = Mul64(out[0], pow10_19)
+        out[1], carry = Add64(out[1], hi, 0)
+        if carry!=0 {
+            return UInt128{}, decimalRangeOffset(str, maxUInt128), strconv.ErrRange
+        }
+        out[0], carry = Add64(out[0], chunk, 0)
         out[1], carry = Add64(out[1], 0, carry)
         if carry!=0 {
-            return UInt128{}, strconv.ErrRange
+            return UInt128{}, decimalRangeOffset(str, maxUInt128), strconv.ErrRange
         }
     }
-    if i==0 || i!=slen {
-        return UInt128{}, strconv.ErrSyntax
+    return out, 0, nil
+}
+
+// parse unsigned decimal integer from bytes and return value, offset of
+// first bad character and error
+func parseUInt128Bytes(str []byte) (UInt128, int, error) {
+    return parseUInt128(bytesToString(str))
+}
+
+// parse unsigned integer from string and return value and error (nil if no error)
+func ParseUInt128(str string) (UInt128, error) {
+    out, offset, err := parseUInt128(str)
+    if err!=nil {
+        return UInt128{}, numError("ParseUInt128", str, offset, err)
     }
     return out, nil
 }
 
 func ParseUInt128Bytes(str []byte) (UInt128, error) {
-    lastDigitValue := UInt128{ 11068046444225730969, 1844674407370955161 }
+    out, offset, err := parseUInt128Bytes(str)
+    if err!=nil {
+        return UInt128{}, numError("ParseUInt128Bytes", string(str), offset, err)
+    }
+    return out, nil
+}
+
+// scanner of digits of unsigned integer in base, shared by base parsers.
+// handles base prefix (if base is 0) and underscores between digits
+type baseDigitScanner struct {
+    str string
+    pos int // offset of last read character (-1 if nothing read)
+    base uint64
+    base0 bool
+    // last seen character: '^' - begin, '0' - digit or prefix, '_' - underscore
+    saw byte
+}
+
+// prepare scanner for string: check base and skip prefix if base is 0.
+// base must be 0 or from 2 to 36. if base is 0 then base is determined by prefix:
+// 0x or 0X - 16, 0o or 0O - 8, 0b or 0B - 2, 0 - 8, otherwise 10.
+// error offset is always 0
+func (s *baseDigitScanner) init(str string, base int) error {
     slen := len(str)
+    if slen==0 {
+        return strconv.ErrSyntax
+    }
+    *s = baseDigitScanner{ str, -1, 0, base==0, '^' }
+    switch {
+    case base>=2 && base<=36:
+        // valid base
+    case s.base0:
+        base = 10
+        if str[0]=='0' {
+            if slen>=3 && (str[1]|0x20)=='b' {
+                base = 2
+                s.pos = 1
+            } else if slen>=3 && (str[1]|0x20)=='o' {
+                base = 8
+                s.pos = 1
+            } else if slen>=3 && (str[1]|0x20)=='x' {
+                base = 16
+                s.pos = 1
+            } else {
+                base = 8
+            }
+            if s.pos!=-1 { s.saw = '0' }
+        }
+    default:
+        return ErrInvalidBase
+    }
+    s.base = uint64(base)
+    return nil
+}
+
+// return next digit and true, or false if end of string. if character is bad or
+// underscore is trailing return error (offset of bad character in pos)
+func (s *baseDigitScanner) next() (uint64, bool, error) {
+    for s.pos++; s.pos<len(s.str); s.pos++ {
+        c := s.str[s.pos]
+        var digit byte
+        if c=='_' && s.base0 {
+            if s.saw!='0' {
+                return 0, false, strconv.ErrSyntax
+            }
+            s.saw = '_'
+            continue
+        } else if c>='0' && c<='9' {
+            digit = c-'0'
+        } else if (c|0x20)>='a' && (c|0x20)<='z' {
+            digit = (c|0x20)-'a'+10
+        } else {
+            return 0, false, strconv.ErrSyntax
+        }
+        if uint64(digit)>=s.base {
+            return 0, false, strconv.ErrSyntax
+        }
+        s.saw = '0'
+        return uint64(digit), true, nil
+    }
+    if s.saw!='0' {
+        // trailing underscore
+        s.pos = len(s.str)-1
+        return 0, false, strconv.ErrSyntax
+    }
+    return 0, false, nil
+}
+
+// parse unsigned integer from string in given base and return value,
+// offset of first bad character and error. value must not be greater than limit
+func parseUInt128Base(str string, base int, limit UInt128) (UInt128, int, error) {
+    var s baseDigitScanner
+    if err := s.init(str, base); err!=nil {
+        return UInt128{}, 0, err
+    }
+    b := s.base
     var out UInt128
-    var carry uint64
-    var i int
-    for i=0; i<19 && i<slen && str[i]>='0' && str[i]<='9'; i++ {
-        digit := byte(str[i])-'0'
-        // multiply by 10
-        out[0] *= 10
-        // add digit
-        out[0] += uint64(digit)
-    }
-    
-    for ; i<slen && str[i]>='0' && str[i]<='9'; i++ {
-        if out[1]>lastDigitValue[1] ||
-            (out[1]==lastDigitValue[1] && out[0] > lastDigitValue[0]) {
-            return UInt128{}, strconv.ErrRange
-        }
-        digit := byte(str[i])-'0'
-        temp := out
-        // multiply by 10
-        out[1] = (temp[1]<<3) | (temp[0]>>61)
-        out[0] = temp[0]<<3
-        out[0], carry = Add64(out[0], temp[0]<<1, 0)
-        out[1], _ = Add64(out[1], (temp[1]<<1) | (temp[0]>>63), carry)
-        // add digit
-        out[0], carry = Add64(out[0], uint64(digit), 0)
-        out[1], carry = Add64(out[1], 0, carry)
+    var carry, hi uint64
+    for {
+        digit, ok, err := s.next()
+        if err!=nil {
+            return UInt128{}, s.pos, err
+        } else if !ok {
+            break
+        }
+        // multiply by base and add digit
+        hi, out[1] = Mul64(out[1], b)
+        if hi!=0 {
+            return UInt128{}, s.pos, strconv.ErrRange
+        }
+        hi, out[0] = Mul64(out[0], b)
+        out[1], carry = Add64(out[1], hi, 0)
         if carry!=0 {
-            return UInt128{}, strconv.ErrRange
+            return UInt128{}, s.pos, strconv.ErrRange
+        }
+        out[0], carry = Add64(out[0], digit, 0)
+        out[1], carry = Add64(out[1], 0, carry)
+        if carry!=0 || out.Cmp(limit)>0 {
+            return UInt128{}, s.pos, strconv.ErrRange
         }
     }
-    if i==0 || i!=slen {
-        return UInt128{}, strconv.ErrSyntax
+    return out, 0, nil
+}
+
+// parse unsigned integer from string in given base and return value and error
+// (nil if no error). base must be 0 or from 2 to 36. if base is 0 then base
+// is determined by prefix: 0x or 0X - 16, 0o or 0O - 8, 0b or 0B - 2,
+// 0 - 8, otherwise 10. if base is 0 then underscores may separate digits.
+func ParseUInt128Base(str string, base int) (UInt128, error) {
+    out, offset, err := parseUInt128Base(str, base, maxUInt128)
+    if err!=nil {
+        return UInt128{}, numError("ParseUInt128Base", str, offset, err)
     }
     return out, nil
 }
 
-// convert 128-unsigned integer to 64-bit float point value
+// parse unsigned integer from bytes in given base and return value and error
+// (nil if no error). rules are same as in ParseUInt128Base
+func ParseUInt128BaseBytes(str []byte, base int) (UInt128, error) {
+    out, offset, err := parseUInt128Base(bytesToString(str), base, maxUInt128)
+    if err!=nil {
+        return UInt128{}, numError("ParseUInt128BaseBytes", string(str), offset, err)
+    }
+    return out, nil
+}
+
+// rounding mode
+type RoundingMode int
+
+const (
+    ToNearestEven RoundingMode = iota // round to nearest, ties to even
+    ToZero // round toward zero (truncate)
+    ToNegativeInf // round toward negative infinity (floor)
+    ToPositiveInf // round toward positive infinity (ceil)
+    ToNearestAway // round to nearest, ties away from zero (half up)
+    AwayFromZero // round away from zero (up)
+)
+
+// round 128-bit unsigned integer to mbits-bit mantissa and return mantissa,
+// binary exponent and true if rounding is exact
+func (a UInt128) roundMantissa(mbits uint, mode RoundingMode) (uint64, int, bool) {
+    n := uint(a.Len())
+    if n<=mbits {
+        return a[0], 0, true
+    }
+    shift := n-mbits
+    m := a.Shr(shift)[0]
+    rem := a.And(maxUInt128.Shr(128-shift))
+    if rem.IsZero() {
+        return m, int(shift), true
+    }
+    var up bool
+    switch mode {
+    case ToNearestEven:
+        half := UInt128{}.SetBit(shift-1, 1)
+        c := rem.Cmp(half)
+        up = c>0 || (c==0 && m&1!=0)
+    case ToNearestAway:
+        half := UInt128{}.SetBit(shift-1, 1)
+        up = rem.Cmp(half)>=0
+    case ToPositiveInf, AwayFromZero:
+        up = true
+    }
+    if up {
+        m++
+        if m==1<<mbits {
+            m >>= 1
+            shift++
+        }
+    }
+    return m, int(shift), false
+}
+
+// convert 128-unsigned integer to 64-bit float point value with rounding
+// and return value and true if conversion is exact
+func (a UInt128) ToFloat64Round(mode RoundingMode) (float64, bool) {
+    m, e, exact := a.roundMantissa(53, mode)
+    return math.Ldexp(float64(m), e), exact
+}
+
+// convert 128-unsigned integer to 32-bit float point value with rounding
+// and return value and true if conversion is exact. value can be infinity
+// if rounded value is out of range
+func (a UInt128) ToFloat32Round(mode RoundingMode) (float32, bool) {
+    m, e, exact := a.roundMantissa(24, mode)
+    return float32(math.Ldexp(float64(m), e)), exact
+}
+
+// convert 128-unsigned integer to 64-bit float point value (rounded to nearest)
 func (a UInt128) ToFloat64() float64 {
-    return float64(a[0]) + float64(a[1])*float64(18446744073709551616.0)
+    v, _ := a.ToFloat64Round(ToNearestEven)
+    return v
 }
 
-// convert 64-bit float point value to 128-bit unsigned integer
+// convert 128-unsigned integer to 32-bit float point value (rounded to nearest)
+func (a UInt128) ToFloat32() float32 {
+    v, _ := a.ToFloat32Round(ToNearestEven)
+    return v
+}
+
+// convert 64-bit float point value to 128-bit unsigned integer (truncate)
 func Float64ToUInt128(a float64) (UInt128, error) {
     if math.IsNaN(a) || a >= 340282366920938463463374607431768211456.0 || a < 0.0 {
         return UInt128{}, strconv.ErrRange
@@ -563,6 +1182,54 @@ func Float64ToUInt128(a float64) (UInt128, error) {
     return UInt128{ ami<<uint(ae), ami>>uint(64-ae) }, nil
 }
 
+// convert 64-bit float point value to 128-bit unsigned integer with rounding
+// and return value, true if conversion is exact and error (strconv.ErrRange
+// if rounded value is out of range or value is NaN)
+func Float64ToUInt128Round(a float64, mode RoundingMode) (UInt128, bool, error) {
+    if math.IsNaN(a) || a >= 340282366920938463463374607431768211456.0 {
+        return UInt128{}, false, strconv.ErrRange
+    }
+    ip, frac := math.Modf(a)
+    if ip<0 {
+        return UInt128{}, false, strconv.ErrRange
+    }
+    if frac<0 {
+        // value between -1 and 0
+        if mode==ToNegativeInf || mode==AwayFromZero ||
+            (mode==ToNearestEven && frac < -0.5) || (mode==ToNearestAway && frac <= -0.5) {
+            return UInt128{}, false, strconv.ErrRange
+        }
+        return UInt128{}, false, nil
+    }
+    v, err := Float64ToUInt128(ip)
+    if err!=nil {
+        return UInt128{}, false, err
+    }
+    if frac==0 {
+        return v, true, nil
+    }
+    // fraction is only possible if value is lesser than 2^53
+    switch mode {
+    case ToNearestEven:
+        if frac>0.5 || (frac==0.5 && v[0]&1!=0) {
+            v[0]++
+        }
+    case ToNearestAway:
+        if frac>=0.5 {
+            v[0]++
+        }
+    case ToPositiveInf, AwayFromZero:
+        v[0]++
+    }
+    return v, false, nil
+}
+
+// convert 32-bit float point value to 128-bit unsigned integer with rounding.
+// rules are same as in Float64ToUInt128Round
+func Float32ToUInt128Round(a float32, mode RoundingMode) (UInt128, bool, error) {
+    return Float64ToUInt128Round(float64(a), mode)
+}
+
 // stringer
 
 func (a UInt128) String() string {
@@ -579,6 +1246,11 @@ func (a UInt128) MarshalBinary() (data []byte, err error) {
 }
 
 var ErrDataTooSmall error = errors.New("Data is too small")
+var ErrDivideByZero error = errors.New("Divide by zero")
+var ErrOverflow error = errors.New("Number overflow")
+var ErrEvenModulus error = errors.New("Even modulus")
+var ErrNotFinite error = errors.New("Number is not finite")
+var ErrInvalidBase error = errors.New("Invalid base")
 
 func (a *UInt128) UnmarshalBinary(data []byte) error {
     if len(data) < 16 { return ErrDataTooSmall }
@@ -592,31 +1264,45 @@ func (a UInt128) MarshalText() (text []byte, err error) {
 }
 
 func (a *UInt128) UnmarshalText(text []byte) error {
-    var err error
-    *a, err = ParseUInt128Bytes(text)
-    return err
+    out, offset, err := parseUInt128Bytes(text)
+    if err!=nil {
+        *a = UInt128{}
+        return numError("UnmarshalText", string(text), offset, err)
+    }
+    *a = out
+    return nil
 }
 
 
-func (a UInt128) MarshalJSON() ([]byte, error) {
+// append JSON form of 128-bit unsigned integer to bytes (number if value
+// fits in 64-bit, otherwise quoted number)
+func (a UInt128) AppendJSON(dst []byte) []byte {
     if a[1]==0 {
-        return []byte(a.Format()), nil
+        return a.AppendFormat(dst)
     }
-    var sb bytes.Buffer
-    sb.WriteRune('"')
-    sb.WriteString(a.Format())
-    sb.WriteRune('"')
-    return sb.Bytes(), nil
+    dst = append(dst, '"')
+    dst = a.AppendFormat(dst)
+    return append(dst, '"')
+}
+
+func (a UInt128) MarshalJSON() ([]byte, error) {
+    return a.AppendJSON(nil), nil
 }
 
 func (a *UInt128) UnmarshalJSON(data []byte) error {
     dlen := len(data)
-    var err error
+    str := data
+    start := 0
     if dlen>=2 && (data[0]=='"'||data[0]=='\'') &&
                     (data[dlen-1]=='"'||data[dlen-1]=='\'') {
-        *a, err = ParseUInt128(string(data[1:dlen-1]))
-        return err
+        str = data[1:dlen-1]
+        start = 1
     }
-    *a, err = ParseUInt128(string(data))
-    return err
+    out, offset, err := parseUInt128Bytes(str)
+    if err!=nil {
+        *a = UInt128{}
+        return numError("UnmarshalJSON", string(data), start+offset, err)
+    }
+    *a = out
+    return nil
 }
@@ -26,6 +26,9 @@ import (
     "encoding/json"
     "fmt"
     "math"
+    "math/big"
+    "math/rand"
+    "sort"
     "strconv"
     "testing"
 )
@@ -47,6 +50,17 @@ func getPanic2(f func()) (bool, string) {
     return paniced, panicStr
 }
 
+// return true if error is expected error or NumError with expected reason
+func errorMatch(expected, err error) bool {
+    if expected==nil {
+        return err==nil
+    }
+    if numErr, ok := err.(*NumError); ok {
+        err = numErr.Err
+    }
+    return err==expected
+}
+
 type UInt128TC struct {
     a, b UInt128
     expected UInt128
@@ -351,15 +365,633 @@ func TestUInt128Shr(t *testing.T) {
             UInt128{ 0x2b2e0d6f95ff2df1, 0 } },
     }
     for i, tc := range testCases {
-        a, b := tc.a, tc.b
-        result := tc.a.Shr(tc.b)
+        a, b := tc.a, tc.b
+        result := tc.a.Shr(tc.b)
+        if tc.expected!=result {
+            t.Errorf("Result mismatch: %d: %v>>%v->%v!=%v",
+                     i, tc.a, tc.b, tc.expected, result)
+        }
+        if tc.a!=a || tc.b!=b {
+            t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
+                     i, a, b, tc.a, tc.b)
+        }
+    }
+}
+
+type UInt128CheckedTC struct {
+    a, b UInt128
+    expected UInt128
+    expError error
+}
+
+func TestUInt128AddChecked(t *testing.T) {
+    testCases := []UInt128CheckedTC {
+        UInt128CheckedTC{ UInt128{ 0xffffffffffff1001, 0x2442 }, UInt128{ 0xf003, 0xa8bc },
+            UInt128{ 0x4, 0xccff }, nil },
+        UInt128CheckedTC{ UInt128{ 0xffffffffffffffff, 0xfffffffffffffffe },
+            UInt128{ 1, 1 }, UInt128{}, ErrOverflow },
+        UInt128CheckedTC{ UInt128{ 0xfffffffffffffffe, 0xffffffffffffffff },
+            UInt128{ 1, 0 }, UInt128{ 0xffffffffffffffff, 0xffffffffffffffff }, nil },
+        UInt128CheckedTC{ UInt128{ 0xffffffffffffffff, 0xffffffffffffffff },
+            UInt128{ 1, 0 }, UInt128{}, ErrOverflow },
+    }
+    for i, tc := range testCases {
+        a, b := tc.a, tc.b
+        result, err := tc.a.AddChecked(tc.b)
+        if tc.expected!=result || !errorMatch(tc.expError, err) {
+            t.Errorf("Result mismatch: %d: addchecked(%v,%v)->%v,%v!=%v,%v",
+                     i, tc.a, tc.b, tc.expected, tc.expError, result, err)
+        }
+        if tc.a!=a || tc.b!=b {
+            t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
+                     i, a, b, tc.a, tc.b)
+        }
+    }
+}
+
+func TestUInt128SubChecked(t *testing.T) {
+    testCases := []UInt128CheckedTC {
+        UInt128CheckedTC{ UInt128{ 0x4, 0xccff }, UInt128{ 0xffffffffffff1001, 0x2442 },
+            UInt128{ 0xf003, 0xa8bc }, nil },
+        UInt128CheckedTC{ UInt128{ 81185, 4252 }, UInt128{ 81183, 4253 },
+            UInt128{}, ErrOverflow },
+        UInt128CheckedTC{ UInt128{ 0, 0 }, UInt128{ 1, 0 }, UInt128{}, ErrOverflow },
+        UInt128CheckedTC{ UInt128{ 1, 0 }, UInt128{ 1, 0 }, UInt128{ 0, 0 }, nil },
+    }
+    for i, tc := range testCases {
+        a, b := tc.a, tc.b
+        result, err := tc.a.SubChecked(tc.b)
+        if tc.expected!=result || !errorMatch(tc.expError, err) {
+            t.Errorf("Result mismatch: %d: subchecked(%v,%v)->%v,%v!=%v,%v",
+                     i, tc.a, tc.b, tc.expected, tc.expError, result, err)
+        }
+        if tc.a!=a || tc.b!=b {
+            t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
+                     i, a, b, tc.a, tc.b)
+        }
+    }
+}
+
+func TestUInt128MulChecked(t *testing.T) {
+    testCases := []UInt128CheckedTC {
+        UInt128CheckedTC{ UInt128{ 0xffffffffffffffff, 0 }, UInt128{ 0xffffffffffffffff, 0 },
+            UInt128{ 1, 0xfffffffffffffffe }, nil },
+        UInt128CheckedTC{ UInt128{ 0, 1 }, UInt128{ 0, 1 }, UInt128{}, ErrOverflow },
+        UInt128CheckedTC{ UInt128{ 5, 1 }, UInt128{ 0x8000000000000000, 0 },
+            UInt128{ 0x8000000000000000, 0x8000000000000002 }, nil },
+        UInt128CheckedTC{ UInt128{ 5, 1 }, UInt128{ 0xffffffffffffffff, 0 },
+            UInt128{}, ErrOverflow },
+        UInt128CheckedTC{ UInt128{ 0xc9baa109a40baa11, 0x1839b9af9dc021 },
+            UInt128{ 0x49310ace3a1a15, 0 }, UInt128{}, ErrOverflow },
+        UInt128CheckedTC{ UInt128{ 0xffffffffffffffff, 0xffffffffffffffff }, UInt128{ 1, 0 },
+            UInt128{ 0xffffffffffffffff, 0xffffffffffffffff }, nil },
+        UInt128CheckedTC{ UInt128{ 0xffffffffffffffff, 0xffffffffffffffff }, UInt128{ 2, 0 },
+            UInt128{}, ErrOverflow },
+        UInt128CheckedTC{ UInt128{ 0xffffffffffffffff, 0x7fffffffffffffff }, UInt128{ 2, 0 },
+            UInt128{ 0xfffffffffffffffe, 0xffffffffffffffff }, nil },
+        UInt128CheckedTC{ UInt128{ 0, 0x8000000000000000 }, UInt128{ 2, 0 },
+            UInt128{}, ErrOverflow },
+    }
+    for i, tc := range testCases {
+        a, b := tc.a, tc.b
+        result, err := tc.a.MulChecked(tc.b)
+        if tc.expected!=result || !errorMatch(tc.expError, err) {
+            t.Errorf("Result mismatch: %d: mulchecked(%v,%v)->%v,%v!=%v,%v",
+                     i, tc.a, tc.b, tc.expected, tc.expError, result, err)
+        }
+        if tc.a!=a || tc.b!=b {
+            t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
+                     i, a, b, tc.a, tc.b)
+        }
+    }
+}
+
+type UInt128_64CheckedTC struct {
+    a UInt128
+    b uint64
+    expected UInt128
+    expError error
+}
+
+func TestUInt128Mul64Checked(t *testing.T) {
+    testCases := []UInt128_64CheckedTC {
+        UInt128_64CheckedTC{ UInt128{ 0xc9baa109a40baa11, 0x384b9a928941ac3 },
+            0x1839b9af9dc021, UInt128{}, ErrOverflow },
+        UInt128_64CheckedTC{ UInt128{ 0x2327f0eac961980e, 0x49f0f9 }, 0x11f82bb55bf,
+            UInt128{ 0x77f8d53cd5871872, 0x530ae9c8b7cb9049 }, nil },
+        UInt128_64CheckedTC{ UInt128{ 0xffffffffffffffff, 0x7fffffffffffffff }, 2,
+            UInt128{ 0xfffffffffffffffe, 0xffffffffffffffff }, nil },
+        UInt128_64CheckedTC{ UInt128{ 0xffffffffffffffff, 0x7fffffffffffffff }, 3,
+            UInt128{}, ErrOverflow },
+        UInt128_64CheckedTC{ UInt128{ 0xffffffffffffffff, 0xffffffffffffffff }, 1,
+            UInt128{ 0xffffffffffffffff, 0xffffffffffffffff }, nil },
+        UInt128_64CheckedTC{ UInt128{ 0, 0xffffffffffffffff }, 2, UInt128{}, ErrOverflow },
+    }
+    for i, tc := range testCases {
+        a, b := tc.a, tc.b
+        result, err := tc.a.Mul64Checked(tc.b)
+        if tc.expected!=result || !errorMatch(tc.expError, err) {
+            t.Errorf("Result mismatch: %d: mul64checked(%v,%v)->%v,%v!=%v,%v",
+                     i, tc.a, tc.b, tc.expected, tc.expError, result, err)
+        }
+        if tc.a!=a || tc.b!=b {
+            t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
+                     i, a, b, tc.a, tc.b)
+        }
+    }
+}
+
+type UInt128ShCheckedTC struct {
+    a UInt128
+    b uint
+    expected UInt128
+    expError error
+}
+
+func TestUInt128ShlChecked(t *testing.T) {
+    testCases := []UInt128ShCheckedTC {
+        UInt128ShCheckedTC{ UInt128{ 0x1234, 0 }, 100, UInt128{ 0, 0x1234000000000 }, nil },
+        UInt128ShCheckedTC{ UInt128{ 0x1234, 0 }, 115, UInt128{ 0, 0x91a0000000000000 }, nil },
+        UInt128ShCheckedTC{ UInt128{ 0x1234, 0 }, 116, UInt128{}, ErrOverflow },
+        UInt128ShCheckedTC{ UInt128{ 0x1234, 0 }, 128, UInt128{}, ErrOverflow },
+        UInt128ShCheckedTC{ UInt128{ 0, 0 }, 300, UInt128{ 0, 0 }, nil },
+        UInt128ShCheckedTC{ UInt128{ 0xffffffffffffffff, 0xffffffffffffffff }, 0,
+            UInt128{ 0xffffffffffffffff, 0xffffffffffffffff }, nil },
+        UInt128ShCheckedTC{ UInt128{ 0xffffffffffffffff, 0xffffffffffffffff }, 1,
+            UInt128{}, ErrOverflow },
+        UInt128ShCheckedTC{ UInt128{ 1, 0 }, 127, UInt128{ 0, 0x8000000000000000 }, nil },
+    }
+    for i, tc := range testCases {
+        a, b := tc.a, tc.b
+        result, err := tc.a.ShlChecked(tc.b)
+        if tc.expected!=result || !errorMatch(tc.expError, err) {
+            t.Errorf("Result mismatch: %d: shlchecked(%v,%v)->%v,%v!=%v,%v",
+                     i, tc.a, tc.b, tc.expected, tc.expError, result, err)
+        }
+        if tc.a!=a || tc.b!=b {
+            t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
+                     i, a, b, tc.a, tc.b)
+        }
+    }
+}
+
+func TestUInt128PowChecked(t *testing.T) {
+    testCases := []UInt128ShCheckedTC {
+        UInt128ShCheckedTC{ UInt128{ 3, 0 }, 80,
+            UInt128{ 0x3cea59789c79d441, 0x6f32f1ef8b18a2bc }, nil },
+        UInt128ShCheckedTC{ UInt128{ 3, 0 }, 81, UInt128{}, ErrOverflow },
+        UInt128ShCheckedTC{ UInt128{ 2, 0 }, 127, UInt128{ 0, 0x8000000000000000 }, nil },
+        UInt128ShCheckedTC{ UInt128{ 2, 0 }, 128, UInt128{}, ErrOverflow },
+        UInt128ShCheckedTC{ UInt128{ 10, 0 }, 38,
+            UInt128{ 0x098a224000000000, 0x4b3b4ca85a86c47a }, nil },
+        UInt128ShCheckedTC{ UInt128{ 10, 0 }, 39, UInt128{}, ErrOverflow },
+        UInt128ShCheckedTC{ UInt128{ 0, 0 }, 0, UInt128{ 1, 0 }, nil },
+        UInt128ShCheckedTC{ UInt128{ 0, 0 }, 5, UInt128{ 0, 0 }, nil },
+        UInt128ShCheckedTC{ UInt128{ 1, 0 }, 1000, UInt128{ 1, 0 }, nil },
+        UInt128ShCheckedTC{ UInt128{ 0xffffffffffffffff, 0xffffffffffffffff }, 1,
+            UInt128{ 0xffffffffffffffff, 0xffffffffffffffff }, nil },
+        UInt128ShCheckedTC{ UInt128{ 0xffffffffffffffff, 0xffffffffffffffff }, 2,
+            UInt128{}, ErrOverflow },
+        UInt128ShCheckedTC{ UInt128{ 0xffffffffffffffff, 0 }, 2,
+            UInt128{ 1, 0xfffffffffffffffe }, nil },
+        UInt128ShCheckedTC{ UInt128{ 0, 1 }, 2, UInt128{}, ErrOverflow },
+    }
+    for i, tc := range testCases {
+        a, b := tc.a, tc.b
+        result, err := tc.a.PowChecked(tc.b)
+        if tc.expected!=result || !errorMatch(tc.expError, err) {
+            t.Errorf("Result mismatch: %d: powchecked(%v,%v)->%v,%v!=%v,%v",
+                     i, tc.a, tc.b, tc.expected, tc.expError, result, err)
+        }
+        if tc.a!=a || tc.b!=b {
+            t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
+                     i, a, b, tc.a, tc.b)
+        }
+    }
+}
+
+func TestUInt128Pow(t *testing.T) {
+    testCases := []UInt128ShCheckedTC {
+        UInt128ShCheckedTC{ UInt128{ 3, 0 }, 80,
+            UInt128{ 0x3cea59789c79d441, 0x6f32f1ef8b18a2bc }, nil },
+        UInt128ShCheckedTC{ UInt128{ 3, 0 }, 81,
+            UInt128{ 0xb6bf0c69d56d7cc3, 0x4d98d5cea149e834 }, nil },
+        UInt128ShCheckedTC{ UInt128{ 2, 0 }, 127, UInt128{ 0, 0x8000000000000000 }, nil },
+        UInt128ShCheckedTC{ UInt128{ 2, 0 }, 128, UInt128{}, nil },
+        UInt128ShCheckedTC{ UInt128{ 10, 0 }, 39,
+            UInt128{ 0x5f65568000000000, 0xf050fe938943acc4 }, nil },
+        UInt128ShCheckedTC{ UInt128{ 7, 0 }, 100,
+            UInt128{ 0x33be1fc93d3a1a61, 0x67d4a2c6aecef689 }, nil },
+        UInt128ShCheckedTC{ UInt128{ 0, 0 }, 0, UInt128{ 1, 0 }, nil },
+        UInt128ShCheckedTC{ UInt128{ 0, 0 }, 5, UInt128{ 0, 0 }, nil },
+        UInt128ShCheckedTC{ UInt128{ 1, 0 }, 1000, UInt128{ 1, 0 }, nil },
+        UInt128ShCheckedTC{ UInt128{ 0xffffffffffffffff, 0xffffffffffffffff }, 2,
+            UInt128{ 1, 0 }, nil },
+        UInt128ShCheckedTC{ UInt128{ 0xffffffffffffffff, 0xffffffffffffffff }, 3,
+            UInt128{ 0xffffffffffffffff, 0xffffffffffffffff }, nil },
+        UInt128ShCheckedTC{ UInt128{ 0, 1 }, 2, UInt128{}, nil },
+    }
+    for i, tc := range testCases {
+        a, b := tc.a, tc.b
+        result := tc.a.Pow(tc.b)
+        if tc.expected!=result {
+            t.Errorf("Result mismatch: %d: pow(%v,%v)->%v!=%v",
+                     i, tc.a, tc.b, tc.expected, result)
+        }
+        if tc.a!=a || tc.b!=b {
+            t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
+                     i, a, b, tc.a, tc.b)
+        }
+    }
+}
+
+type UInt128LogTC struct {
+    a UInt128
+    base uint64
+    expected int
+}
+
+func TestUInt128Log(t *testing.T) {
+    testCases := []UInt128LogTC {
+        UInt128LogTC{ UInt128{ 0, 0 }, 2, -1 },
+        UInt128LogTC{ UInt128{ 0, 0 }, 4, -1 },
+        UInt128LogTC{ UInt128{ 0, 0 }, 10, -1 },
+        UInt128LogTC{ UInt128{ 1, 0 }, 2, 0 },
+        UInt128LogTC{ UInt128{ 1, 0 }, 10, 0 },
+        UInt128LogTC{ UInt128{ 3, 0 }, 4, 0 },
+        UInt128LogTC{ UInt128{ 4, 0 }, 4, 1 },
+        UInt128LogTC{ UInt128{ 9, 0 }, 10, 0 },
+        UInt128LogTC{ UInt128{ 10, 0 }, 10, 1 },
+        UInt128LogTC{ UInt128{ 12345, 0 }, 7, 4 },
+        UInt128LogTC{ UInt128{ 0xffffffffffffffff, 0 }, 2, 63 },
+        UInt128LogTC{ UInt128{ 0, 1 }, 2, 64 },
+        UInt128LogTC{ UInt128{ 0, 1 }, 16, 16 },
+        UInt128LogTC{ UInt128{ 0xffffffffffffffff, 0 }, 16, 15 },
+        UInt128LogTC{ UInt128{ 0x098a223fffffffff, 0x4b3b4ca85a86c47a }, 10, 37 },
+        UInt128LogTC{ UInt128{ 0x098a224000000000, 0x4b3b4ca85a86c47a }, 10, 38 },
+        UInt128LogTC{ MaxUInt128, 2, 127 },
+        UInt128LogTC{ MaxUInt128, 7, 45 },
+        UInt128LogTC{ MaxUInt128, 10, 38 },
+        UInt128LogTC{ MaxUInt128, 1<<63, 2 },
+        UInt128LogTC{ UInt128{ 0xffffffffffffffff, 0x3fffffffffffffff }, 1<<63, 1 },
+        UInt128LogTC{ MaxUInt128, 10000000000000000000, 2 },
+        UInt128LogTC{ MaxUInt128, 0xffffffffffffffff, 2 },
+    }
+    for i, tc := range testCases {
+        a := tc.a
+        result := tc.a.LogBase(tc.base)
+        if tc.expected!=result {
+            t.Errorf("Result mismatch: %d: logbase(%v,%v)->%v!=%v",
+                     i, tc.a, tc.base, tc.expected, result)
+        }
+        if tc.base==2 {
+            result = tc.a.Log2()
+            if tc.expected!=result {
+                t.Errorf("Result mismatch: %d: log2(%v)->%v!=%v",
+                         i, tc.a, tc.expected, result)
+            }
+        }
+        if tc.base==10 {
+            result = tc.a.Log10()
+            if tc.expected!=result {
+                t.Errorf("Result mismatch: %d: log10(%v)->%v!=%v",
+                         i, tc.a, tc.expected, result)
+            }
+        }
+        if tc.a!=a {
+            t.Errorf("Argument has been modified: %d: %v!=%v", i, a, tc.a)
+        }
+    }
+    if paniced, _ := getPanic2(func() { MaxUInt128.LogBase(1) }); !paniced {
+        t.Errorf("LogBase(1) does not panic")
+    }
+}
+
+func TestUInt128DecimalDigits(t *testing.T) {
+    values := []UInt128{ UInt128{}, UInt128{ 9, 0 }, UInt128{ 10, 0 }, MaxUInt128 }
+    for i:=0; i<len(uint128_10powers); i++ {
+        values = append(values, uint128_10powers[i], uint128_10powers[i].Sub64(1))
+    }
+    for i, v := range values {
+        expected := len(v.FormatBytes())
+        result := v.DecimalDigits()
+        if expected!=result {
+            t.Errorf("Result mismatch: %d: digits(%v)->%v!=%v", i, v, expected, result)
+        }
+    }
+}
+
+// changing exported MaxUInt128 and MinUInt128 must not change library behaviour
+func TestUInt128MaxMinModified(t *testing.T) {
+    oldMax, oldMin := MaxUInt128, MinUInt128
+    defer func() { MaxUInt128, MinUInt128 = oldMax, oldMin }()
+    MaxUInt128, MinUInt128 = UInt128{ 10, 0 }, UInt128{ 5, 0 }
+    expected := UInt128{ 0xffffffffffffffff, 0xffffffffffffffff }
+    if result := (UInt128{ 1, 0 }).SatAdd(expected); result!=expected {
+        t.Errorf("Result mismatch: satadd->%v!=%v", expected, result)
+    }
+    if result := (UInt128{ 1, 0 }).SatSub(UInt128{ 2, 0 }); result!=(UInt128{}) {
+        t.Errorf("Result mismatch: satsub->0!=%v", result)
+    }
+    result, err := ParseUInt128Base("0xffffffffffffffffffffffffffffffff", 0)
+    if result!=expected || err!=nil {
+        t.Errorf("Result mismatch: parse->%v!=%v,%v", expected, result, err)
+    }
+    var v UInt128
+    if _, err = fmt.Sscan("1234", &v); v!=(UInt128{ 1234, 0 }) || err!=nil {
+        t.Errorf("Result mismatch: scan->1234!=%v,%v", v, err)
+    }
+}
+
+func TestUInt128SatAdd(t *testing.T) {
+    testCases := []UInt128TC {
+        UInt128TC{ UInt128{ 0xffffffffffff1001, 0x2442 }, UInt128{ 0xf003, 0xa8bc },
+            UInt128{ 0x4, 0xccff } },
+        UInt128TC{ UInt128{ 0xffffffffffffffff, 0xfffffffffffffffe }, UInt128{ 1, 1 },
+            MaxUInt128 },
+        UInt128TC{ UInt128{ 0xfffffffffffffffe, 0xffffffffffffffff }, UInt128{ 1, 0 },
+            MaxUInt128 },
+        UInt128TC{ MaxUInt128, MaxUInt128, MaxUInt128 },
+    }
+    for i, tc := range testCases {
+        a, b := tc.a, tc.b
+        result := tc.a.SatAdd(tc.b)
+        if tc.expected!=result {
+            t.Errorf("Result mismatch: %d: satadd(%v,%v)->%v!=%v",
+                     i, tc.a, tc.b, tc.expected, result)
+        }
+        if tc.a!=a || tc.b!=b {
+            t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
+                     i, a, b, tc.a, tc.b)
+        }
+    }
+}
+
+func TestUInt128SatAdd64(t *testing.T) {
+    testCases := []UInt128_64TC {
+        UInt128_64TC{ UInt128{ 0xffffffffffff1001, 0x2446 }, 0xf003,
+                UInt128{ 0x4, 0x2447 } },
+        UInt128_64TC{ UInt128{ 0xffffffffffff1001, 0xffffffffffffffff }, 0xf003,
+                MaxUInt128 },
+        UInt128_64TC{ UInt128{ 0xfffffffffffffffe, 0xffffffffffffffff }, 1, MaxUInt128 },
+    }
+    for i, tc := range testCases {
+        a, b := tc.a, tc.b
+        result := tc.a.SatAdd64(tc.b)
+        if tc.expected!=result {
+            t.Errorf("Result mismatch: %d: satadd64(%v,%v)->%v!=%v",
+                     i, tc.a, tc.b, tc.expected, result)
+        }
+        if tc.a!=a || tc.b!=b {
+            t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
+                     i, a, b, tc.a, tc.b)
+        }
+    }
+}
+
+func TestUInt128SatSub(t *testing.T) {
+    testCases := []UInt128TC {
+        UInt128TC{ UInt128{ 0x4, 0xccff }, UInt128{ 0xffffffffffff1001, 0x2442 },
+            UInt128{ 0xf003, 0xa8bc } },
+        UInt128TC{ UInt128{ 81185, 4252 }, UInt128{ 81183, 4253 }, MinUInt128 },
+        UInt128TC{ UInt128{ 0, 0 }, UInt128{ 1, 0 }, MinUInt128 },
+        UInt128TC{ UInt128{ 1, 0 }, UInt128{ 1, 0 }, UInt128{ 0, 0 } },
+    }
+    for i, tc := range testCases {
+        a, b := tc.a, tc.b
+        result := tc.a.SatSub(tc.b)
+        if tc.expected!=result {
+            t.Errorf("Result mismatch: %d: satsub(%v,%v)->%v!=%v",
+                     i, tc.a, tc.b, tc.expected, result)
+        }
+        if tc.a!=a || tc.b!=b {
+            t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
+                     i, a, b, tc.a, tc.b)
+        }
+    }
+}
+
+func TestUInt128SatSub64(t *testing.T) {
+    testCases := []UInt128_64TC {
+        UInt128_64TC{ UInt128{ 0x5, 0xccff }, 0xffffffffffff2001,
+                UInt128{ 0xe004, 0xccfe } },
+        UInt128_64TC{ UInt128{ 0x5, 0 }, 6, MinUInt128 },
+        UInt128_64TC{ UInt128{ 0x5, 0 }, 5, UInt128{ 0, 0 } },
+    }
+    for i, tc := range testCases {
+        a, b := tc.a, tc.b
+        result := tc.a.SatSub64(tc.b)
+        if tc.expected!=result {
+            t.Errorf("Result mismatch: %d: satsub64(%v,%v)->%v!=%v",
+                     i, tc.a, tc.b, tc.expected, result)
+        }
+        if tc.a!=a || tc.b!=b {
+            t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
+                     i, a, b, tc.a, tc.b)
+        }
+    }
+}
+
+func TestUInt128SatMul(t *testing.T) {
+    testCases := []UInt128TC {
+        UInt128TC{ UInt128{ 0xffffffffffffffff, 0 }, UInt128{ 0xffffffffffffffff, 0 },
+            UInt128{ 1, 0xfffffffffffffffe } },
+        UInt128TC{ UInt128{ 0, 1 }, UInt128{ 0, 1 }, MaxUInt128 },
+        UInt128TC{ UInt128{ 5, 1 }, UInt128{ 0xffffffffffffffff, 0 }, MaxUInt128 },
+        UInt128TC{ MaxUInt128, UInt128{ 1, 0 }, MaxUInt128 },
+        UInt128TC{ MaxUInt128, UInt128{ 0, 0 }, UInt128{ 0, 0 } },
+        UInt128TC{ UInt128{ 0xffffffffffffffff, 0x7fffffffffffffff }, UInt128{ 2, 0 },
+            UInt128{ 0xfffffffffffffffe, 0xffffffffffffffff } },
+    }
+    for i, tc := range testCases {
+        a, b := tc.a, tc.b
+        result := tc.a.SatMul(tc.b)
+        if tc.expected!=result {
+            t.Errorf("Result mismatch: %d: satmul(%v,%v)->%v!=%v",
+                     i, tc.a, tc.b, tc.expected, result)
+        }
+        if tc.a!=a || tc.b!=b {
+            t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
+                     i, a, b, tc.a, tc.b)
+        }
+    }
+}
+
+func TestUInt128SatMul64(t *testing.T) {
+    testCases := []UInt128_64TC {
+        UInt128_64TC{ UInt128{ 0x2327f0eac961980e, 0x49f0f9 }, 0x11f82bb55bf,
+            UInt128{ 0x77f8d53cd5871872, 0x530ae9c8b7cb9049 } },
+        UInt128_64TC{ UInt128{ 0xc9baa109a40baa11, 0x384b9a928941ac3 },
+            0x1839b9af9dc021, MaxUInt128 },
+        UInt128_64TC{ UInt128{ 0xffffffffffffffff, 0x7fffffffffffffff }, 3, MaxUInt128 },
+    }
+    for i, tc := range testCases {
+        a, b := tc.a, tc.b
+        result := tc.a.SatMul64(tc.b)
+        if tc.expected!=result {
+            t.Errorf("Result mismatch: %d: satmul64(%v,%v)->%v!=%v",
+                     i, tc.a, tc.b, tc.expected, result)
+        }
+        if tc.a!=a || tc.b!=b {
+            t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
+                     i, a, b, tc.a, tc.b)
+        }
+    }
+}
+
+type UInt128BitwiseTC struct {
+    a, b UInt128
+    expAnd, expOr, expXor, expAndNot UInt128
+}
+
+func TestUInt128Bitwise(t *testing.T) {
+    testCases := []UInt128BitwiseTC {
+        UInt128BitwiseTC{ UInt128{ 0xff00ff00f0f0cccc, 0x123456789abcdef0 },
+            UInt128{ 0x0ff00ff0ff00aaaa, 0xfedcba9876543210 },
+            UInt128{ 0x0f000f00f0008888, 0x1214121812141210 },
+            UInt128{ 0xfff0fff0fff0eeee, 0xfefcfef8fefcfef0 },
+            UInt128{ 0xf0f0f0f00ff06666, 0xece8ece0ece8ece0 },
+            UInt128{ 0xf000f00000f04444, 0x0020446088a8cce0 } },
+    }
+    for i, tc := range testCases {
+        a, b := tc.a, tc.b
+        resAnd, resOr := tc.a.And(tc.b), tc.a.Or(tc.b)
+        resXor, resAndNot := tc.a.Xor(tc.b), tc.a.AndNot(tc.b)
+        if tc.expAnd!=resAnd || tc.expOr!=resOr || tc.expXor!=resXor ||
+            tc.expAndNot!=resAndNot {
+            t.Errorf("Result mismatch: %d: bitwise(%v,%v)->%v,%v,%v,%v!=%v,%v,%v,%v",
+                     i, tc.a, tc.b, tc.expAnd, tc.expOr, tc.expXor, tc.expAndNot,
+                     resAnd, resOr, resXor, resAndNot)
+        }
+        resNot := tc.a.Not()
+        if resNot[0]!=^tc.a[0] || resNot[1]!=^tc.a[1] {
+            t.Errorf("Result mismatch: %d: not(%v)->%v", i, tc.a, resNot)
+        }
+        if tc.a!=a || tc.b!=b {
+            t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
+                     i, a, b, tc.a, tc.b)
+        }
+    }
+}
+
+type UInt128BitCountTC struct {
+    a UInt128
+    expLZ, expTZ, expOnes, expLen int
+}
+
+func TestUInt128BitCounts(t *testing.T) {
+    testCases := []UInt128BitCountTC {
+        UInt128BitCountTC{ UInt128{ 0, 0 }, 128, 128, 0, 0 },
+        UInt128BitCountTC{ UInt128{ 1, 0 }, 127, 0, 1, 1 },
+        UInt128BitCountTC{ UInt128{ 0, 0x8000000000000000 }, 0, 127, 1, 128 },
+        UInt128BitCountTC{ UInt128{ 0x62b71430f1765e8f, 0xb5ed145b3920ca5a }, 0, 0, 64, 128 },
+        UInt128BitCountTC{ UInt128{ 0x30f1765e00000000, 0x14 }, 59, 33, 19, 69 },
+        UInt128BitCountTC{ UInt128{ 0, 0x140 }, 55, 70, 2, 73 },
+        UInt128BitCountTC{ MaxUInt128, 0, 0, 128, 128 },
+    }
+    for i, tc := range testCases {
+        a := tc.a
+        resLZ, resTZ := tc.a.LeadingZeros(), tc.a.TrailingZeros()
+        resOnes, resLen := tc.a.OnesCount(), tc.a.Len()
+        if tc.expLZ!=resLZ || tc.expTZ!=resTZ || tc.expOnes!=resOnes ||
+            tc.expLen!=resLen {
+            t.Errorf("Result mismatch: %d: bitcounts(%v)->%v,%v,%v,%v!=%v,%v,%v,%v",
+                     i, tc.a, tc.expLZ, tc.expTZ, tc.expOnes, tc.expLen,
+                     resLZ, resTZ, resOnes, resLen)
+        }
+        if tc.a!=a {
+            t.Errorf("Argument has been modified: %d: %v!=%v", i, a, tc.a)
+        }
+    }
+}
+
+type UInt128RotTC struct {
+    a UInt128
+    k int
+    expected UInt128
+}
+
+func TestUInt128RotateLeft(t *testing.T) {
+    testCases := []UInt128RotTC {
+        UInt128RotTC{ UInt128{ 0x62b71430f1765e8f, 0xb5ed145b3920ca5a }, 0,
+            UInt128{ 0x62b71430f1765e8f, 0xb5ed145b3920ca5a } },
+        UInt128RotTC{ UInt128{ 0x62b71430f1765e8f, 0xb5ed145b3920ca5a }, 3,
+            UInt128{ 0x15b8a1878bb2f47d, 0xaf68a2d9c90652d3 } },
+        UInt128RotTC{ UInt128{ 0x62b71430f1765e8f, 0xb5ed145b3920ca5a }, 64,
+            UInt128{ 0xb5ed145b3920ca5a, 0x62b71430f1765e8f } },
+        UInt128RotTC{ UInt128{ 0x62b71430f1765e8f, 0xb5ed145b3920ca5a }, 70,
+            UInt128{ 0x7b4516ce48329698, 0xadc50c3c5d97a3ed } },
+        UInt128RotTC{ UInt128{ 0x62b71430f1765e8f, 0xb5ed145b3920ca5a }, 127,
+            UInt128{ 0x315b8a1878bb2f47, 0xdaf68a2d9c90652d } },
+        UInt128RotTC{ UInt128{ 0x62b71430f1765e8f, 0xb5ed145b3920ca5a }, -3,
+            UInt128{ 0x4c56e2861e2ecbd1, 0xf6bda28b6724194b } },
+        UInt128RotTC{ UInt128{ 0x62b71430f1765e8f, 0xb5ed145b3920ca5a }, 200,
+            UInt128{ 0xed145b3920ca5a62, 0xb71430f1765e8fb5 } },
+    }
+    for i, tc := range testCases {
+        a, k := tc.a, tc.k
+        result := tc.a.RotateLeft(tc.k)
         if tc.expected!=result {
-            t.Errorf("Result mismatch: %d: %v>>%v->%v!=%v",
-                     i, tc.a, tc.b, tc.expected, result)
+            t.Errorf("Result mismatch: %d: rotl(%v,%v)->%v!=%v",
+                     i, tc.a, tc.k, tc.expected, result)
         }
-        if tc.a!=a || tc.b!=b {
+        if tc.a!=a || tc.k!=k {
             t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
-                     i, a, b, tc.a, tc.b)
+                     i, a, k, tc.a, tc.k)
+        }
+    }
+}
+
+func TestUInt128Reverse(t *testing.T) {
+    a := UInt128{ 0x62b71430f1765e8f, 0xb5ed145b3920ca5a }
+    result := a.Reverse()
+    expected := UInt128{ 0x5a53049cda28b7ad, 0xf17a6e8f0c28ed46 }
+    if expected!=result {
+        t.Errorf("Result mismatch: reverse(%v)->%v!=%v", a, expected, result)
+    }
+    result = a.ReverseBytes()
+    expected = UInt128{ 0x5aca20395b14edb5, 0x8f5e76f13014b762 }
+    if expected!=result {
+        t.Errorf("Result mismatch: reversebytes(%v)->%v!=%v", a, expected, result)
+    }
+}
+
+type UInt128BitTC struct {
+    a UInt128
+    i uint
+    expected uint
+    expSet, expClear UInt128
+}
+
+func TestUInt128Bit(t *testing.T) {
+    testCases := []UInt128BitTC {
+        UInt128BitTC{ UInt128{ 0x62b71430f1765e8f, 0xb5ed145b3920ca5a }, 0, 1,
+            UInt128{ 0x62b71430f1765e8f, 0xb5ed145b3920ca5a },
+            UInt128{ 0x62b71430f1765e8e, 0xb5ed145b3920ca5a } },
+        UInt128BitTC{ UInt128{ 0x62b71430f1765e8f, 0xb5ed145b3920ca5a }, 4, 0,
+            UInt128{ 0x62b71430f1765e9f, 0xb5ed145b3920ca5a },
+            UInt128{ 0x62b71430f1765e8f, 0xb5ed145b3920ca5a } },
+        UInt128BitTC{ UInt128{ 0x62b71430f1765e8f, 0xb5ed145b3920ca5a }, 64, 0,
+            UInt128{ 0x62b71430f1765e8f, 0xb5ed145b3920ca5b },
+            UInt128{ 0x62b71430f1765e8f, 0xb5ed145b3920ca5a } },
+        UInt128BitTC{ UInt128{ 0x62b71430f1765e8f, 0xb5ed145b3920ca5a }, 127, 1,
+            UInt128{ 0x62b71430f1765e8f, 0xb5ed145b3920ca5a },
+            UInt128{ 0x62b71430f1765e8f, 0x35ed145b3920ca5a } },
+        UInt128BitTC{ UInt128{ 0x62b71430f1765e8f, 0xb5ed145b3920ca5a }, 128, 0,
+            UInt128{ 0x62b71430f1765e8f, 0xb5ed145b3920ca5a },
+            UInt128{ 0x62b71430f1765e8f, 0xb5ed145b3920ca5a } },
+    }
+    for i, tc := range testCases {
+        a := tc.a
+        result := tc.a.Bit(tc.i)
+        resSet, resClear := tc.a.SetBit(tc.i, 1), tc.a.SetBit(tc.i, 0)
+        if tc.expected!=result || tc.expSet!=resSet || tc.expClear!=resClear {
+            t.Errorf("Result mismatch: %d: bit(%v,%v)->%v,%v,%v!=%v,%v,%v",
+                     i, tc.a, tc.i, tc.expected, tc.expSet, tc.expClear,
+                     result, resSet, resClear)
+        }
+        if tc.a!=a {
+            t.Errorf("Argument has been modified: %d: %v!=%v", i, a, tc.a)
         }
     }
 }
@@ -472,6 +1104,17 @@ func TestUInt128DivFull(t *testing.T) {
             UInt128{ 0xcc8a934a9b390141, 0xd8a91058bc8f94ae }, UInt128{}, // a
             UInt128{ 0xcc8a934a9b390144, 0xd8a91058bc8f94ae }, // b
             UInt128{}, UInt128{ 0xcc8a934a9b390141, 0xd8a91058bc8f94ae } }, // quo,rem
+        // divisor with highest bit set (partial remainder exceeds 128 bits)
+        UInt128DivFTC{ UInt128{ 0xa42cdd81bfa5bdec, 0x59e6b8b59bb9c5d8 }, // alo
+            UInt128{ 0xe982f0554f9, 0 }, // ahi
+            UInt128{ 0xcae796e93ed20fbd, 0xf2e6dcb652f28edf }, // b
+            UInt128{ 0xf61a72a9900, 0 }, // quo
+            UInt128{ 0x3438169e543bc8ec, 0x95b0aa73c2a9cf14 } }, // rem
+        UInt128DivFTC{ UInt128{ 0xffffffffffffffff, 0xffffffffffffffff }, // alo
+            UInt128{ 0xffffffffffffff60, 0xffffffffffffffff }, // ahi
+            UInt128{ 0xffffffffffffff61, 0xffffffffffffffff }, // b
+            UInt128{ 0xffffffffffffffff, 0xffffffffffffffff }, // quo
+            UInt128{ 0xffffffffffffff60, 0xffffffffffffffff } }, // rem
     }
     for i, tc := range testCases {
         alo, ahi, b := tc.alo, tc.ahi, tc.b
@@ -509,6 +1152,134 @@ func TestUInt128DivFull(t *testing.T) {
     }
 }
 
+// regression test: compare with big.Int for divisors with highest bit set
+// (older versions lost highest bit of partial remainder and returned wrong results)
+func TestUInt128DivFullBig(t *testing.T) {
+    toBig := func(a UInt128) *big.Int {
+        v := new(big.Int).Lsh(new(big.Int).SetUint64(a[1]), 64)
+        return v.Or(v, new(big.Int).SetUint64(a[0]))
+    }
+    rnd := rand.New(rand.NewSource(17))
+    for i:=0; i<5000; i++ {
+        b := UInt128{ rnd.Uint64(), rnd.Uint64() | 0x8000000000000000 }
+        if i&1!=0 {
+            // divisor close to 2^128
+            b[1] = 0xffffffffffffffff
+        }
+        hi := UInt128{ rnd.Uint64(), rnd.Uint64() }
+        if hi.Cmp(b)>=0 {
+            hi = hi.Sub(b)
+        }
+        lo := UInt128{ rnd.Uint64(), rnd.Uint64() }
+        a := new(big.Int).Lsh(toBig(hi), 128)
+        a.Or(a, toBig(lo))
+        expected, expRem := new(big.Int).QuoRem(a, toBig(b), new(big.Int))
+        result, rem := UInt128DivFull(hi, lo, b)
+        if expected.Cmp(toBig(result))!=0 || expRem.Cmp(toBig(rem))!=0 {
+            t.Errorf("Result mismatch: %d: (%v,%v)/%v->%v,%v!=%v,%v",
+                     i, lo, hi, b, expected, expRem, result, rem)
+        }
+    }
+}
+
+type UInt128DivMTC struct {
+    a, b UInt128
+    expected, expRem UInt128
+}
+
+func TestUInt128DivMod(t *testing.T) {
+    testCases := []UInt128DivMTC {
+        UInt128DivMTC{ UInt128{ 0xa168b431ea4cbf25, 0xeeaf8afeafe15bf3 },
+            UInt128{ 0x64611073ad67885c, 0x159b7addc721d10f },
+            UInt128{ 0xb, 0 }, UInt128{ 0x513cff3976d9e331, 0x1014377216d604a } },
+        UInt128DivMTC{ UInt128{ 0xffffffffffffffff, 0xffffffffffffffff }, UInt128{ 1, 0 },
+            UInt128{ 0xffffffffffffffff, 0xffffffffffffffff }, UInt128{ 0, 0 } },
+        UInt128DivMTC{ UInt128{ 0xffffffffffffffff, 0xffffffffffffffff },
+            UInt128{ 0xffffffffffffffff, 0xffffffffffffffff },
+            UInt128{ 1, 0 }, UInt128{ 0, 0 } },
+        UInt128DivMTC{ UInt128{ 0xffffffffffffffff, 0xffffffffffffffff },
+            UInt128{ 0xfffffffffffffffe, 0xffffffffffffffff },
+            UInt128{ 1, 0 }, UInt128{ 1, 0 } },
+        UInt128DivMTC{ UInt128{ 0xfffffffffffffffe, 0xffffffffffffffff },
+            UInt128{ 0xffffffffffffffff, 0xffffffffffffffff },
+            UInt128{ 0, 0 }, UInt128{ 0xfffffffffffffffe, 0xffffffffffffffff } },
+        UInt128DivMTC{ UInt128{ 0x1234, 0 }, UInt128{ 0x99999, 0 },
+            UInt128{ 0, 0 }, UInt128{ 0x1234, 0 } },
+        UInt128DivMTC{ UInt128{ 0xffffffffffffffff, 0xffffffffffffffff },
+            UInt128{ 0xffffffffffffffff, 0 }, UInt128{ 1, 1 }, UInt128{ 0, 0 } },
+        UInt128DivMTC{ UInt128{ 0xffffffffffffffff, 0xffffffffffffffff }, UInt128{ 3, 0 },
+            UInt128{ 0x5555555555555555, 0x5555555555555555 }, UInt128{ 0, 0 } },
+        UInt128DivMTC{ UInt128{ 0x0d362b7e0421d339, 0xbb09d477baa0 },
+            UInt128{ 0x6afcb5c6af1e507b, 0 },
+            UInt128{ 492083670228144, 0 }, UInt128{ 0x13f254e3d9ce0aa9, 0 } },
+        UInt128DivMTC{ UInt128{ 0xffffffffffffffff, 0xffffffffffffffff }, UInt128{ 0, 1 },
+            UInt128{ 0xffffffffffffffff, 0 }, UInt128{ 0xffffffffffffffff, 0 } },
+        UInt128DivMTC{ UInt128{ 0xffffffffffffffff, 0xffffffffffffffff }, UInt128{ 1, 1 },
+            UInt128{ 0xffffffffffffffff, 0 }, UInt128{ 0, 0 } },
+        UInt128DivMTC{ UInt128{ 0xffffffffffffffff, 0x8000000000000000 },
+            UInt128{ 0x7fffffffffffffff, 0x4000000000000000 },
+            UInt128{ 2, 0 }, UInt128{ 1, 0 } },
+        UInt128DivMTC{ UInt128{ 0xf2a74de452e6b438, 0x6513270e269e0d37 },
+            UInt128{ 0x0c5c7fd0a6a3a450, 0x4 },
+            UInt128{ 0x18f7a181b53bc0fe, 0 }, UInt128{ 0x681fc8b0d2ddacd8, 0x2 } },
+        UInt128DivMTC{ UInt128{ 0x892f902bd23f0824, 0x5d9dc9f81818e811 },
+            UInt128{ 0x0ed904759531985d, 0x8e8e25d94 },
+            UInt128{ 0xa81da37, 0 }, UInt128{ 0xb3af5beb1ffb1a29, 0x45b96c315 } },
+        UInt128DivMTC{ UInt128{ 0x099950d836f675cc, 0x6f03675a1600a35a },
+            UInt128{ 0x11e20b8f6b0d549b, 0xb9c7bec3d9c1724 },
+            UInt128{ 9, 0 }, UInt128{ 0x68a6e8cd737e7c59, 0x6830c0beb83d315 } },
+    }
+    for i, tc := range testCases {
+        a, b := tc.a, tc.b
+        result, rem := tc.a.DivMod(tc.b)
+        if tc.expected!=result || tc.expRem!=rem {
+            t.Errorf("Result mismatch: %d: divmod(%v,%v)->%v,%v!=%v,%v",
+                     i, tc.a, tc.b, tc.expected, tc.expRem, result, rem)
+        }
+        result = tc.a.Div(tc.b)
+        rem = tc.a.Mod(tc.b)
+        if tc.expected!=result || tc.expRem!=rem {
+            t.Errorf("Result mismatch: %d: div,mod(%v,%v)->%v,%v!=%v,%v",
+                     i, tc.a, tc.b, tc.expected, tc.expRem, result, rem)
+        }
+        result = tc.a.Quo(tc.b)
+        rem = tc.a.Rem(tc.b)
+        if tc.expected!=result || tc.expRem!=rem {
+            t.Errorf("Result mismatch: %d: quo,rem(%v,%v)->%v,%v!=%v,%v",
+                     i, tc.a, tc.b, tc.expected, tc.expRem, result, rem)
+        }
+        var err error
+        result, rem, err = tc.a.DivModChecked(tc.b)
+        if tc.expected!=result || tc.expRem!=rem || err!=nil {
+            t.Errorf("Result mismatch: %d: divmodchecked(%v,%v)->%v,%v!=%v,%v,%v",
+                     i, tc.a, tc.b, tc.expected, tc.expRem, result, rem, err)
+        }
+        if tc.a!=a || tc.b!=b {
+            t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
+                     i, a, b, tc.a, tc.b)
+        }
+    }
+    
+    paniced, panicStr := getPanic2(func() {
+        UInt128{ 0x54cd834632566de9, 0x213a9ec7545 }.DivMod(UInt128{})
+    })
+    if !paniced || panicStr!="Divide by zero" {
+        t.Errorf("Unexpected panic: %v,%v", paniced, panicStr)
+    }
+    _, _, err := UInt128{ 0x54cd834632566de9, 0x213a9ec7545 }.DivModChecked(UInt128{})
+    if err!=ErrDivideByZero {
+        t.Errorf("Unexpected error: %v", err)
+    }
+}
+
+func BenchmarkUInt128DivMod(b *testing.B) {
+    a := UInt128{ 0x892f902bd23f0824, 0x5d9dc9f81818e811 }
+    d := UInt128{ 0x0ed904759531985d, 0x8e8e25d94 }
+    for i := 0; i < b.N; i++ {
+        a.DivMod(d)
+    }
+}
+
 type UInt128FmtTC struct {
     a UInt128
     expected string
@@ -549,7 +1320,7 @@ func TestUInt128Format(t *testing.T) {
     }
     for i, tc := range testCases {
         a := tc.a
-        result := tc.a.Format()
+        result := tc.a.String()
         if tc.expected!=result {
             t.Errorf("Result mismatch: %d: fmt(%v)->%v!=%v",
                      i, tc.a, tc.expected, result)
@@ -562,6 +1333,10 @@ func TestUInt128Format(t *testing.T) {
             t.Errorf("Result mismatch: %d: fmtBytes(%v)->%v!=%v",
                      i, tc.a, tc.expected, result)
         }
+        if result = tc.a.FormatString(); tc.expected!=result {
+            t.Errorf("Result mismatch: %d: fmtString(%v)->%v!=%v",
+                     i, tc.a, tc.expected, result)
+        }
         if tc.a!=a {
             t.Errorf("Argument has been modified: %d: %v!=%v", i, a, tc.a)
         }
@@ -571,14 +1346,420 @@ func TestUInt128Format(t *testing.T) {
 func BenchmarkUInt128Format64(b *testing.B) {
     a := UInt128{ 834899285198348317, 0 }
     for i := 0; i < b.N; i++ {
-        a.Format()
+        a.FormatBytes()
     }
 }
 
 func BenchmarkUInt128Format128(b *testing.B) {
     a := UInt128{ 0x1c9e66c000000000, 0xe1b1e5f90f944d6e }
     for i := 0; i < b.N; i++ {
-        a.Format()
+        a.FormatBytes()
+    }
+}
+
+// old decimal formatting (digit by digit by subtracting powers of 10)
+// used as reference for tests and benchmarks
+func formatBytesReference(a UInt128) []byte {
+    if a[0]==0 && a[1]==0 { return []byte{ '0' } }
+    var borrow uint64
+    var chars [41]byte
+    i := sort.Search(len(uint128_10powers), func(ii int) bool {
+        _, borrow = Sub64(a[0], uint128_10powers[ii][0], 0)
+        _, borrow = Sub64(a[1], uint128_10powers[ii][1], borrow)
+        return borrow!=0 // a<uint128_10powers[ii]
+    })-1
+    end := i
+    if i<19 {
+        var tmpa, tmp, x, x1 uint64
+        tmp = a[0]
+        for ; i>=0; i-- {
+            // calculate digit
+            x = uint128_10powers[i][0]
+            var digit byte = '0'
+            {
+                x1 = x<<3
+                // check if 3 bit of digit - 8
+                tmpa, borrow = Sub64(tmp, x1, 0)
+                if borrow==0 {
+                    digit += 8
+                    tmp = tmpa
+                }
+                x1 = x<<2
+                // check if 2 bit of digit - 4
+                tmpa, borrow = Sub64(tmp, x1, 0)
+                if borrow==0 {
+                    digit += 4
+                    tmp = tmpa
+                }
+            }
+            // check if 1 bit of digit - 2
+            x1 = x<<1
+            tmpa, borrow = Sub64(tmp, x1, 0)
+            if borrow==0 {
+                digit += 2
+                tmp = tmpa
+            }
+            // check if 0 bit of digit - 1
+            tmpa, borrow = Sub64(tmp, x, 0)
+            if borrow==0 {
+                digit++
+                tmp = tmpa
+            }
+            chars[40-i] = digit
+        }
+    } else {
+        var tmpa, tmp, x, x1 UInt128
+        var borrow uint64
+        tmp = a
+        for ; i>=19; i-- {
+            // calculate digit
+            x = uint128_10powers[i]
+            var digit byte = '0'
+            if i<38 {
+                x1[1] = (x[1]<<3) | (x[0]>>61)
+                x1[0] = x[0]<<3
+                // check if 3 bit of digit - 8
+                tmpa[0], borrow = Sub64(tmp[0], x1[0], 0)
+                tmpa[1], borrow = Sub64(tmp[1], x1[1], borrow)
+                if borrow==0 {
+                    digit += 8
+                    tmp = tmpa
+                }
+                x1[1] = (x[1]<<2) | (x[0]>>62)
+                x1[0] = x[0]<<2
+                // check if 2 bit of digit - 4
+                tmpa[0], borrow = Sub64(tmp[0], x1[0], 0)
+                tmpa[1], borrow = Sub64(tmp[1], x1[1], borrow)
+                if borrow==0 {
+                    digit += 4
+                    tmp = tmpa
+                }
+            }
+            // check if 1 bit of digit - 2
+            x1[1] = (x[1]<<1) | (x[0]>>63)
+            x1[0] = x[0]<<1
+            tmpa[0], borrow = Sub64(tmp[0], x1[0], 0)
+            tmpa[1], borrow = Sub64(tmp[1], x1[1], borrow)
+            if borrow==0 {
+                digit += 2
+                tmp = tmpa
+            }
+            // check if 0 bit of digit - 1
+            tmpa[0], borrow = Sub64(tmp[0], x[0], 0)
+            tmpa[1], borrow = Sub64(tmp[1], x[1], borrow)
+            if borrow==0 {
+                digit++
+                tmp = tmpa
+            }
+            chars[40-i] = digit
+        }
+        for ; i>=0; i-- {
+            // calculate digit
+            x[0] = uint128_10powers[i][0]
+            var digit byte = '0'
+            {
+                x1[0] = x[0]<<3
+                // check if 3 bit of digit - 8
+                tmpa[0], borrow = Sub64(tmp[0], x1[0], 0)
+                if borrow==0 {
+                    digit += 8
+                    tmp[0] = tmpa[0]
+                }
+                x1[0] = x[0]<<2
+                // check if 2 bit of digit - 4
+                tmpa[0], borrow = Sub64(tmp[0], x1[0], 0)
+                if borrow==0 {
+                    digit += 4
+                    tmp[0] = tmpa[0]
+                }
+            }
+            // check if 1 bit of digit - 2
+            x1[0] = x[0]<<1
+            tmpa[0], borrow = Sub64(tmp[0], x1[0], 0)
+            if borrow==0 {
+                digit += 2
+                tmp[0] = tmpa[0]
+            }
+            // check if 0 bit of digit - 1
+            tmpa[0], borrow = Sub64(tmp[0], x[0], 0)
+            if borrow==0 {
+                digit++
+                tmp[0] = tmpa[0]
+            }
+            chars[40-i] = digit
+        }
+    }
+    return chars[40-end:]
+}
+
+func BenchmarkUInt128FormatReference64(b *testing.B) {
+    a := UInt128{ 834899285198348317, 0 }
+    for i := 0; i < b.N; i++ {
+        formatBytesReference(a)
+    }
+}
+
+func BenchmarkUInt128FormatReference128(b *testing.B) {
+    a := UInt128{ 0x1c9e66c000000000, 0xe1b1e5f90f944d6e }
+    for i := 0; i < b.N; i++ {
+        formatBytesReference(a)
+    }
+}
+
+// values at boundaries of 10^19 chunks
+func TestUInt128FormatChunks(t *testing.T) {
+    testCases := []UInt128FmtTC {
+        UInt128FmtTC{ UInt128{ 0x8ac7230489e7ffff, 0x0 },
+            "9999999999999999999" },
+        UInt128FmtTC{ UInt128{ 0x8ac7230489e80000, 0x0 },
+            "10000000000000000000" },
+        UInt128FmtTC{ UInt128{ 0x8ac7230489e80001, 0x0 },
+            "10000000000000000001" },
+        UInt128FmtTC{ UInt128{ 0x158e460913cfffff, 0x1 },
+            "19999999999999999999" },
+        UInt128FmtTC{ UInt128{ 0xb5e3af16b1880007, 0x2 },
+            "50000000000000000007" },
+        UInt128FmtTC{ UInt128{ 0xffffffffffffffff, 0x0 },
+            "18446744073709551615" },
+        UInt128FmtTC{ UInt128{ 0x0, 0x1 },
+            "18446744073709551616" },
+        UInt128FmtTC{ UInt128{ 0x6bc75e2d630fffff, 0x5 },
+            "99999999999999999999" },
+        UInt128FmtTC{ UInt128{ 0x6bc75e2d63100000, 0x5 },
+            "100000000000000000000" },
+        UInt128FmtTC{ UInt128{ 0x7ec2ff3b76180000, 0x4b3b4ca85a86c479 },
+            "99999999999999999990000000000000000000" },
+        UInt128FmtTC{ UInt128{ 0x98a223fffffffff, 0x4b3b4ca85a86c47a },
+            "99999999999999999999999999999999999999" },
+        UInt128FmtTC{ UInt128{ 0x98a224000000000, 0x4b3b4ca85a86c47a },
+            "100000000000000000000000000000000000000" },
+        UInt128FmtTC{ UInt128{ 0x98a224000000001, 0x4b3b4ca85a86c47a },
+            "100000000000000000000000000000000000001" },
+        UInt128FmtTC{ UInt128{ 0x9451454489e80000, 0x4b3b4ca85a86c47a },
+            "100000000000000000010000000000000000000" },
+        UInt128FmtTC{ UInt128{ 0x9451454489e7ffff, 0x4b3b4ca85a86c47a },
+            "100000000000000000009999999999999999999" },
+        UInt128FmtTC{ UInt128{ 0x1c9e66c000003039, 0xe1b1e5f90f944d6e },
+            "300000000000000000000000000000000012345" },
+        UInt128FmtTC{ UInt128{ 0xffffffffffffffff, 0xffffffffffffffff },
+            "340282366920938463463374607431768211455" },
+    }
+    for i, tc := range testCases {
+        result := string(tc.a.FormatBytes())
+        if tc.expected!=result {
+            t.Errorf("Result mismatch: %d: fmt(%v)->%v!=%v", i, tc.a, tc.expected, result)
+        }
+    }
+}
+
+// compare decimal formatting (and reference formatting) with big.Int
+func TestUInt128FormatBig(t *testing.T) {
+    values := []UInt128 { UInt128{ 0, 0 }, MaxUInt128 }
+    p := UInt128{ 1, 0 }
+    for i:=1; i<=38; i++ {
+        p = p.Mul64(10)
+        values = append(values, p, p.Sub64(1), p.Add64(1))
+    }
+    rnd := rand.New(rand.NewSource(1234))
+    for i:=0; i<2000; i++ {
+        v := UInt128{ rnd.Uint64(), rnd.Uint64() }
+        values = append(values, v, v.Shr(uint(rnd.Intn(128))))
+    }
+    var bv, lo big.Int
+    for i, v := range values {
+        bv.Lsh(bv.SetUint64(v[1]), 64)
+        bv.Or(&bv, lo.SetUint64(v[0]))
+        expected := bv.Text(10)
+        result := string(v.FormatBytes())
+        if expected!=result {
+            t.Errorf("Result mismatch: %d: fmt(%v)->%v!=%v", i, v, expected, result)
+        }
+        if result = string(formatBytesReference(v)); expected!=result {
+            t.Errorf("Result mismatch: %d: fmtRef(%v)->%v!=%v", i, v, expected, result)
+        }
+    }
+}
+
+type UInt128AppendTC struct {
+    a UInt128
+    expected string
+    expJSON string
+}
+
+func TestUInt128AppendFormat(t *testing.T) {
+    testCases := []UInt128AppendTC {
+        UInt128AppendTC{ UInt128{ 0, 0 }, "0", "0" },
+        UInt128AppendTC{ UInt128{ 130994, 0 }, "130994", "130994" },
+        UInt128AppendTC{ UInt128{ 0xffffffffffffffff, 0 },
+            "18446744073709551615", "18446744073709551615" },
+        UInt128AppendTC{ UInt128{ 0, 1 },
+            "18446744073709551616", "\"18446744073709551616\"" },
+        UInt128AppendTC{ UInt128{ 0x1c9e66c000000000, 0xe1b1e5f90f944d6e },
+            "300000000000000000000000000000000000000",
+            "\"300000000000000000000000000000000000000\"" },
+    }
+    for i, tc := range testCases {
+        a := tc.a
+        result := string(tc.a.AppendFormat([]byte("x=")))
+        if "x="+tc.expected!=result {
+            t.Errorf("Result mismatch: %d: append(%v)->%v!=%v",
+                     i, tc.a, "x="+tc.expected, result)
+        }
+        result = string(tc.a.AppendJSON([]byte("x=")))
+        if "x="+tc.expJSON!=result {
+            t.Errorf("Result mismatch: %d: appendJSON(%v)->%v!=%v",
+                     i, tc.a, "x="+tc.expJSON, result)
+        }
+        if tc.a!=a {
+            t.Errorf("Argument has been modified: %d: %v!=%v", i, a, tc.a)
+        }
+    }
+}
+
+func TestUInt128AppendFormatAllocs(t *testing.T) {
+    a := UInt128{ 0x1c9e66c000000000, 0xe1b1e5f90f944d6e }
+    buf := make([]byte, 0, 64)
+    allocs := testing.AllocsPerRun(100, func() {
+        buf = a.AppendFormat(buf[:0])
+        buf = a.AppendJSON(buf[:0])
+    })
+    if allocs!=0 {
+        t.Errorf("AppendFormat and AppendJSON allocate: %v", allocs)
+    }
+}
+
+func BenchmarkUInt128AppendFormat128(b *testing.B) {
+    a := UInt128{ 0x1c9e66c000000000, 0xe1b1e5f90f944d6e }
+    buf := make([]byte, 0, 64)
+    b.ReportAllocs()
+    for i := 0; i < b.N; i++ {
+        buf = a.AppendFormat(buf[:0])
+    }
+}
+
+func BenchmarkUInt128AppendJSON128(b *testing.B) {
+    a := UInt128{ 0x1c9e66c000000000, 0xe1b1e5f90f944d6e }
+    buf := make([]byte, 0, 64)
+    b.ReportAllocs()
+    for i := 0; i < b.N; i++ {
+        buf = a.AppendJSON(buf[:0])
+    }
+}
+
+type UInt128FmtBaseTC struct {
+    a UInt128
+    base int
+    expected string
+}
+
+func TestUInt128FormatBase(t *testing.T) {
+    testCases := []UInt128FmtBaseTC {
+        UInt128FmtBaseTC{ UInt128{ 0x0, 0x0 }, 2,
+            "0" },
+        UInt128FmtBaseTC{ UInt128{ 0x0, 0x0 }, 10,
+            "0" },
+        UInt128FmtBaseTC{ UInt128{ 0x0, 0x0 }, 36,
+            "0" },
+        UInt128FmtBaseTC{ UInt128{ 0x1, 0x0 }, 16,
+            "1" },
+        UInt128FmtBaseTC{ UInt128{ 0x5f75348b0131b3af, 0xb3af0f }, 2,
+            "1011001110101111000011110101111101110101001101001000101100000001001100011011001110101111" },
+        UInt128FmtBaseTC{ UInt128{ 0x5f75348b0131b3af, 0xb3af0f }, 3,
+            "10201212020220101121110012011220210221120110222111002210" },
+        UInt128FmtBaseTC{ UInt128{ 0x5f75348b0131b3af, 0xb3af0f }, 7,
+            "12431454132005342546110160220252" },
+        UInt128FmtBaseTC{ UInt128{ 0x5f75348b0131b3af, 0xb3af0f }, 8,
+            "131657036575651510540114331657" },
+        UInt128FmtBaseTC{ UInt128{ 0x5f75348b0131b3af, 0xb3af0f }, 10,
+            "217224419425143693331510191" },
+        UInt128FmtBaseTC{ UInt128{ 0x5f75348b0131b3af, 0xb3af0f }, 16,
+            "b3af0f5f75348b0131b3af" },
+        UInt128FmtBaseTC{ UInt128{ 0x5f75348b0131b3af, 0xb3af0f }, 32,
+            "5jls7lut9khc0j3ctf" },
+        UInt128FmtBaseTC{ UInt128{ 0x5f75348b0131b3af, 0xb3af0f }, 36,
+            "ral53pg0j1nl402b3" },
+        UInt128FmtBaseTC{ UInt128{ 0xffffffffffffffff, 0xffffffffffffffff }, 3,
+            "202201102121002021012000211012011021221022212021111001022110211020010021100121010" },
+        UInt128FmtBaseTC{ UInt128{ 0xffffffffffffffff, 0xffffffffffffffff }, 8,
+            "3777777777777777777777777777777777777777777" },
+        UInt128FmtBaseTC{ UInt128{ 0xffffffffffffffff, 0xffffffffffffffff }, 10,
+            "340282366920938463463374607431768211455" },
+        UInt128FmtBaseTC{ UInt128{ 0xffffffffffffffff, 0xffffffffffffffff }, 16,
+            "ffffffffffffffffffffffffffffffff" },
+        UInt128FmtBaseTC{ UInt128{ 0xffffffffffffffff, 0xffffffffffffffff }, 36,
+            "f5lxx1zz5pnorynqglhzmsp33" },
+        UInt128FmtBaseTC{ UInt128{ 0x0, 0x1 }, 10,
+            "18446744073709551616" },
+        UInt128FmtBaseTC{ UInt128{ 0x0, 0x1 }, 16,
+            "10000000000000000" },
+        UInt128FmtBaseTC{ UInt128{ 0x0, 0x1 }, 36,
+            "3w5e11264sgsg" },
+        UInt128FmtBaseTC{ UInt128{ 0xffffffffffffffff, 0x0 }, 7,
+            "45012021522523134134601" },
+        UInt128FmtBaseTC{ UInt128{ 0xffffffffffffffff, 0x0 }, 36,
+            "3w5e11264sgsf" },
+    }
+    for i, tc := range testCases {
+        a := tc.a
+        result := tc.a.FormatBase(tc.base)
+        if tc.expected!=result {
+            t.Errorf("Result mismatch: %d: fmtBase(%v,%v)->%v!=%v",
+                     i, tc.a, tc.base, tc.expected, result)
+        }
+        resultBytes := tc.a.AppendBase([]byte("xx"), tc.base)
+        if "xx"+tc.expected!=string(resultBytes) {
+            t.Errorf("Result mismatch: %d: appendBase(%v,%v)->%v!=%v",
+                     i, tc.a, tc.base, tc.expected, string(resultBytes))
+        }
+        if tc.a!=a {
+            t.Errorf("Argument has been modified: %d: %v!=%v", i, a, tc.a)
+        }
+    }
+    paniced, panicStr := getPanic2(func() {
+        UInt128{ 1, 0 }.FormatBase(37)
+    })
+    if !paniced || panicStr!="Illegal base" {
+        t.Errorf("Unexpected panic: %v,%v", paniced, panicStr)
+    }
+}
+
+type UInt128FmtBaseWidthTC struct {
+    a UInt128
+    base int
+    upper bool
+    width int
+    expected string
+}
+
+func TestUInt128FormatBaseWidth(t *testing.T) {
+    testCases := []UInt128FmtBaseWidthTC {
+        UInt128FmtBaseWidthTC{ UInt128{ 0x5f75348b0131b3af, 0xb3af0f }, 16, true, 0,
+            "B3AF0F5F75348B0131B3AF" },
+        UInt128FmtBaseWidthTC{ UInt128{ 0x5f75348b0131b3af, 0xb3af0f }, 16, false, 32,
+            "0000000000b3af0f5f75348b0131b3af" },
+        UInt128FmtBaseWidthTC{ UInt128{ 0x5f75348b0131b3af, 0xb3af0f }, 16, true, 10,
+            "B3AF0F5F75348B0131B3AF" },
+        UInt128FmtBaseWidthTC{ UInt128{ 0, 0 }, 2, false, 8, "00000000" },
+        UInt128FmtBaseWidthTC{ UInt128{ 0x1e, 0 }, 36, true, 4, "000U" },
+        UInt128FmtBaseWidthTC{ UInt128{ 0x1e, 0 }, 10, true, 4, "0030" },
+    }
+    for i, tc := range testCases {
+        a := tc.a
+        result := tc.a.FormatBaseWidth(tc.base, tc.upper, tc.width)
+        if tc.expected!=result {
+            t.Errorf("Result mismatch: %d: fmtBaseWidth(%v,%v,%v,%v)->%v!=%v",
+                     i, tc.a, tc.base, tc.upper, tc.width, tc.expected, result)
+        }
+        if tc.a!=a {
+            t.Errorf("Argument has been modified: %d: %v!=%v", i, a, tc.a)
+        }
+    }
+}
+
+func BenchmarkUInt128FormatBase16(b *testing.B) {
+    a := UInt128{ 0x1c9e66c000000000, 0xe1b1e5f90f944d6e }
+    for i := 0; i < b.N; i++ {
+        a.FormatBase(16)
     }
 }
 
@@ -629,18 +1810,141 @@ func TestUInt128Parse(t *testing.T) {
     }
     for i, tc := range testCases {
         result, err := ParseUInt128(tc.str)
-        if tc.expected!=result || tc.expError!=err {
+        if tc.expected!=result || !errorMatch(tc.expError, err) {
             t.Errorf("Result mismatch: %d: parse(%v)->%v,%v!=%v,%v",
                      i, tc.str, tc.expected, tc.expError, result, err)
         }
         result, err = ParseUInt128Bytes([]byte(tc.str))
-        if tc.expected!=result || tc.expError!=err {
+        if tc.expected!=result || !errorMatch(tc.expError, err) {
             t.Errorf("Result mismatch: %d: parseBytes(%v)->%v,%v!=%v,%v",
                      i, tc.str, tc.expected, tc.expError, result, err)
         }
     }
 }
 
+type UInt128ParseBaseTC struct {
+    str string
+    base int
+    expected UInt128
+    expError error
+}
+
+func TestUInt128ParseBase(t *testing.T) {
+    testCases := []UInt128ParseBaseTC {
+        UInt128ParseBaseTC{ "b3af0f5f75348b0131b3af", 16,
+            UInt128{ 0x5f75348b0131b3af, 0xb3af0f }, nil },
+        UInt128ParseBaseTC{ "B3AF0F5F75348B0131B3AF", 16,
+            UInt128{ 0x5f75348b0131b3af, 0xb3af0f }, nil },
+        UInt128ParseBaseTC{ "0xB3AF0F5F75348B0131B3AF", 0,
+            UInt128{ 0x5f75348b0131b3af, 0xb3af0f }, nil },
+        UInt128ParseBaseTC{ "0XB3AF0F_5F75348B_0131B3AF", 0,
+            UInt128{ 0x5f75348b0131b3af, 0xb3af0f }, nil },
+        UInt128ParseBaseTC{ "0x_b3af0f", 0, UInt128{ 0xb3af0f, 0 }, nil },
+        UInt128ParseBaseTC{ "217224419425143693331510191", 10,
+            UInt128{ 0x5f75348b0131b3af, 0xb3af0f }, nil },
+        UInt128ParseBaseTC{ "217_224_419_425_143_693_331_510_191", 0,
+            UInt128{ 0x5f75348b0131b3af, 0xb3af0f }, nil },
+        UInt128ParseBaseTC{ "ral53pg0j1nl402b3", 36,
+            UInt128{ 0x5f75348b0131b3af, 0xb3af0f }, nil },
+        UInt128ParseBaseTC{ "0b1011", 0, UInt128{ 11, 0 }, nil },
+        UInt128ParseBaseTC{ "0B1_011", 0, UInt128{ 11, 0 }, nil },
+        UInt128ParseBaseTC{ "1011", 2, UInt128{ 11, 0 }, nil },
+        UInt128ParseBaseTC{ "0o777", 0, UInt128{ 511, 0 }, nil },
+        UInt128ParseBaseTC{ "0777", 0, UInt128{ 511, 0 }, nil },
+        UInt128ParseBaseTC{ "0_777", 0, UInt128{ 511, 0 }, nil },
+        UInt128ParseBaseTC{ "0", 0, UInt128{ 0, 0 }, nil },
+        UInt128ParseBaseTC{ "zz", 36, UInt128{ 1295, 0 }, nil },
+        UInt128ParseBaseTC{ "ffffffffffffffffffffffffffffffff", 16,
+            UInt128{ 0xffffffffffffffff, 0xffffffffffffffff }, nil },
+        UInt128ParseBaseTC{ "f5lxx1zz5pnorynqglhzmsp33", 36,
+            UInt128{ 0xffffffffffffffff, 0xffffffffffffffff }, nil },
+        UInt128ParseBaseTC{ "100000000000000000000000000000000", 16,
+            UInt128{}, strconv.ErrRange },
+        UInt128ParseBaseTC{ "f5lxx1zz5pnorynqglhzmsp34", 36,
+            UInt128{}, strconv.ErrRange },
+        UInt128ParseBaseTC{ "340282366920938463463374607431768211456", 0,
+            UInt128{}, strconv.ErrRange },
+        UInt128ParseBaseTC{ "", 0, UInt128{}, strconv.ErrSyntax },
+        UInt128ParseBaseTC{ "0x", 0, UInt128{}, strconv.ErrSyntax },
+        UInt128ParseBaseTC{ "0x_", 0, UInt128{}, strconv.ErrSyntax },
+        UInt128ParseBaseTC{ "1__0", 0, UInt128{}, strconv.ErrSyntax },
+        UInt128ParseBaseTC{ "_10", 0, UInt128{}, strconv.ErrSyntax },
+        UInt128ParseBaseTC{ "10_", 0, UInt128{}, strconv.ErrSyntax },
+        UInt128ParseBaseTC{ "1_0", 10, UInt128{}, strconv.ErrSyntax },
+        UInt128ParseBaseTC{ "0x10", 16, UInt128{}, strconv.ErrSyntax },
+        UInt128ParseBaseTC{ "019", 0, UInt128{}, strconv.ErrSyntax },
+        UInt128ParseBaseTC{ "102", 2, UInt128{}, strconv.ErrSyntax },
+        UInt128ParseBaseTC{ "-1", 10, UInt128{}, strconv.ErrSyntax },
+    }
+    for i, tc := range testCases {
+        result, err := ParseUInt128Base(tc.str, tc.base)
+        if tc.expected!=result || !errorMatch(tc.expError, err) {
+            t.Errorf("Result mismatch: %d: parseBase(%v,%v)->%v,%v!=%v,%v",
+                     i, tc.str, tc.base, tc.expected, tc.expError, result, err)
+        }
+        result, err = ParseUInt128BaseBytes([]byte(tc.str), tc.base)
+        if tc.expected!=result || !errorMatch(tc.expError, err) {
+            t.Errorf("Result mismatch: %d: parseBaseBytes(%v,%v)->%v,%v!=%v,%v",
+                     i, tc.str, tc.base, tc.expected, tc.expError, result, err)
+        }
+    }
+    _, err := ParseUInt128Base("11", 37)
+    if numErr, ok := err.(*NumError); !ok || numErr.Err!=ErrInvalidBase {
+        t.Errorf("Unexpected error: %v", err)
+    }
+    _, err = ParseUInt128Base("11", 1)
+    if !errorMatch(ErrInvalidBase, err) {
+        t.Errorf("Unexpected error: %v", err)
+    }
+}
+
+type NumErrorTC struct {
+    str string
+    expected NumError
+}
+
+func TestNumError(t *testing.T) {
+    testCases := []NumErrorTC {
+        NumErrorTC{ "", NumError{ "ParseUInt128", "", 0, strconv.ErrSyntax } },
+        NumErrorTC{ "342xx", NumError{ "ParseUInt128", "342xx", 3, strconv.ErrSyntax } },
+        NumErrorTC{ "340282366920938463463374607431768211456",
+            NumError{ "ParseUInt128", "340282366920938463463374607431768211456",
+                38, strconv.ErrRange } },
+        NumErrorTC{ "1558928921818937854975793489238928111248",
+            NumError{ "ParseUInt128", "1558928921818937854975793489238928111248",
+                39, strconv.ErrRange } },
+        NumErrorTC{ "3402823669209384634633746074317682114550",
+            NumError{ "ParseUInt128", "3402823669209384634633746074317682114550",
+                39, strconv.ErrRange } },
+    }
+    for i, tc := range testCases {
+        _, err := ParseUInt128(tc.str)
+        if numErr, ok := err.(*NumError); !ok || *numErr!=tc.expected {
+            t.Errorf("Result mismatch: %d: parse(%v)->%v!=%v",
+                     i, tc.str, tc.expected, err)
+        }
+    }
+    _, err := ParseUInt128Base("0x12_g", 0)
+    expected := NumError{ "ParseUInt128Base", "0x12_g", 5, strconv.ErrSyntax }
+    if numErr, ok := err.(*NumError); !ok || *numErr!=expected {
+        t.Errorf("Result mismatch: %v!=%v", expected, err)
+    }
+    if err.Error()!=`goint128.ParseUInt128Base: parsing "0x12_g" at offset 5: invalid syntax` {
+        t.Errorf("Result mismatch: %v", err.Error())
+    }
+    var a UInt128
+    err = a.UnmarshalJSON([]byte(`"1234x"`))
+    expected = NumError{ "UnmarshalJSON", `"1234x"`, 5, strconv.ErrSyntax }
+    if numErr, ok := err.(*NumError); !ok || *numErr!=expected {
+        t.Errorf("Result mismatch: %v!=%v", expected, err)
+    }
+    err = a.UnmarshalText([]byte("12a4"))
+    expected = NumError{ "UnmarshalText", "12a4", 2, strconv.ErrSyntax }
+    if numErr, ok := err.(*NumError); !ok || *numErr!=expected {
+        t.Errorf("Result mismatch: %v!=%v", expected, err)
+    }
+}
+
 func BenchmarkUInt128Parse64(b *testing.B) {
     s := "834899285198348317"
     for i := 0; i < b.N; i++ {
@@ -669,6 +1973,84 @@ func BenchmarkUInt128Parse128Bytes(b *testing.B) {
     }
 }
 
+// old decimal parsing (digit by digit) used as reference for tests and benchmarks
+func parseUInt128Reference(str string) (UInt128, int, error) {
+    lastDigitValue := UInt128{ 11068046444225730969, 1844674407370955161 }
+    slen := len(str)
+    var out UInt128
+    var carry uint64
+    var i int
+    for i=0; i<19 && i<slen && str[i]>='0' && str[i]<='9'; i++ {
+        digit := byte(str[i])-'0'
+        // multiply by 10
+        out[0] *= 10
+        // add digit
+        out[0] += uint64(digit)
+    }
+    
+    for ; i<slen && str[i]>='0' && str[i]<='9'; i++ {
+        if out[1]>lastDigitValue[1] ||
+            (out[1]==lastDigitValue[1] && out[0] > lastDigitValue[0]) {
+            return UInt128{}, i, strconv.ErrRange
+        }
+        digit := byte(str[i])-'0'
+        temp := out
+        // multiply by 10
+        out[1] = (temp[1]<<3) | (temp[0]>>61)
+        out[0] = temp[0]<<3
+        out[0], carry = Add64(out[0], temp[0]<<1, 0)
+        out[1], _ = Add64(out[1], (temp[1]<<1) | (temp[0]>>63), carry)
+        // add digit
+        out[0], carry = Add64(out[0], uint64(digit), 0)
+        out[1], carry = Add64(out[1], 0, carry)
+        if carry!=0 {
+            return UInt128{}, i, strconv.ErrRange
+        }
+    }
+    if i==0 || i!=slen {
+        return UInt128{}, i, strconv.ErrSyntax
+    }
+    return out, 0, nil
+}
+
+func TestUInt128ParseReference(t *testing.T) {
+    strs := []string { "", "0", "00000000000000000000000000000000000000000001", "x", "1x",
+        "340282366920938463463374607431768211455",
+        "340282366920938463463374607431768211456",
+        "340282366920938463463374607431768211455x",
+        "340282366920938463463374607431768211456x",
+        "3402823669209384634633746074317682114550",
+        "99999999999999999999999999999999999999999999999999x",
+        "1844674407370955161518446744073709551615",
+        "18446744073709551615x18446744073709551615" }
+    rnd := rand.New(rand.NewSource(4321))
+    for i:=0; i<3000; i++ {
+        digits := make([]byte, 1+rnd.Intn(45))
+        for j := range digits {
+            digits[j] = byte('0'+rnd.Intn(10))
+        }
+        if rnd.Intn(4)==0 {
+            digits[rnd.Intn(len(digits))] = 'a'
+        }
+        strs = append(strs, string(digits))
+    }
+    for i, str := range strs {
+        expected, expOffset, expErr := parseUInt128Reference(str)
+        result, offset, err := parseUInt128(str)
+        if expected!=result || expOffset!=offset || expErr!=err {
+            t.Errorf("Result mismatch: %d: parse(%v)->%v,%v,%v!=%v,%v,%v",
+                     i, str, expected, expOffset, expErr, result, offset, err)
+        }
+    }
+}
+
+func BenchmarkUInt128ParseReference128(b *testing.B) {
+    s := "303386871892539280136352169180487469"
+    for i := 0; i < b.N; i++ {
+        parseUInt128Reference(s)
+    }
+}
+
 type UInt128ToFloat64TC struct {
     value UInt128
     expected float64
@@ -684,6 +2066,8 @@ func TestUInt128ToFloat64(t *testing.T) {
                     189589895689685989335661129029377.0 },
         UInt128ToFloat64TC{ UInt128{ 0xffffffffffffffff, 0xffffffffffffffff },
                 340282366920938463463374607431768211455.0 },
+        // sum of separately converted parts is rounded twice
+        UInt128ToFloat64TC{ UInt128{ 10819940262408736842, 2 }, 47713428409827844096.0 },
     }
     for i, tc := range testCases {
         result := tc.value.ToFloat64()
@@ -694,6 +2078,89 @@ func TestUInt128ToFloat64(t *testing.T) {
     }
 }
 
+type UInt128ToFloatRoundTC struct {
+    value UInt128
+    mode RoundingMode
+    expected64 float64
+    expExact64 bool
+    expected32 float32
+    expExact32 bool
+}
+
+func TestUInt128ToFloatRound(t *testing.T) {
+    testCases := []UInt128ToFloatRoundTC{
+        UInt128ToFloatRoundTC{ UInt128{ 0, 0 }, ToNearestEven, 0.0, true, 0.0, true },
+        UInt128ToFloatRoundTC{ UInt128{ 16777217, 0 }, ToNearestEven,
+            16777217.0, true, 16777216.0, false },
+        UInt128ToFloatRoundTC{ UInt128{ 16777217, 0 }, ToPositiveInf,
+            16777217.0, true, 16777218.0, false },
+        UInt128ToFloatRoundTC{ UInt128{ 16777219, 0 }, ToNearestEven,
+            16777219.0, true, 16777220.0, false },
+        UInt128ToFloatRoundTC{ UInt128{ 16777219, 0 }, ToZero,
+            16777219.0, true, 16777218.0, false },
+        UInt128ToFloatRoundTC{ UInt128{ 16777217, 0 }, ToNearestAway,
+            16777217.0, true, 16777218.0, false },
+        UInt128ToFloatRoundTC{ UInt128{ 16777219, 0 }, AwayFromZero,
+            16777219.0, true, 16777220.0, false },
+        UInt128ToFloatRoundTC{ UInt128{ 10819940262408736842, 2 }, ToNearestEven,
+            47713428409827844096.0, false, 47713430232641830912.0, false },
+        UInt128ToFloatRoundTC{ UInt128{ 10819940262408736842, 2 }, ToZero,
+            47713428409827835904.0, false, 47713425834595319808.0, false },
+        UInt128ToFloatRoundTC{ UInt128{ 10819940262408736842, 2 }, ToNegativeInf,
+            47713428409827835904.0, false, 47713425834595319808.0, false },
+        UInt128ToFloatRoundTC{ UInt128{ 10819940262408736842, 2 }, ToPositiveInf,
+            47713428409827844096.0, false, 47713430232641830912.0, false },
+        UInt128ToFloatRoundTC{ UInt128{ 0, 1<<63 }, ToPositiveInf,
+            170141183460469231731687303715884105728.0, true,
+            170141183460469231731687303715884105728.0, true },
+        UInt128ToFloatRoundTC{ MaxUInt128, ToNearestEven,
+            340282366920938463463374607431768211456.0, false,
+            float32(math.Inf(1)), false },
+        UInt128ToFloatRoundTC{ MaxUInt128, ToZero,
+            340282366920938425684442744474606501888.0, false,
+            math.MaxFloat32, false },
+    }
+    for i, tc := range testCases {
+        result64, exact64 := tc.value.ToFloat64Round(tc.mode)
+        if tc.expected64!=result64 || tc.expExact64!=exact64 {
+            t.Errorf("Result mismatch: %d: tofloat64(%v,%v)->%v,%v!=%v,%v",
+                     i, tc.value, tc.mode, tc.expected64, tc.expExact64,
+                     result64, exact64)
+        }
+        result32, exact32 := tc.value.ToFloat32Round(tc.mode)
+        if tc.expected32!=result32 || tc.expExact32!=exact32 {
+            t.Errorf("Result mismatch: %d: tofloat32(%v,%v)->%v,%v!=%v,%v",
+                     i, tc.value, tc.mode, tc.expected32, tc.expExact32,
+                     result32, exact32)
+        }
+    }
+    // compare with rounding of big float
+    modes := []RoundingMode{ ToNearestEven, ToZero, ToNegativeInf, ToPositiveInf,
+        ToNearestAway, AwayFromZero }
+    bigModes := []big.RoundingMode{ big.ToNearestEven, big.ToZero,
+        big.ToNegativeInf, big.ToPositiveInf, big.ToNearestAway, big.AwayFromZero }
+    rnd := rand.New(rand.NewSource(7))
+    for i:=0; i<2000; i++ {
+        v := UInt128{ rnd.Uint64(), rnd.Uint64() }.Shr(uint(rnd.Intn(128)))
+        for j, mode := range modes {
+            bf := new(big.Float).SetMode(bigModes[j]).SetPrec(53).SetInt(v.BigInt())
+            expected64, _ := bf.Float64()
+            result64, exact64 := v.ToFloat64Round(mode)
+            if expected64!=result64 || (bf.Acc()==big.Exact)!=exact64 {
+                t.Errorf("Result mismatch: tofloat64(%v,%v)->%v,%v!=%v,%v",
+                         v, mode, expected64, bf.Acc(), result64, exact64)
+            }
+            bf = new(big.Float).SetMode(bigModes[j]).SetPrec(24).SetInt(v.BigInt())
+            expected32, _ := bf.Float32()
+            result32, exact32 := v.ToFloat32Round(mode)
+            if expected32!=result32 || (bf.Acc()==big.Exact)!=exact32 {
+                t.Errorf("Result mismatch: tofloat32(%v,%v)->%v,%v!=%v,%v",
+                         v, mode, expected32, bf.Acc(), result32, exact32)
+            }
+        }
+    }
+}
+
 type Float64ToUInt128TC struct {
     value float64
     expected UInt128
@@ -731,7 +2198,7 @@ func TestFloat64ToUInt128(t *testing.T) {
     }
     for i, tc := range testCases {
         result, err := Float64ToUInt128(tc.value)
-        if tc.expected!=result || tc.expError!=err {
+        if tc.expected!=result || !errorMatch(tc.expError, err) {
             t.Errorf("Result mismatch: %d: touint128(%v)->%v,%v!=%v,%v",
                      i, tc.value, tc.expected, tc.expError, result, err)
         }
@@ -743,6 +2210,66 @@ type UInt128MarshalBinTC struct {
     expected []byte
 }
 
+type Float64ToUInt128RoundTC struct {
+    value float64
+    mode RoundingMode
+    expected UInt128
+    expExact bool
+    expError error
+}
+
+func TestFloat64ToUInt128Round(t *testing.T) {
+    testCases := []Float64ToUInt128RoundTC{
+        Float64ToUInt128RoundTC{ 0.0, ToNearestEven, UInt128{}, true, nil },
+        Float64ToUInt128RoundTC{ math.Copysign(0, -1), ToNegativeInf, UInt128{}, true, nil },
+        Float64ToUInt128RoundTC{ 1.5, ToNearestEven, UInt128{ 2, 0 }, false, nil },
+        Float64ToUInt128RoundTC{ 2.5, ToNearestEven, UInt128{ 2, 0 }, false, nil },
+        Float64ToUInt128RoundTC{ 2.5000001, ToNearestEven, UInt128{ 3, 0 }, false, nil },
+        Float64ToUInt128RoundTC{ 2.4999999, ToNearestEven, UInt128{ 2, 0 }, false, nil },
+        Float64ToUInt128RoundTC{ 2.7, ToZero, UInt128{ 2, 0 }, false, nil },
+        Float64ToUInt128RoundTC{ 2.7, ToNegativeInf, UInt128{ 2, 0 }, false, nil },
+        Float64ToUInt128RoundTC{ 2.1, ToPositiveInf, UInt128{ 3, 0 }, false, nil },
+        Float64ToUInt128RoundTC{ 2.5, ToNearestAway, UInt128{ 3, 0 }, false, nil },
+        Float64ToUInt128RoundTC{ 2.4999999, ToNearestAway, UInt128{ 2, 0 }, false, nil },
+        Float64ToUInt128RoundTC{ 2.1, AwayFromZero, UInt128{ 3, 0 }, false, nil },
+        Float64ToUInt128RoundTC{ 4503599627370495.5, ToPositiveInf,
+            UInt128{ 4503599627370496, 0 }, false, nil },
+        Float64ToUInt128RoundTC{ 26858969188828978177.0, ToPositiveInf,
+            UInt128{ 8412225115119427584, 1 }, true, nil },
+        Float64ToUInt128RoundTC{ 340282366920938425684442744474606501888.0, ToNearestEven,
+            UInt128{ 0, 0xfffffffffffff800 }, true, nil },
+        Float64ToUInt128RoundTC{ -0.3, ToZero, UInt128{}, false, nil },
+        Float64ToUInt128RoundTC{ -0.3, ToPositiveInf, UInt128{}, false, nil },
+        Float64ToUInt128RoundTC{ -0.5, ToNearestEven, UInt128{}, false, nil },
+        Float64ToUInt128RoundTC{ -0.6, ToNearestEven, UInt128{}, false, strconv.ErrRange },
+        Float64ToUInt128RoundTC{ -0.4, ToNearestAway, UInt128{}, false, nil },
+        Float64ToUInt128RoundTC{ -0.5, ToNearestAway, UInt128{}, false, strconv.ErrRange },
+        Float64ToUInt128RoundTC{ -0.3, AwayFromZero, UInt128{}, false, strconv.ErrRange },
+        Float64ToUInt128RoundTC{ -0.3, ToNegativeInf, UInt128{}, false, strconv.ErrRange },
+        Float64ToUInt128RoundTC{ -1.0, ToPositiveInf, UInt128{}, false, strconv.ErrRange },
+        Float64ToUInt128RoundTC{ 340282366920938463463374607431768211456.0, ToZero,
+            UInt128{}, false, strconv.ErrRange },
+        Float64ToUInt128RoundTC{ math.Inf(1), ToZero, UInt128{}, false, strconv.ErrRange },
+        Float64ToUInt128RoundTC{ math.NaN(), ToZero, UInt128{}, false, strconv.ErrRange },
+    }
+    for i, tc := range testCases {
+        result, exact, err := Float64ToUInt128Round(tc.value, tc.mode)
+        if tc.expected!=result || tc.expExact!=exact || tc.expError!=err {
+            t.Errorf("Result mismatch: %d: touint128(%v,%v)->%v,%v,%v!=%v,%v,%v",
+                     i, tc.value, tc.mode, tc.expected, tc.expExact, tc.expError,
+                     result, exact, err)
+        }
+        if float64(float32(tc.value))==tc.value || math.IsNaN(tc.value) {
+            result, exact, err = Float32ToUInt128Round(float32(tc.value), tc.mode)
+            if tc.expected!=result || tc.expExact!=exact || tc.expError!=err {
+                t.Errorf("Result mismatch: %d: touint128(float32(%v),%v)->%v,%v,%v!=%v,%v,%v",
+                         i, tc.value, tc.mode, tc.expected, tc.expExact, tc.expError,
+                         result, exact, err)
+            }
+        }
+    }
+}
+
 func TestUInt128MarshalBinary(t *testing.T) {
     testCases := []UInt128MarshalBinTC{
         UInt128MarshalBinTC{ UInt128{ 0xccaa010203040506, 0xbbaca34c0a04521 },
@@ -781,7 +2308,7 @@ func TestUInt128UnmarshalBinary(t *testing.T) {
     for i, tc := range testCases {
         var v UInt128
         err := v.UnmarshalBinary(tc.data)
-        if tc.expected!=v || tc.expError!=err {
+        if tc.expected!=v || !errorMatch(tc.expError, err) {
             t.Errorf("Result mismatch: %d: unmarshalbin(%v)->%v,%v!=%v,%v",
                      i, tc.data, tc.expected, tc.expError, v, err)
         }
@@ -819,7 +2346,7 @@ func TestUInt128UnmarshalText(t *testing.T) {
     for i, tc := range testCases {
         var v UInt128
         err := v.UnmarshalText(tc.data)
-        if tc.expected!=v || tc.expError!=err {
+        if tc.expected!=v || !errorMatch(tc.expError, err) {
             t.Errorf("Result mismatch: %d: unmarshaltext(%v)->%v,%v!=%v,%v",
                      i, tc.data, tc.expected, tc.expError, v, err)
         }
@@ -863,7 +2390,7 @@ func TestUInt128UnmarshalJSON(t *testing.T) {
     for i, tc := range testCases {
         var v UInt128
         err := v.UnmarshalJSON(tc.data)
-        if tc.expected!=v || tc.expError!=err {
+        if tc.expected!=v || !errorMatch(tc.expError, err) {
             t.Errorf("Result mismatch: %d: unmarshaljson(%v)->%v,%v!=%v,%v",
                      i, tc.data, tc.expected, tc.expError, v, err)
         }
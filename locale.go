@@ -23,7 +23,6 @@
 package goint128
 
 import (
-    "strings"
     "strconv"
     "unicode/utf8"
 )
@@ -122,7 +121,7 @@ var localeFormats map[string]LocFmt = map[string]LocFmt {
 }
 
 // get locale formating info
-func GetLocFmt(lang string) *LocFmt {
+func getLocFmt(lang string) LocFmt {
     outLang := lang
     langSlen := len(lang)
     if langSlen>=3 && (lang[2]=='_' || lang[2]=='-') {
@@ -132,16 +131,27 @@ func GetLocFmt(lang string) *LocFmt {
     }
     l, ok := localeFormats[outLang]
     if !ok { l = defaultLocaleFormat }
+    return l
+}
+
+// get locale formating info
+func GetLocFmt(lang string) *LocFmt {
+    l := getLocFmt(lang)
     return &l
 }
 
-// format 128-bit unsigned integer including locale
-func (a UInt128) LocaleFormatBytes(lang string, noSep1000 bool) []byte {
-    l := GetLocFmt(lang)
-    s := a.FormatBytes()
+// append UTF-8 encoded rune to bytes
+func appendRune(dst []byte, r rune) []byte {
+    var rbuf [utf8.UTFMax]byte
+    return append(dst, rbuf[:utf8.EncodeRune(rbuf[:], r)]...)
+}
+
+// append 128-bit unsigned integer formatted including locale to bytes
+func (a UInt128) AppendLocaleFormat(dst []byte, lang string, noSep1000 bool) []byte {
+    l := getLocFmt(lang)
+    var buf [40]byte
+    s := a.AppendFormat(buf[:0])
     slen := len(s)
-    os := make([]byte, slen<<1) // optimization
-    oslen := 0
     ti := slen
     i := slen
     if !l.Sep100and1000 {
@@ -150,79 +160,57 @@ func (a UInt128) LocaleFormatBytes(lang string, noSep1000 bool) []byte {
     }
     for _, r := range s {
         if r>='0' && r<='9' {
-            if oslen+4 >= len(os) {
-                os = append(os, 0,0,0,0)
-            }
-            oslen += utf8.EncodeRune(os[oslen:], l.Digits[r-'0'])
+            dst = appendRune(dst, l.Digits[r-'0'])
         }
         if !noSep1000 && i!=1 {
             if !l.Sep100and1000 || ti<=3 {
                 ti--
                 if ti==0 {
-                    if oslen+4 >= len(os) {
-                        os = append(os, 0,0,0,0)
-                    }
-                    oslen += utf8.EncodeRune(os[oslen:], l.Sep1000)
+                    dst = appendRune(dst, l.Sep1000)
                     ti = 3
                 }
             } else {
                 ti--
                 if (ti-3)&1==0 {
-                    if oslen+4 >= len(os) {
-                        os = append(os, 0,0,0,0)
-                    }
-                    oslen += utf8.EncodeRune(os[oslen:], l.Sep1000)
+                    dst = appendRune(dst, l.Sep1000)
                 }
             }
         }
         i--
     }
-    return os[:oslen]
+    return dst
+}
+
+// format 128-bit unsigned integer including locale
+func (a UInt128) LocaleFormatBytes(lang string, noSep1000 bool) []byte {
+    return a.AppendLocaleFormat(make([]byte, 0, 64), lang, noSep1000)
 }
 
 // format 128-bit unsigned integer including locale
 func (a UInt128) LocaleFormat(lang string, noSep1000 bool) string {
-    l := GetLocFmt(lang)
-    s := a.FormatBytes()
-    var os strings.Builder
-    slen := len(s)
-    os.Grow(slen*3)
-    ti := slen
-    i := slen
-    if !l.Sep100and1000 {
-        ti = (slen)%3
-        if ti==0 { ti=3 }
-    }
-    for _, r := range s {
-        if r>='0' && r<='9' {
-            os.WriteRune(l.Digits[r-'0'])
-        }
-        if !noSep1000 && i!=1 {
-            if !l.Sep100and1000 || ti<=3 {
-                ti--
-                if ti==0 {
-                    os.WriteRune(l.Sep1000)
-                    ti = 3
-                }
-            } else {
-                ti--
-                if (ti-3)&1==0 {
-                    os.WriteRune(l.Sep1000)
-                }
-            }
+    var buf [192]byte
+    return string(a.AppendLocaleFormat(buf[:0], lang, noSep1000))
+}
+
+// return byte offset of n-th digit in string formatted with locale rules
+func localeDigitOffset(l LocFmt, str string, n int) int {
+    for i, r := range str {
+        if r!=l.Sep1000 && r!=l.Sep1000_2 {
+            if n==0 { return i }
+            n--
         }
-        i--
     }
-    return os.String()
+    return len(str)
 }
 
-// parse unsigned integer from string and return value and error (nil if no error)
-func LocaleParseUInt128(lang, str string) (UInt128, error) {
-    l := GetLocFmt(lang)
-    if len(str)==0 { return UInt128{}, strconv.ErrSyntax }
+// parse unsigned integer from string including locale and return value,
+// offset of first bad character and error
+func localeParseUInt128(lang, str string) (UInt128, int, error) {
+    l := getLocFmt(lang)
+    if len(str)==0 { return UInt128{}, 0, strconv.ErrSyntax }
     
     os := make([]byte, 0, len(str))
-    for _, r := range str {
+    for i, r := range str {
         if r>='0' && r<='9' {
             // if standard digits
             os = append(os, byte(r))
@@ -236,41 +224,33 @@ func LocaleParseUInt128(lang, str string) (UInt128, error) {
                     break
                 }
             }
-            if !found { return UInt128{}, strconv.ErrSyntax }
+            if !found { return UInt128{}, i, strconv.ErrSyntax }
             os = append(os, '0'+byte(dig))
         }
         // otherwise skip sep1000
     }
-    return ParseUInt128Bytes(os)
+    out, offset, err := parseUInt128(bytesToString(os))
+    if err!=nil {
+        return UInt128{}, localeDigitOffset(l, str, offset), err
+    }
+    return out, 0, nil
 }
 
 // parse unsigned integer from string and return value and error (nil if no error)
+func LocaleParseUInt128(lang, str string) (UInt128, error) {
+    out, offset, err := localeParseUInt128(lang, str)
+    if err!=nil {
+        return UInt128{}, numError("LocaleParseUInt128", str, offset, err)
+    }
+    return out, nil
+}
+
+// parse unsigned integer from bytes and return value and error (nil if no error)
 func LocaleParseUInt128Bytes(lang string, strInput []byte) (UInt128, error) {
-    l := GetLocFmt(lang)
-    if len(strInput)==0 { return UInt128{}, strconv.ErrSyntax }
-    
-    os := make([]byte, 0, len(strInput))
-    str := strInput
-    for len(str)>0 {
-        r, size := utf8.DecodeRune(str)
-        if r>='0' && r<='9' {
-            // if standard digits
-            os = append(os, byte(r))
-        } else if r!=l.Sep1000 && r!=l.Sep1000_2 {
-            // if non-standard digit
-            dig:=0
-            found := false
-            for ; dig<=9; dig++ {
-                if l.Digits[dig]==r {
-                    found = true
-                    break
-                }
-            }
-            if !found { return UInt128{}, strconv.ErrSyntax }
-            os = append(os, '0'+byte(dig))
-        }
-        // otherwise skip sep1000
-        str = str[size:]
+    out, offset, err := localeParseUInt128(lang, bytesToString(strInput))
+    if err!=nil {
+        return UInt128{}, numError("LocaleParseUInt128Bytes", string(strInput),
+                                   offset, err)
     }
-    return ParseUInt128Bytes(os)
+    return out, nil
 }
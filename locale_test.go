@@ -148,6 +148,11 @@ func TestUInt128LocaleFormat(t *testing.T) {
             t.Errorf("Result mismatch: %d: fmtBytes(%v,%s)->%v!=%v",
                      i, tc.a, tc.lang, tc.expected, result)
         }
+        resultBytes = tc.a.AppendLocaleFormat([]byte("x="), tc.lang, tc.noSep1000)
+        if "x="+tc.expected!=string(resultBytes) {
+            t.Errorf("Result mismatch: %d: append(%v,%s)->%v!=%v",
+                     i, tc.a, tc.lang, "x="+tc.expected, string(resultBytes))
+        }
         if tc.a!=a {
             t.Errorf("Argument has been modified: %d %s: %v!=%v", i, tc.lang, a, tc.a)
         }
@@ -175,18 +180,54 @@ func TestUInt128LocaleParse(t *testing.T) {
     }
     for i, tc := range testCases {
         result, err := LocaleParseUInt128(tc.lang, tc.str)
-        if tc.expected!=result || tc.expError!=err {
+        if tc.expected!=result || !errorMatch(tc.expError, err) {
             t.Errorf("Result mismatch: %d: parse(%v,%v)->%v,%v!=%v,%v",
                      i, tc.lang, tc.str, tc.expected, tc.expError, result, err)
         }
         result, err = LocaleParseUInt128Bytes(tc.lang, []byte(tc.str))
-        if tc.expected!=result || tc.expError!=err {
+        if tc.expected!=result || !errorMatch(tc.expError, err) {
             t.Errorf("Result mismatch: %d: parseBytes(%v,%v)->%v,%v!=%v,%v",
                      i, tc.lang, tc.str, tc.expected, tc.expError, result, err)
         }
     }
 }
 
+type UInt128LocNumErrorTC struct {
+    lang, str string
+    expOffset int
+    expError error
+}
+
+func TestUInt128LocaleParseNumError(t *testing.T) {
+    testCases := []UInt128LocNumErrorTC {
+        UInt128LocNumErrorTC{ "en", "", 0, strconv.ErrSyntax },
+        UInt128LocNumErrorTC{ "en", ",", 1, strconv.ErrSyntax },
+        UInt128LocNumErrorTC{ "en", "1,234x", 5, strconv.ErrSyntax },
+        UInt128LocNumErrorTC{ "bn", "১,২৩,৪৫x৬৭,৮৯০", 17, strconv.ErrSyntax },
+        UInt128LocNumErrorTC{ "en",
+            "340,282,366,920,938,463,463,374,607,431,768,211,456",
+            50, strconv.ErrRange },
+    }
+    for i, tc := range testCases {
+        _, err := LocaleParseUInt128(tc.lang, tc.str)
+        numErr, ok := err.(*NumError)
+        if !ok || numErr.Func!="LocaleParseUInt128" ||
+            numErr.Num!=tc.str || numErr.Offset!=tc.expOffset ||
+            numErr.Err!=tc.expError {
+            t.Errorf("Result mismatch: %d: parse(%v,%v)->%v,%v!=%v",
+                     i, tc.lang, tc.str, tc.expOffset, tc.expError, err)
+        }
+        _, err = LocaleParseUInt128Bytes(tc.lang, []byte(tc.str))
+        numErr, ok = err.(*NumError)
+        if !ok || numErr.Func!="LocaleParseUInt128Bytes" ||
+            numErr.Num!=tc.str || numErr.Offset!=tc.expOffset ||
+            numErr.Err!=tc.expError {
+            t.Errorf("Result mismatch: %d: parseBytes(%v,%v)->%v,%v!=%v",
+                     i, tc.lang, tc.str, tc.expOffset, tc.expError, err)
+        }
+    }
+}
+
 func BenchmarkUInt128LocaleFormat(b *testing.B) {
     a := UInt128{ 7341542494928938945, 938491 }
     for i := 0; i < b.N; i++ {
@@ -200,3 +241,23 @@ func BenchmarkUInt128LocaleFormatBytes(b *testing.B) {
         a.LocaleFormatBytes("pl", false)
     }
 }
+
+func TestUInt128AppendLocaleFormatAllocs(t *testing.T) {
+    a := UInt128{ 7341542494928938945, 938491 }
+    buf := make([]byte, 0, 128)
+    allocs := testing.AllocsPerRun(100, func() {
+        buf = a.AppendLocaleFormat(buf[:0], "bn", false)
+    })
+    if allocs!=0 {
+        t.Errorf("AppendLocaleFormat allocates: %v", allocs)
+    }
+}
+
+func BenchmarkUInt128AppendLocaleFormat(b *testing.B) {
+    a := UInt128{ 7341542494928938945, 938491 }
+    buf := make([]byte, 0, 128)
+    b.ReportAllocs()
+    for i := 0; i < b.N; i++ {
+        buf = a.AppendLocaleFormat(buf[:0], "pl", false)
+    }
+}
@@ -9,11 +9,10 @@
 package goint128
 
 import "math/bits"
-import "errors"
 
 
-var divideError = errors.New("Divide by zero")
-var overflowError = errors.New("Number overflow")
+var divideError = ErrDivideByZero
+var overflowError = ErrOverflow
 
 // Add64 returns the sum with carry of x, y and carry: sum = x + y + carry.
 // The carry input must be 0 or 1; otherwise the behavior is undefined.
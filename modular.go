@@ -0,0 +1,115 @@
+/*
+ * modular.go - modular arithmetic on 128-bit integers
+ *
+ * goint128 - go int128 library
+ * Copyright (C) 2020  Mateusz Szpakowski
+ *
+ * This library is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU Lesser General Public
+ * License as published by the Free Software Foundation; either
+ * version 2.1 of the License, or (at your option) any later version.
+ *
+ * This library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+ * Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public
+ * License along with this library; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301  USA
+ */
+
+// Package to operate on 128-bit integers
+package goint128
+
+// reduce 128-bit unsigned integer modulo m if it is not lesser than m
+func (a UInt128) reduce(m UInt128) UInt128 {
+    if a.Cmp(m)>=0 {
+        return a.Mod(m)
+    }
+    return a
+}
+
+// return (a+b) mod m. panics if m is zero
+func (a UInt128) AddMod(b, m UInt128) UInt128 {
+    if m[0]==0 && m[1]==0 {
+        panic("Divide by zero")
+    }
+    a, b = a.reduce(m), b.reduce(m)
+    c, carry := a.AddC(b, 0)
+    if carry!=0 || c.Cmp(m)>=0 {
+        c = c.Sub(m)
+    }
+    return c
+}
+
+// return (a-b) mod m. panics if m is zero
+func (a UInt128) SubMod(b, m UInt128) UInt128 {
+    if m[0]==0 && m[1]==0 {
+        panic("Divide by zero")
+    }
+    a, b = a.reduce(m), b.reduce(m)
+    c, borrow := a.SubB(b, 0)
+    if borrow!=0 {
+        c = c.Add(m)
+    }
+    return c
+}
+
+// return (a*b) mod m. panics if m is zero
+func (a UInt128) MulMod(b, m UInt128) UInt128 {
+    if m[0]==0 && m[1]==0 {
+        panic("Divide by zero")
+    }
+    a, b = a.reduce(m), b.reduce(m)
+    // high part of product is lesser than m
+    hi, lo := a.MulFull(b)
+    _, r := UInt128DivFull(hi, lo, m)
+    return r
+}
+
+// return (a^exp) mod m. panics if m is zero
+func (a UInt128) PowMod(exp, m UInt128) UInt128 {
+    if m[0]==0 && m[1]==0 {
+        panic("Divide by zero")
+    }
+    c := UInt128{ 1, 0 }.reduce(m)
+    a = a.reduce(m)
+    for i := exp.Len()-1; i>=0; i-- {
+        c = c.MulMod(c, m)
+        if exp.Bit(uint(i))!=0 {
+            c = c.MulMod(a, m)
+        }
+    }
+    return c
+}
+
+// return modular inverse of a modulo m (x such that a*x mod m = 1) and true
+// if it exists (a and m are coprime), otherwise return zero and false.
+// panics if m is zero
+func (a UInt128) ModInverse(m UInt128) (UInt128, bool) {
+    if m[0]==0 && m[1]==0 {
+        panic("Divide by zero")
+    }
+    if m[0]==1 && m[1]==0 {
+        return UInt128{}, true
+    }
+    // extended Euclid algorithm. signs of coefficients alternate, thus
+    // only magnitudes are stored: u(k+1) = u(k-1) + q*u(k)
+    r0, r1 := m, a.reduce(m)
+    u0, u1 := UInt128{}, UInt128{ 1, 0 }
+    neg := false
+    for !r1.IsZero() && !(r1[0]==1 && r1[1]==0) {
+        q, r := r0.DivMod(r1)
+        r0, r1 = r1, r
+        u0, u1 = u1, u0.Add(q.Mul(u1))
+        neg = !neg
+    }
+    if r1.IsZero() {
+        return UInt128{}, false
+    }
+    if neg {
+        return m.Sub(u1), true
+    }
+    return u1, true
+}
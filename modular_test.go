@@ -0,0 +1,231 @@
+/*
+ * modular_test.go - tests for modular arithmetic on 128-bit integers
+ *
+ * goint128 - go int128 library
+ * Copyright (C) 2020  Mateusz Szpakowski
+ *
+ * This library is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU Lesser General Public
+ * License as published by the Free Software Foundation; either
+ * version 2.1 of the License, or (at your option) any later version.
+ *
+ * This library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+ * Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public
+ * License along with this library; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301  USA
+ */
+
+package goint128
+
+import (
+    "math/big"
+    "math/rand"
+    "testing"
+)
+
+// 2^128-159 - greatest 128-bit prime
+var prime128 = UInt128{ 0xffffffffffffff61, 0xffffffffffffffff }
+// 2^127-1 - Mersenne prime
+var prime127 = UInt128{ 0xffffffffffffffff, 0x7fffffffffffffff }
+
+type UInt128ModTC struct {
+    a, b, m UInt128
+    expected UInt128
+}
+
+func TestUInt128AddMod(t *testing.T) {
+    testCases := []UInt128ModTC {
+        UInt128ModTC{ UInt128{ 5, 0 }, UInt128{ 7, 0 }, UInt128{ 10, 0 }, UInt128{ 2, 0 } },
+        UInt128ModTC{ UInt128{ 15, 0 }, UInt128{ 27, 0 }, UInt128{ 10, 0 }, UInt128{ 2, 0 } },
+        UInt128ModTC{ prime128.Sub64(1), prime128.Sub64(2), prime128, prime128.Sub64(3) },
+        UInt128ModTC{ MaxUInt128, MaxUInt128, prime128, UInt128{ 316, 0 } },
+        UInt128ModTC{ MaxUInt128, UInt128{ 1, 0 }, MaxUInt128, UInt128{ 1, 0 } },
+        UInt128ModTC{ MaxUInt128.Sub64(1), MaxUInt128.Sub64(1), MaxUInt128,
+            MaxUInt128.Sub64(2) },
+    }
+    for i, tc := range testCases {
+        a, b := tc.a, tc.b
+        result := tc.a.AddMod(tc.b, tc.m)
+        if tc.expected!=result {
+            t.Errorf("Result mismatch: %d: addmod(%v,%v,%v)->%v!=%v",
+                     i, tc.a, tc.b, tc.m, tc.expected, result)
+        }
+        if tc.a!=a || tc.b!=b {
+            t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
+                     i, a, b, tc.a, tc.b)
+        }
+    }
+}
+
+func TestUInt128SubMod(t *testing.T) {
+    testCases := []UInt128ModTC {
+        UInt128ModTC{ UInt128{ 7, 0 }, UInt128{ 5, 0 }, UInt128{ 10, 0 }, UInt128{ 2, 0 } },
+        UInt128ModTC{ UInt128{ 5, 0 }, UInt128{ 7, 0 }, UInt128{ 10, 0 }, UInt128{ 8, 0 } },
+        UInt128ModTC{ UInt128{ 0, 0 }, UInt128{ 1, 0 }, prime128, prime128.Sub64(1) },
+        UInt128ModTC{ UInt128{ 0, 0 }, MaxUInt128, prime128, prime128.Sub64(158) },
+        UInt128ModTC{ UInt128{ 3, 0 }, MaxUInt128.Sub64(1), MaxUInt128, UInt128{ 4, 0 } },
+    }
+    for i, tc := range testCases {
+        a, b := tc.a, tc.b
+        result := tc.a.SubMod(tc.b, tc.m)
+        if tc.expected!=result {
+            t.Errorf("Result mismatch: %d: submod(%v,%v,%v)->%v!=%v",
+                     i, tc.a, tc.b, tc.m, tc.expected, result)
+        }
+        if tc.a!=a || tc.b!=b {
+            t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
+                     i, a, b, tc.a, tc.b)
+        }
+    }
+}
+
+func TestUInt128MulMod(t *testing.T) {
+    testCases := []UInt128ModTC {
+        UInt128ModTC{ UInt128{ 7, 0 }, UInt128{ 5, 0 }, UInt128{ 10, 0 }, UInt128{ 5, 0 } },
+        UInt128ModTC{ prime128.Sub64(1), prime128.Sub64(1), prime128, UInt128{ 1, 0 } },
+        UInt128ModTC{ MaxUInt128, MaxUInt128, prime128, UInt128{ 158*158, 0 } },
+        UInt128ModTC{ UInt128{ 0, 1 }, UInt128{ 0, 1 }, prime127, UInt128{ 2, 0 } },
+        UInt128ModTC{ MaxUInt128, UInt128{ 12345, 0 }, UInt128{ 1, 0 }, UInt128{ 0, 0 } },
+    }
+    for i, tc := range testCases {
+        a, b := tc.a, tc.b
+        result := tc.a.MulMod(tc.b, tc.m)
+        if tc.expected!=result {
+            t.Errorf("Result mismatch: %d: mulmod(%v,%v,%v)->%v!=%v",
+                     i, tc.a, tc.b, tc.m, tc.expected, result)
+        }
+        if tc.a!=a || tc.b!=b {
+            t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
+                     i, a, b, tc.a, tc.b)
+        }
+    }
+}
+
+func TestUInt128PowMod(t *testing.T) {
+    testCases := []UInt128ModTC {
+        UInt128ModTC{ UInt128{ 3, 0 }, UInt128{ 4, 0 }, UInt128{ 10, 0 }, UInt128{ 1, 0 } },
+        UInt128ModTC{ UInt128{ 12345, 0 }, UInt128{ 0, 0 }, UInt128{ 10, 0 }, UInt128{ 1, 0 } },
+        UInt128ModTC{ UInt128{ 12345, 0 }, UInt128{ 0, 0 }, UInt128{ 1, 0 }, UInt128{ 0, 0 } },
+        // Fermat's little theorem
+        UInt128ModTC{ UInt128{ 0x123456789abcdef, 0xfedcba }, prime128.Sub64(1), prime128,
+            UInt128{ 1, 0 } },
+        UInt128ModTC{ UInt128{ 3, 0 }, prime127.Sub64(1), prime127, UInt128{ 1, 0 } },
+        // 2^127 = 1 mod 2^127-1
+        UInt128ModTC{ UInt128{ 2, 0 }, UInt128{ 127, 0 }, prime127, UInt128{ 1, 0 } },
+    }
+    for i, tc := range testCases {
+        a, b := tc.a, tc.b
+        result := tc.a.PowMod(tc.b, tc.m)
+        if tc.expected!=result {
+            t.Errorf("Result mismatch: %d: powmod(%v,%v,%v)->%v!=%v",
+                     i, tc.a, tc.b, tc.m, tc.expected, result)
+        }
+        if tc.a!=a || tc.b!=b {
+            t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
+                     i, a, b, tc.a, tc.b)
+        }
+    }
+}
+
+type UInt128ModInverseTC struct {
+    a, m UInt128
+    expected UInt128
+    expOk bool
+}
+
+func TestUInt128ModInverse(t *testing.T) {
+    testCases := []UInt128ModInverseTC {
+        UInt128ModInverseTC{ UInt128{ 3, 0 }, UInt128{ 10, 0 }, UInt128{ 7, 0 }, true },
+        UInt128ModInverseTC{ UInt128{ 1, 0 }, UInt128{ 10, 0 }, UInt128{ 1, 0 }, true },
+        UInt128ModInverseTC{ UInt128{ 4, 0 }, UInt128{ 10, 0 }, UInt128{}, false },
+        UInt128ModInverseTC{ UInt128{ 0, 0 }, UInt128{ 10, 0 }, UInt128{}, false },
+        UInt128ModInverseTC{ UInt128{ 20, 0 }, UInt128{ 10, 0 }, UInt128{}, false },
+        UInt128ModInverseTC{ UInt128{ 5, 0 }, UInt128{ 1, 0 }, UInt128{}, true },
+        UInt128ModInverseTC{ UInt128{ 2, 0 }, MaxUInt128,
+            UInt128{ 0, 0x8000000000000000 }, true },
+        UInt128ModInverseTC{ MaxUInt128.Sub64(1), MaxUInt128, MaxUInt128.Sub64(1), true },
+        UInt128ModInverseTC{ prime128.Sub64(1), prime128, prime128.Sub64(1), true },
+    }
+    for i, tc := range testCases {
+        a := tc.a
+        result, ok := tc.a.ModInverse(tc.m)
+        if tc.expected!=result || tc.expOk!=ok {
+            t.Errorf("Result mismatch: %d: modinverse(%v,%v)->%v,%v!=%v,%v",
+                     i, tc.a, tc.m, tc.expected, tc.expOk, result, ok)
+        }
+        if tc.a!=a {
+            t.Errorf("Argument has been modified: %d: %v!=%v", i, a, tc.a)
+        }
+    }
+}
+
+// compare modular arithmetic with big integers
+func TestUInt128ModularBig(t *testing.T) {
+    rnd := rand.New(rand.NewSource(17))
+    for i:=0; i<2000; i++ {
+        a := UInt128{ rnd.Uint64(), rnd.Uint64() }.Shr(uint(rnd.Intn(128)))
+        b := UInt128{ rnd.Uint64(), rnd.Uint64() }.Shr(uint(rnd.Intn(128)))
+        m := UInt128{ rnd.Uint64(), rnd.Uint64() }.Shr(uint(rnd.Intn(127))).Add64(1)
+        ba, bb, bm := a.BigInt(), b.BigInt(), m.BigInt()
+        expected := new(big.Int).Add(ba, bb)
+        expected.Mod(expected, bm)
+        if result := a.AddMod(b, m); expected.Cmp(result.BigInt())!=0 {
+            t.Errorf("Result mismatch: addmod(%v,%v,%v)->%v!=%v", a, b, m, expected, result)
+        }
+        expected.Sub(ba, bb).Mod(expected, bm)
+        if result := a.SubMod(b, m); expected.Cmp(result.BigInt())!=0 {
+            t.Errorf("Result mismatch: submod(%v,%v,%v)->%v!=%v", a, b, m, expected, result)
+        }
+        expected.Mul(ba, bb).Mod(expected, bm)
+        if result := a.MulMod(b, m); expected.Cmp(result.BigInt())!=0 {
+            t.Errorf("Result mismatch: mulmod(%v,%v,%v)->%v!=%v", a, b, m, expected, result)
+        }
+        expected.Exp(ba, bb, bm)
+        if result := a.PowMod(b, m); expected.Cmp(result.BigInt())!=0 {
+            t.Errorf("Result mismatch: powmod(%v,%v,%v)->%v!=%v", a, b, m, expected, result)
+        }
+        inv := new(big.Int).ModInverse(ba, bm)
+        result, ok := a.ModInverse(m)
+        if m==(UInt128{ 1, 0 }) {
+            // big.Int returns nil if modulus is 1
+            inv = big.NewInt(0)
+        }
+        if (inv!=nil)!=ok || (ok && inv.Cmp(result.BigInt())!=0) {
+            t.Errorf("Result mismatch: modinverse(%v,%v)->%v!=%v,%v", a, m, inv, result, ok)
+        }
+    }
+}
+
+func TestUInt128ModularPanics(t *testing.T) {
+    funcs := []func() {
+        func() { MaxUInt128.AddMod(MaxUInt128, UInt128{}) },
+        func() { MaxUInt128.SubMod(MaxUInt128, UInt128{}) },
+        func() { MaxUInt128.MulMod(MaxUInt128, UInt128{}) },
+        func() { MaxUInt128.PowMod(MaxUInt128, UInt128{}) },
+        func() { MaxUInt128.ModInverse(UInt128{}) },
+    }
+    for i, f := range funcs {
+        if paniced, panicStr := getPanic2(f); !paniced || panicStr!="Divide by zero" {
+            t.Errorf("Result mismatch: %d: panic->%v,%v", i, paniced, panicStr)
+        }
+    }
+}
+
+func BenchmarkUInt128MulMod(b *testing.B) {
+    x := UInt128{ 0x1c9e66c000000000, 0xe1b1e5f90f944d6e }
+    y := UInt128{ 0x8c261ad7409395f0, 0xf7a96e0000000000 }
+    for i := 0; i < b.N; i++ {
+        x.MulMod(y, prime128)
+    }
+}
+
+func BenchmarkUInt128PowMod(b *testing.B) {
+    x := UInt128{ 0x1c9e66c000000000, 0xe1b1e5f90f944d6e }
+    for i := 0; i < b.N; i++ {
+        x.PowMod(prime128.Sub64(2), prime128)
+    }
+}
@@ -0,0 +1,55 @@
+// +build go1.13
+
+/*
+ * numerror_go113_test.go - tests for NumError with errors.Is and errors.As
+ *
+ * goint128 - go int128 library
+ * Copyright (C) 2020  Mateusz Szpakowski
+ *
+ * This library is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU Lesser General Public
+ * License as published by the Free Software Foundation; either
+ * version 2.1 of the License, or (at your option) any later version.
+ *
+ * This library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+ * Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public
+ * License along with this library; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301  USA
+ */
+
+package goint128
+
+import (
+    "errors"
+    "strconv"
+    "testing"
+)
+
+func TestNumErrorUnwrap(t *testing.T) {
+    _, err := ParseUInt128("12x")
+    if !errors.Is(err, strconv.ErrSyntax) || errors.Is(err, strconv.ErrRange) {
+        t.Errorf("Result mismatch: errors.Is(%v,%v)", err, strconv.ErrSyntax)
+    }
+    var numErr *NumError
+    expected := NumError{ "ParseUInt128", "12x", 2, strconv.ErrSyntax }
+    if !errors.As(err, &numErr) || *numErr!=expected {
+        t.Errorf("Result mismatch: errors.As(%v)->%v", err, expected)
+    }
+    _, err = ParseInt128("-170141183460469231731687303715884105729")
+    if !errors.Is(err, strconv.ErrRange) {
+        t.Errorf("Result mismatch: errors.Is(%v,%v)", err, strconv.ErrRange)
+    }
+    _, err = LocaleParseUInt128Bytes("en", []byte("1,2x"))
+    if !errors.As(err, &numErr) || numErr.Func!="LocaleParseUInt128Bytes" ||
+        numErr.Offset!=3 || !errors.Is(err, strconv.ErrSyntax) {
+        t.Errorf("Result mismatch: errors.As(%v)", err)
+    }
+    _, err = ParseUInt256Base("11", 37)
+    if !errors.Is(err, ErrInvalidBase) {
+        t.Errorf("Result mismatch: errors.Is(%v,%v)", err, ErrInvalidBase)
+    }
+}
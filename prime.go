@@ -0,0 +1,328 @@
+/*
+ * prime.go - primality testing and factorization
+ *
+ * goint128 - go int128 library
+ * Copyright (C) 2020  Mateusz Szpakowski
+ *
+ * This library is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU Lesser General Public
+ * License as published by the Free Software Foundation; either
+ * version 2.1 of the License, or (at your option) any later version.
+ *
+ * This library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+ * Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public
+ * License along with this library; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301  USA
+ */
+
+// Package to operate on 128-bit integers
+package goint128
+
+import (
+    "sort"
+)
+
+// primes lesser than 256
+var smallPrimes = []uint64{ 2, 3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37, 41, 43, 47,
+    53, 59, 61, 67, 71, 73, 79, 83, 89, 97, 101, 103, 107, 109, 113, 127, 131, 137,
+    139, 149, 151, 157, 163, 167, 173, 179, 181, 191, 193, 197, 199, 211, 223, 227,
+    229, 233, 239, 241, 251 }
+
+// Miller-Rabin test with first 13 prime bases is deterministic
+// for numbers lesser than this value (3317044064679887385961981)
+var millerRabinLimit = UInt128{ 0x51adc5b22410a5fd, 0x2be69 }
+
+// return smallest prime divisor lesser than 256 or 0 if it does not exist
+func smallPrimeDivisor(a UInt128) uint64 {
+    for i:=0; i<len(smallPrimes); {
+        // join primes into product to use single 128-bit division
+        prod := smallPrimes[i]
+        j := i+1
+        for ; j<len(smallPrimes); j++ {
+            hi, lo := Mul64(prod, smallPrimes[j])
+            if hi!=0 { break }
+            prod = lo
+        }
+        _, r := Div64(a[1]%prod, a[0], prod)
+        for ; i<j; i++ {
+            if r%smallPrimes[i]==0 {
+                return smallPrimes[i]
+            }
+        }
+    }
+    return 0
+}
+
+// return Jacobi symbol (a/n) for odd n
+func jacobi(a, n UInt128) int {
+    a = a.reduce(n)
+    t := 1
+    for !a.IsZero() {
+        z := uint(a.TrailingZeros())
+        a = a.Shr(z)
+        if z&1!=0 && (n[0]&7==3 || n[0]&7==5) {
+            t = -t
+        }
+        // quadratic reciprocity
+        a, n = n, a
+        if a[0]&3==3 && n[0]&3==3 {
+            t = -t
+        }
+        a = a.Mod(n)
+    }
+    if n[0]==1 && n[1]==0 {
+        return t
+    }
+    return 0
+}
+
+// Miller-Rabin test for odd n>2 for base (in Montgomery form).
+// n-1 = d*2^s, one and minusOne are in Montgomery form
+func (mt *Montgomery128) millerRabin(base, d UInt128, s int, one, minusOne UInt128) bool {
+    x := mt.Pow(base, d)
+    if x==one || x==minusOne {
+        return true
+    }
+    for i:=1; i<s; i++ {
+        x = mt.Mul(x, x)
+        if x==minusOne {
+            return true
+        }
+        if x==one {
+            return false
+        }
+    }
+    return false
+}
+
+// extra strong Lucas probable prime test for odd n>2 that is not perfect square
+// (parameters P and Q=1 chosen as in math/big)
+func (mt *Montgomery128) lucas(n UInt128) bool {
+    var p uint64
+    for p = 3; ; p++ {
+        j := jacobi(UInt128{ p*p-4, 0 }, n)
+        if j==-1 {
+            break
+        }
+        if j==0 {
+            // n and p*p-4 have common divisor
+            return n==UInt128{ p+2, 0 }
+        }
+    }
+    // n+1 = s*2^r, s is odd
+    s := n.Add64(1)
+    r := uint(s.TrailingZeros())
+    s = s.Shr(r)
+    mp := mt.ToMont(UInt128{ p, 0 })
+    two := mt.ToMont(UInt128{ 2, 0 })
+    // compute V(s) and V(s+1): V(2k) = V(k)^2-2, V(2k+1) = V(k)*V(k+1)-P
+    vk, vk1 := two, mp
+    for i := s.Len()-1; i>=0; i-- {
+        if s.Bit(uint(i))!=0 {
+            vk = mt.Mul(vk, vk1).SubMod(mp, n)
+            vk1 = mt.Mul(vk1, vk1).SubMod(two, n)
+        } else {
+            vk1 = mt.Mul(vk, vk1).SubMod(mp, n)
+            vk = mt.Mul(vk, vk).SubMod(two, n)
+        }
+    }
+    if vk==two || vk==n.Sub(two) {
+        // U(s) = 0 if P*V(s) = 2*V(s+1)
+        if mt.Mul(vk, mp)==mt.Mul(vk1, two) {
+            return true
+        }
+    }
+    for t:=uint(0); t+1<r; t++ {
+        if vk.IsZero() {
+            return true
+        }
+        vk = mt.Mul(vk, vk).SubMod(two, n)
+    }
+    return false
+}
+
+// check primality: trial division and Miller-Rabin test with first rounds prime
+// bases (after them pseudo-random bases). if bpsw is true then Lucas test
+// is applied too (Baillie-PSW test). returned value is proven only for
+// numbers lesser than 3317044064679887385961981 and at least 13 rounds
+func (a UInt128) isPrime(rounds int, bpsw bool) bool {
+    if a[1]==0 && a[0]<256 {
+        for _, p := range smallPrimes {
+            if a[0]==p {
+                return true
+            }
+        }
+        return false
+    }
+    if smallPrimeDivisor(a)!=0 {
+        return false
+    }
+    if a[1]==0 && a[0]<256*256 {
+        return true
+    }
+    mt, _ := NewMontgomery128(a)
+    one := mt.ToMont(UInt128{ 1, 0 })
+    minusOne := mt.ToMont(a.Sub64(1))
+    d := a.Sub64(1)
+    s := d.TrailingZeros()
+    d = d.Shr(uint(s))
+    seed := a[0] ^ a[1]
+    for i:=0; i<rounds; i++ {
+        var base UInt128
+        if i<len(smallPrimes) {
+            base = UInt128{ smallPrimes[i], 0 }
+        } else {
+            // pseudo-random base in range 2..a-2 (splitmix64)
+            seed += 0x9e3779b97f4a7c15
+            z := (seed ^ (seed>>30)) * 0xbf58476d1ce4e5b9
+            z = (z ^ (z>>27)) * 0x94d049bb133111eb
+            base = UInt128{ z ^ (z>>31), z*0x9e3779b97f4a7c15 }.Mod(a.Sub64(3)).Add64(2)
+        }
+        if !mt.millerRabin(mt.ToMont(base), d, s, one, minusOne) {
+            return false
+        }
+    }
+    if bpsw {
+        if sq := a.Sqrt(); sq.Mul(sq)==a {
+            return false
+        }
+        if !mt.millerRabin(mt.ToMont(UInt128{ 2, 0 }), d, s, one, minusOne) {
+            return false
+        }
+        return mt.lucas(a)
+    }
+    return true
+}
+
+// return true if integer is probably prime. it applies n rounds of
+// Miller-Rabin test with deterministic bases (first n primes) and
+// Baillie-PSW test. as in math/big it is 100% accurate for numbers
+// lesser than 2^64, for greater numbers probability of false positive
+// is at most 1/4^n (and no composite passing Baillie-PSW test is known)
+func (a UInt128) IsProbablePrime(n int) bool {
+    return a.isPrime(n, true)
+}
+
+// return true if integer is prime. result is proven (deterministic
+// Miller-Rabin test) for numbers lesser than 3317044064679887385961981,
+// greater numbers are checked by IsProbablePrime(13)
+func (a UInt128) IsPrime() bool {
+    if a.Cmp(millerRabinLimit)<0 {
+        return a.isPrime(13, false)
+    }
+    return a.isPrime(13, true)
+}
+
+// return smallest prime greater than integer or ErrOverflow if it
+// does not fit in 128 bits
+func (a UInt128) NextPrime() (UInt128, error) {
+    if a[1]==0 && a[0]<2 {
+        return UInt128{ 2, 0 }, nil
+    }
+    // check odd numbers until addition overflows
+    for b := a.Add64(1 + a[0]&1); b.Cmp(a)>0; b = b.Add64(2) {
+        if b.IsPrime() {
+            return b, nil
+        }
+    }
+    return UInt128{}, ErrOverflow
+}
+
+// find non-trivial divisor of odd composite n by Pollard-rho method
+// (Brent's variant). return n if method failed for constant c
+func (mt *Montgomery128) pollardRho(n, c UInt128) UInt128 {
+    const batch = 128
+    y := mt.ToMont(UInt128{ 2, 0 })
+    q := mt.ToMont(UInt128{ 1, 0 })
+    var x, ys UInt128
+    g := UInt128{ 1, 0 }
+    one := g
+    for r := 1; g==one; r <<= 1 {
+        x = y
+        for i:=0; i<r; i++ {
+            y = mt.Mul(y, y).AddMod(c, n)
+        }
+        for k:=0; k<r && g==one; k += batch {
+            ys = y
+            for i:=0; i<batch && i<r-k; i++ {
+                y = mt.Mul(y, y).AddMod(c, n)
+                // product of differences (factor R does not change GCD)
+                if x.Cmp(y)>0 {
+                    q = mt.Mul(q, x.Sub(y))
+                } else {
+                    q = mt.Mul(q, y.Sub(x))
+                }
+            }
+            g = GCD(q, n)
+        }
+    }
+    if g==n {
+        // batch product is zero modulo n: find divisor step by step
+        for g = one; g==one; {
+            ys = mt.Mul(ys, ys).AddMod(c, n)
+            if x.Cmp(ys)>0 {
+                g = GCD(x.Sub(ys), n)
+            } else {
+                g = GCD(ys.Sub(x), n)
+            }
+        }
+    }
+    return g
+}
+
+// append prime factors of odd n that has not divisors lesser than 256
+func appendPrimeFactors(factors []UInt128, n UInt128) []UInt128 {
+    if n[0]==1 && n[1]==0 {
+        return factors
+    }
+    if n.IsPrime() {
+        return append(factors, n)
+    }
+    if sq := n.Sqrt(); sq.Mul(sq)==n {
+        factors = appendPrimeFactors(factors, sq)
+        return appendPrimeFactors(factors, sq)
+    }
+    mt, _ := NewMontgomery128(n)
+    d := n
+    for c := uint64(1); d==n; c++ {
+        d = mt.pollardRho(n, UInt128{ c, 0 })
+    }
+    factors = appendPrimeFactors(factors, d)
+    return appendPrimeFactors(factors, n.Div(d))
+}
+
+// return prime factors of integer in ascending order (with repetitions).
+// factors of 0 and 1 are empty. it uses trial division and Pollard-rho method,
+// thus it can be slow if integer has two or more large (greater than 2^50) factors
+func (a UInt128) Factor() []UInt128 {
+    var factors []UInt128
+    if a[1]==0 && a[0]<2 {
+        return factors
+    }
+    for _, p := range smallPrimes {
+        for {
+            q, r := a.DivMod(UInt128{ p, 0 })
+            if r[0]!=0 {
+                break
+            }
+            factors = append(factors, UInt128{ p, 0 })
+            a = q
+        }
+    }
+    if a[1]==0 && a[0]<256*256 {
+        // all divisors lesser than 256 are removed: rest is prime
+        if a[0]!=1 {
+            factors = append(factors, a)
+        }
+        return factors
+    }
+    factors = appendPrimeFactors(factors, a)
+    sort.Slice(factors, func(i, j int) bool {
+        return factors[i].Cmp(factors[j])<0
+    })
+    return factors
+}
@@ -0,0 +1,229 @@
+/*
+ * prime_test.go - tests for primality testing and factorization
+ *
+ * goint128 - go int128 library
+ * Copyright (C) 2020  Mateusz Szpakowski
+ *
+ * This library is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU Lesser General Public
+ * License as published by the Free Software Foundation; either
+ * version 2.1 of the License, or (at your option) any later version.
+ *
+ * This library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+ * Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public
+ * License along with this library; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301  USA
+ */
+
+package goint128
+
+import (
+    "math/rand"
+    "reflect"
+    "testing"
+)
+
+type UInt128IsPrimeTC struct {
+    a UInt128
+    expected bool
+}
+
+func TestUInt128IsPrime(t *testing.T) {
+    testCases := []UInt128IsPrimeTC {
+        UInt128IsPrimeTC{ UInt128{ 0, 0 }, false },
+        UInt128IsPrimeTC{ UInt128{ 1, 0 }, false },
+        UInt128IsPrimeTC{ UInt128{ 2, 0 }, true },
+        UInt128IsPrimeTC{ UInt128{ 251, 0 }, true },
+        UInt128IsPrimeTC{ UInt128{ 253, 0 }, false },
+        UInt128IsPrimeTC{ UInt128{ 257, 0 }, true },
+        UInt128IsPrimeTC{ UInt128{ 65521, 0 }, true },
+        UInt128IsPrimeTC{ UInt128{ 65537, 0 }, true },
+        // Carmichael numbers
+        UInt128IsPrimeTC{ UInt128{ 561, 0 }, false },
+        UInt128IsPrimeTC{ UInt128{ 825265, 0 }, false },
+        // strong pseudoprimes to bases 2, 3, 5, 7
+        UInt128IsPrimeTC{ UInt128{ 3215031751, 0 }, false },
+        // strong pseudoprime to bases 2..23
+        UInt128IsPrimeTC{ UInt128{ 3825123056546413051, 0 }, false },
+        // strong pseudoprime to bases 2..37 (318665857834031151167461)
+        UInt128IsPrimeTC{ UInt128{ 0xe92817f9fc85b7e5, 0x437a }, false },
+        // strong pseudoprime to bases 2..41 (3317044064679887385961981)
+        UInt128IsPrimeTC{ millerRabinLimit, false },
+        UInt128IsPrimeTC{ UInt128{ 0x1fffffffffffffff, 0 }, true }, // 2^61-1
+        UInt128IsPrimeTC{ UInt128{ 0xffffffffffffffc5, 0 }, true }, // 2^64-59
+        UInt128IsPrimeTC{ UInt128{ 0xd, 1 }, true }, // 2^64+13
+        UInt128IsPrimeTC{ UInt128{ 0xffffffffffffffff, 0x1ffffff }, true }, // 2^89-1
+        UInt128IsPrimeTC{ UInt128{ 0xffffffffffffffff, 0x7ffffffffff }, true }, // 2^107-1
+        UInt128IsPrimeTC{ prime127, true },
+        UInt128IsPrimeTC{ prime128, true },
+        UInt128IsPrimeTC{ MaxUInt128, false },
+        UInt128IsPrimeTC{ UInt128{ 0xffffffffffffffc5, 0 }.Mul64(0x1fffffffffffffff), false },
+        UInt128IsPrimeTC{ UInt128{ 0x1fffffffffffffff, 0 }.Mul64(0x1fffffffffffffff), false },
+    }
+    for i, tc := range testCases {
+        a := tc.a
+        result := tc.a.IsPrime()
+        if tc.expected!=result {
+            t.Errorf("Result mismatch: %d: isprime(%v)->%v!=%v", i, tc.a, tc.expected, result)
+        }
+        result = tc.a.IsProbablePrime(1)
+        if tc.expected!=result {
+            t.Errorf("Result mismatch: %d: isprobableprime(%v)->%v!=%v",
+                     i, tc.a, tc.expected, result)
+        }
+        if tc.a!=a {
+            t.Errorf("Argument has been modified: %d: %v!=%v", i, a, tc.a)
+        }
+    }
+    // strong pseudoprimes pass Miller-Rabin test with 12 and 13 first prime bases
+    if !(UInt128{ 0xe92817f9fc85b7e5, 0x437a }).isPrime(12, false) ||
+        !millerRabinLimit.isPrime(13, false) {
+        t.Errorf("Result mismatch: Miller-Rabin test does not pass")
+    }
+}
+
+// compare primality testing with big integers
+func TestUInt128IsPrimeBig(t *testing.T) {
+    rnd := rand.New(rand.NewSource(21))
+    for i:=0; i<3000; i++ {
+        a := UInt128{ rnd.Uint64(), rnd.Uint64() }.Shr(uint(rnd.Intn(128)))
+        a[0] |= 1
+        if i&1==0 {
+            // product of two numbers
+            a = UInt128{ rnd.Uint64()|1, 0 }.Shr(uint(rnd.Intn(64))).
+                Mul64((rnd.Uint64()|1)>>uint(rnd.Intn(64)))
+        }
+        expected := a.BigInt().ProbablyPrime(20)
+        if result := a.IsPrime(); expected!=result {
+            t.Errorf("Result mismatch: isprime(%v)->%v!=%v", a, expected, result)
+        }
+        if result := a.IsProbablePrime(20); expected!=result {
+            t.Errorf("Result mismatch: isprobableprime(%v)->%v!=%v", a, expected, result)
+        }
+    }
+}
+
+type UInt128NextPrimeTC struct {
+    a UInt128
+    expected UInt128
+    expError error
+}
+
+func TestUInt128NextPrime(t *testing.T) {
+    testCases := []UInt128NextPrimeTC {
+        UInt128NextPrimeTC{ UInt128{ 0, 0 }, UInt128{ 2, 0 }, nil },
+        UInt128NextPrimeTC{ UInt128{ 2, 0 }, UInt128{ 3, 0 }, nil },
+        UInt128NextPrimeTC{ UInt128{ 3, 0 }, UInt128{ 5, 0 }, nil },
+        UInt128NextPrimeTC{ UInt128{ 24, 0 }, UInt128{ 29, 0 }, nil },
+        UInt128NextPrimeTC{ UInt128{ 0xffffffffffffffc5, 0 }, UInt128{ 0xd, 1 }, nil },
+        UInt128NextPrimeTC{ UInt128{ 0, 1 }, UInt128{ 0xd, 1 }, nil },
+        UInt128NextPrimeTC{ UInt128{ 0, 0x1000000000 }, UInt128{ 0x115, 0x1000000000 }, nil },
+        UInt128NextPrimeTC{ UInt128{ 0, 0x8000000000000000 },
+            UInt128{ 0x1d, 0x8000000000000000 }, nil },
+        UInt128NextPrimeTC{ UInt128{ 0x4674edea40000000, 0xc9f2c9cd0 },
+            UInt128{ 0x4674edea40000039, 0xc9f2c9cd0 }, nil }, // 10^30
+        UInt128NextPrimeTC{ prime128.Sub64(1), prime128, nil },
+        UInt128NextPrimeTC{ prime128, UInt128{}, ErrOverflow },
+        UInt128NextPrimeTC{ MaxUInt128, UInt128{}, ErrOverflow },
+    }
+    for i, tc := range testCases {
+        a := tc.a
+        result, err := tc.a.NextPrime()
+        if tc.expected!=result || tc.expError!=err {
+            t.Errorf("Result mismatch: %d: nextprime(%v)->%v,%v!=%v,%v",
+                     i, tc.a, tc.expected, tc.expError, result, err)
+        }
+        if tc.a!=a {
+            t.Errorf("Argument has been modified: %d: %v!=%v", i, a, tc.a)
+        }
+    }
+}
+
+type UInt128FactorTC struct {
+    a UInt128
+    expected []UInt128
+}
+
+func TestUInt128Factor(t *testing.T) {
+    testCases := []UInt128FactorTC {
+        UInt128FactorTC{ UInt128{ 0, 0 }, nil },
+        UInt128FactorTC{ UInt128{ 1, 0 }, nil },
+        UInt128FactorTC{ UInt128{ 2, 0 }, []UInt128{ UInt128{ 2, 0 } } },
+        UInt128FactorTC{ UInt128{ 360, 0 }, []UInt128{ UInt128{ 2, 0 }, UInt128{ 2, 0 },
+            UInt128{ 2, 0 }, UInt128{ 3, 0 }, UInt128{ 3, 0 }, UInt128{ 5, 0 } } },
+        UInt128FactorTC{ UInt128{ 65537*257, 0 }, []UInt128{ UInt128{ 257, 0 },
+            UInt128{ 65537, 0 } } },
+        UInt128FactorTC{ MaxUInt128, []UInt128{ UInt128{ 3, 0 }, UInt128{ 5, 0 },
+            UInt128{ 17, 0 }, UInt128{ 257, 0 }, UInt128{ 641, 0 }, UInt128{ 65537, 0 },
+            UInt128{ 274177, 0 }, UInt128{ 6700417, 0 }, UInt128{ 67280421310721, 0 } } },
+        UInt128FactorTC{ prime127, []UInt128{ prime127 } },
+        UInt128FactorTC{ UInt128{ 0x1fffffffffffffff, 0 }.Mul64(0x1fffffffffffffff),
+            []UInt128{ UInt128{ 0x1fffffffffffffff, 0 }, UInt128{ 0x1fffffffffffffff, 0 } } },
+        UInt128FactorTC{ UInt128{ 0x7fffffff, 0 }.Mul64(0x1fffffffffffffff).Mul64(3),
+            []UInt128{ UInt128{ 3, 0 }, UInt128{ 0x7fffffff, 0 },
+                UInt128{ 0x1fffffffffffffff, 0 } } },
+        UInt128FactorTC{ UInt128{ 1000003, 0 }.Mul64(1000003).Mul64(999983).Mul64(4294967291),
+            []UInt128{ UInt128{ 999983, 0 }, UInt128{ 1000003, 0 }, UInt128{ 1000003, 0 },
+                UInt128{ 4294967291, 0 } } },
+    }
+    for i, tc := range testCases {
+        a := tc.a
+        result := tc.a.Factor()
+        if len(tc.expected)!=len(result) ||
+            (len(result)!=0 && !reflect.DeepEqual(tc.expected, result)) {
+            t.Errorf("Result mismatch: %d: factor(%v)->%v!=%v",
+                     i, tc.a, tc.expected, result)
+        }
+        if tc.a!=a {
+            t.Errorf("Argument has been modified: %d: %v!=%v", i, a, tc.a)
+        }
+    }
+}
+
+// check factorization of random numbers: factors must be prime, sorted and
+// their product must be equal to number
+func TestUInt128FactorRandom(t *testing.T) {
+    rnd := rand.New(rand.NewSource(21))
+    for i:=0; i<300; i++ {
+        // product of numbers lesser than 2^40 (Pollard-rho finds them quickly)
+        a := UInt128{ 1, 0 }
+        for a.Len()<88 {
+            a = a.Mul64(rnd.Uint64()>>uint(24+rnd.Intn(40)) | 1)
+        }
+        factors := a.Factor()
+        prod := UInt128{ 1, 0 }
+        for j, f := range factors {
+            if !f.IsPrime() || (j!=0 && factors[j-1].Cmp(f)>0) {
+                t.Errorf("Result mismatch: factor(%v)->%v", a, factors)
+            }
+            prod = prod.Mul(f)
+        }
+        if prod!=a {
+            t.Errorf("Result mismatch: factor(%v)->%v", a, factors)
+        }
+    }
+}
+
+func BenchmarkUInt128IsPrime(b *testing.B) {
+    for i := 0; i < b.N; i++ {
+        prime128.IsPrime()
+    }
+}
+
+func BenchmarkUInt128NextPrime(b *testing.B) {
+    a := UInt128{ 0x4674edea40000000, 0xc9f2c9cd0 }
+    for i := 0; i < b.N; i++ {
+        a.NextPrime()
+    }
+}
+
+func BenchmarkUInt128Factor(b *testing.B) {
+    a := UInt128{ 1000003, 0 }.Mul64(1000003).Mul64(999983).Mul64(4294967291)
+    for i := 0; i < b.N; i++ {
+        a.Factor()
+    }
+}
@@ -0,0 +1,196 @@
+/*
+ * reduction.go - Montgomery and reciprocal modular reduction
+ *
+ * goint128 - go int128 library
+ * Copyright (C) 2020  Mateusz Szpakowski
+ *
+ * This library is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU Lesser General Public
+ * License as published by the Free Software Foundation; either
+ * version 2.1 of the License, or (at your option) any later version.
+ *
+ * This library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+ * Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public
+ * License along with this library; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301  USA
+ */
+
+// Package to operate on 128-bit integers
+package goint128
+
+// Montgomery multiplication context for odd modulus (R = 2^128).
+// values in Montgomery form (a*R mod m) are multiplied by Mul and Pow
+type Montgomery128 struct {
+    m UInt128 // modulus
+    mInv UInt128 // -m^-1 mod R
+    r UInt128 // R mod m
+    r2 UInt128 // R^2 mod m
+}
+
+// create Montgomery context for odd modulus. return ErrEvenModulus if
+// modulus is even
+func NewMontgomery128(m UInt128) (*Montgomery128, error) {
+    if m[0]&1==0 {
+        return nil, ErrEvenModulus
+    }
+    // Newton iteration for inverse modulo 2^128: x = x*(2-m*x)
+    // (m*m = 1 mod 8 for odd m and every step doubles number of correct bits)
+    x := m
+    for i:=0; i<6; i++ {
+        x = x.Mul(UInt128{ 2, 0 }.Sub(m.Mul(x)))
+    }
+    // R mod m = (R-1) mod m + 1 (mod m)
+    r := maxUInt128.Mod(m).Add64(1)
+    if r==m {
+        r = UInt128{}
+    }
+    _, r2 := UInt128DivFull(r, UInt128{}, m)
+    return &Montgomery128{ m, UInt128{}.Sub(x), r, r2 }, nil
+}
+
+// return modulus
+func (mt *Montgomery128) Modulus() UInt128 {
+    return mt.m
+}
+
+// Montgomery reduction: return (hi,lo)*R^-1 mod m. (hi,lo) must be lesser than m*R
+func (mt *Montgomery128) reduce(hi, lo UInt128) UInt128 {
+    u := lo.Mul(mt.mInv)
+    uhi, ulo := u.MulFull(mt.m)
+    // lo+ulo = 0 mod R, carry is set if lo is not zero
+    _, carry := lo.AddC(ulo, 0)
+    t, carry := hi.AddC(uhi, carry)
+    if carry!=0 || t.Cmp(mt.m)>=0 {
+        t = t.Sub(mt.m)
+    }
+    return t
+}
+
+// convert integer to Montgomery form (a*R mod m)
+func (mt *Montgomery128) ToMont(a UInt128) UInt128 {
+    return mt.Mul(a.reduce(mt.m), mt.r2)
+}
+
+// convert integer from Montgomery form
+func (mt *Montgomery128) FromMont(a UInt128) UInt128 {
+    return mt.reduce(UInt128{}, a)
+}
+
+// multiply values in Montgomery form (must be lesser than modulus)
+// and return product in Montgomery form
+func (mt *Montgomery128) Mul(a, b UInt128) UInt128 {
+    hi, lo := a.MulFull(b)
+    return mt.reduce(hi, lo)
+}
+
+// raise value in Montgomery form (must be lesser than modulus) to power exp
+// and return result in Montgomery form
+func (mt *Montgomery128) Pow(a, exp UInt128) UInt128 {
+    c := mt.r
+    for i := exp.Len()-1; i>=0; i-- {
+        c = mt.Mul(c, c)
+        if exp.Bit(uint(i))!=0 {
+            c = mt.Mul(c, a)
+        }
+    }
+    return c
+}
+
+// divide 256-bit (u1,u0) unsigned integer by normalized 128-bit divisor d
+// (highest bit set) using its reciprocal v = (2^256-1)/d - 2^128 and return
+// quotient and remainder. u1 must be lesser than d (Moller-Granlund algorithm)
+func divFull2by1(u1, u0, d, v UInt128) (UInt128, UInt128) {
+    q1, q0 := v.MulFull(u1)
+    var carry uint64
+    q0, carry = q0.AddC(u0, 0)
+    q1, _ = q1.AddC(u1, carry)
+    q1 = q1.Add64(1)
+    r := u0.Sub(q1.Mul(d))
+    if r.Cmp(q0)>0 {
+        q1 = q1.Sub64(1)
+        r = r.Add(d)
+    }
+    if r.Cmp(d)>=0 {
+        q1 = q1.Add64(1)
+        r = r.Sub(d)
+    }
+    return q1, r
+}
+
+// return reciprocal of normalized 128-bit divisor (2^256-1)/d - 2^128
+func reciprocal128(d UInt128) UInt128 {
+    v, _ := UInt128DivFull(d.Not(), maxUInt128, d)
+    return v
+}
+
+// reciprocal reduction context for any non-zero modulus. it uses reciprocal
+// of normalized modulus to reduce products by multiplications only
+// (Moller-Granlund division). it is used instead of Barrett reduction, because
+// Barrett factor floor(2^256/m) needs 129 bits and its products need 256-bit
+// multiplications, while reciprocal of normalized modulus fits in 128 bits
+// and needs single 128-bit full multiplication and at most two corrections
+type Reciprocal128 struct {
+    m UInt128 // modulus
+    d UInt128 // normalized modulus (shifted left to set highest bit)
+    v UInt128 // reciprocal of normalized modulus
+    shift uint // shift of normalized modulus
+}
+
+// create reciprocal reduction context for modulus. return ErrDivideByZero if modulus is zero
+func NewReciprocal128(m UInt128) (*Reciprocal128, error) {
+    if m[0]==0 && m[1]==0 {
+        return nil, ErrDivideByZero
+    }
+    shift := uint(m.LeadingZeros())
+    d := m.Shl(shift)
+    return &Reciprocal128{ m, d, reciprocal128(d), shift }, nil
+}
+
+// return modulus
+func (rc *Reciprocal128) Modulus() UInt128 {
+    return rc.m
+}
+
+// return (hi,lo) mod m. hi must be lesser than modulus
+func (rc *Reciprocal128) Reduce(hi, lo UInt128) UInt128 {
+    // normalize dividend
+    u1, u0 := hi.Shl(rc.shift), lo.Shl(rc.shift)
+    if rc.shift!=0 {
+        u1 = u1.Or(lo.Shr(128-rc.shift))
+    }
+    _, r := divFull2by1(u1, u0, rc.d, rc.v)
+    return r.Shr(rc.shift)
+}
+
+// return a mod m
+func (rc *Reciprocal128) Mod(a UInt128) UInt128 {
+    if a.Cmp(rc.m)<0 {
+        return a
+    }
+    return rc.Reduce(UInt128{}, a)
+}
+
+// return (a*b) mod m
+func (rc *Reciprocal128) Mul(a, b UInt128) UInt128 {
+    hi, lo := rc.Mod(a).MulFull(rc.Mod(b))
+    return rc.Reduce(hi, lo)
+}
+
+// return (a^exp) mod m
+func (rc *Reciprocal128) Pow(a, exp UInt128) UInt128 {
+    a = rc.Mod(a)
+    c := rc.Mod(UInt128{ 1, 0 })
+    for i := exp.Len()-1; i>=0; i-- {
+        hi, lo := c.MulFull(c)
+        c = rc.Reduce(hi, lo)
+        if exp.Bit(uint(i))!=0 {
+            hi, lo = c.MulFull(a)
+            c = rc.Reduce(hi, lo)
+        }
+    }
+    return c
+}
@@ -0,0 +1,202 @@
+/*
+ * reduction_test.go - tests for Montgomery and reciprocal modular reduction
+ *
+ * goint128 - go int128 library
+ * Copyright (C) 2020  Mateusz Szpakowski
+ *
+ * This library is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU Lesser General Public
+ * License as published by the Free Software Foundation; either
+ * version 2.1 of the License, or (at your option) any later version.
+ *
+ * This library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+ * Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public
+ * License along with this library; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301  USA
+ */
+
+package goint128
+
+import (
+    "math/big"
+    "math/rand"
+    "testing"
+)
+
+func TestNewMontgomery128(t *testing.T) {
+    for i, m := range []UInt128{ UInt128{ 1, 0 }, UInt128{ 3, 0 }, prime127, prime128,
+            MaxUInt128, UInt128{ 0xffffffffffffffff, 1 } } {
+        mt, err := NewMontgomery128(m)
+        if err!=nil {
+            t.Errorf("Result mismatch: %d: NewMontgomery128(%v)->%v", i, m, err)
+            continue
+        }
+        // m*(-m^-1) = -1 mod 2^128
+        if mt.Modulus()!=m || m.Mul(mt.mInv)!=MaxUInt128 {
+            t.Errorf("Result mismatch: %d: NewMontgomery128(%v)->%v,%v",
+                     i, m, mt.Modulus(), mt.mInv)
+        }
+    }
+    for i, m := range []UInt128{ UInt128{}, UInt128{ 2, 0 }, UInt128{ 0, 1 } } {
+        if _, err := NewMontgomery128(m); err!=ErrEvenModulus {
+            t.Errorf("Result mismatch: %d: NewMontgomery128(%v)->%v", i, m, err)
+        }
+    }
+}
+
+func TestNewReciprocal128(t *testing.T) {
+    if _, err := NewReciprocal128(UInt128{}); err!=ErrDivideByZero {
+        t.Errorf("Result mismatch: NewReciprocal128(0)->%v", err)
+    }
+    rc, err := NewReciprocal128(prime127)
+    if err!=nil || rc.Modulus()!=prime127 {
+        t.Errorf("Result mismatch: NewReciprocal128(%v)->%v", prime127, err)
+    }
+}
+
+func TestMontgomery128(t *testing.T) {
+    testCases := []UInt128ModTC {
+        UInt128ModTC{ UInt128{ 5, 0 }, UInt128{ 7, 0 }, UInt128{ 11, 0 }, UInt128{ 2, 0 } },
+        UInt128ModTC{ prime128.Sub64(1), prime128.Sub64(1), prime128, UInt128{ 1, 0 } },
+        UInt128ModTC{ MaxUInt128, MaxUInt128, prime128, UInt128{ 158*158, 0 } },
+        UInt128ModTC{ UInt128{ 0, 1 }, UInt128{ 0, 1 }, prime127, UInt128{ 2, 0 } },
+        UInt128ModTC{ MaxUInt128, UInt128{ 3, 0 }, MaxUInt128, UInt128{ 0, 0 } },
+        UInt128ModTC{ UInt128{ 7, 0 }, UInt128{ 9, 0 }, UInt128{ 1, 0 }, UInt128{ 0, 0 } },
+    }
+    for i, tc := range testCases {
+        a, b := tc.a, tc.b
+        mt, _ := NewMontgomery128(tc.m)
+        result := mt.FromMont(mt.Mul(mt.ToMont(tc.a), mt.ToMont(tc.b)))
+        if tc.expected!=result {
+            t.Errorf("Result mismatch: %d: montmul(%v,%v,%v)->%v!=%v",
+                     i, tc.a, tc.b, tc.m, tc.expected, result)
+        }
+        if tc.a!=a || tc.b!=b {
+            t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
+                     i, a, b, tc.a, tc.b)
+        }
+    }
+}
+
+func TestReciprocal128(t *testing.T) {
+    testCases := []UInt128ModTC {
+        UInt128ModTC{ UInt128{ 5, 0 }, UInt128{ 7, 0 }, UInt128{ 10, 0 }, UInt128{ 5, 0 } },
+        UInt128ModTC{ prime128.Sub64(1), prime128.Sub64(1), prime128, UInt128{ 1, 0 } },
+        UInt128ModTC{ MaxUInt128, MaxUInt128, prime128, UInt128{ 158*158, 0 } },
+        UInt128ModTC{ UInt128{ 0, 1 }, UInt128{ 0, 1 }, prime127, UInt128{ 2, 0 } },
+        UInt128ModTC{ MaxUInt128, MaxUInt128, UInt128{ 0, 1 }, UInt128{ 1, 0 } },
+        UInt128ModTC{ UInt128{ 7, 0 }, UInt128{ 9, 0 }, UInt128{ 1, 0 }, UInt128{ 0, 0 } },
+    }
+    for i, tc := range testCases {
+        a, b := tc.a, tc.b
+        rc, _ := NewReciprocal128(tc.m)
+        result := rc.Mul(tc.a, tc.b)
+        if tc.expected!=result {
+            t.Errorf("Result mismatch: %d: reciprocalmul(%v,%v,%v)->%v!=%v",
+                     i, tc.a, tc.b, tc.m, tc.expected, result)
+        }
+        if tc.a!=a || tc.b!=b {
+            t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
+                     i, a, b, tc.a, tc.b)
+        }
+    }
+}
+
+// compare Montgomery and reciprocal reduction with big integers
+func TestReductionBig(t *testing.T) {
+    rnd := rand.New(rand.NewSource(18))
+    for i:=0; i<2000; i++ {
+        a := UInt128{ rnd.Uint64(), rnd.Uint64() }.Shr(uint(rnd.Intn(128)))
+        b := UInt128{ rnd.Uint64(), rnd.Uint64() }.Shr(uint(rnd.Intn(128)))
+        m := UInt128{ rnd.Uint64(), rnd.Uint64() }.Shr(uint(rnd.Intn(127))).Add64(1)
+        ba, bb, bm := a.BigInt(), b.BigInt(), m.BigInt()
+        rc, _ := NewReciprocal128(m)
+        expected := new(big.Int).Mod(ba, bm)
+        if result := rc.Mod(a); expected.Cmp(result.BigInt())!=0 {
+            t.Errorf("Result mismatch: reciprocalmod(%v,%v)->%v!=%v", a, m, expected, result)
+        }
+        expected.Mul(ba, bb).Mod(expected, bm)
+        if result := rc.Mul(a, b); expected.Cmp(result.BigInt())!=0 {
+            t.Errorf("Result mismatch: reciprocalmul(%v,%v,%v)->%v!=%v",
+                     a, b, m, expected, result)
+        }
+        expPow := new(big.Int).Exp(ba, bb, bm)
+        if result := rc.Pow(a, b); expPow.Cmp(result.BigInt())!=0 {
+            t.Errorf("Result mismatch: reciprocalpow(%v,%v,%v)->%v!=%v",
+                     a, b, m, expPow, result)
+        }
+        m[0] |= 1
+        bm = m.BigInt()
+        mt, _ := NewMontgomery128(m)
+        expected.Mul(ba, bb).Mod(expected, bm)
+        if result := mt.FromMont(mt.Mul(mt.ToMont(a), mt.ToMont(b)));
+            expected.Cmp(result.BigInt())!=0 {
+            t.Errorf("Result mismatch: montmul(%v,%v,%v)->%v!=%v",
+                     a, b, m, expected, result)
+        }
+        expPow.Exp(ba, bb, bm)
+        if result := mt.FromMont(mt.Pow(mt.ToMont(a), b)); expPow.Cmp(result.BigInt())!=0 {
+            t.Errorf("Result mismatch: montpow(%v,%v,%v)->%v!=%v",
+                     a, b, m, expPow, result)
+        }
+    }
+}
+
+func BenchmarkMontgomery128Mul(b *testing.B) {
+    mt, _ := NewMontgomery128(prime128)
+    x := mt.ToMont(UInt128{ 0x1c9e66c000000000, 0xe1b1e5f90f944d6e })
+    y := mt.ToMont(UInt128{ 0x8c261ad7409395f0, 0xf7a96e0000000000 })
+    for i := 0; i < b.N; i++ {
+        mt.Mul(x, y)
+    }
+}
+
+func BenchmarkReciprocal128Mul(b *testing.B) {
+    rc, _ := NewReciprocal128(prime128)
+    x := UInt128{ 0x1c9e66c000000000, 0xe1b1e5f90f944d6e }
+    y := UInt128{ 0x8c261ad7409395f0, 0xf7a96e0000000000 }
+    for i := 0; i < b.N; i++ {
+        rc.Mul(x, y)
+    }
+}
+
+func BenchmarkBigIntMulMod(b *testing.B) {
+    x := UInt128{ 0x1c9e66c000000000, 0xe1b1e5f90f944d6e }.BigInt()
+    y := UInt128{ 0x8c261ad7409395f0, 0xf7a96e0000000000 }.BigInt()
+    m := prime128.BigInt()
+    z := new(big.Int)
+    for i := 0; i < b.N; i++ {
+        z.Mul(x, y)
+        z.Mod(z, m)
+    }
+}
+
+func BenchmarkMontgomery128Pow(b *testing.B) {
+    mt, _ := NewMontgomery128(prime128)
+    x := mt.ToMont(UInt128{ 0x1c9e66c000000000, 0xe1b1e5f90f944d6e })
+    for i := 0; i < b.N; i++ {
+        mt.Pow(x, prime128.Sub64(2))
+    }
+}
+
+func BenchmarkReciprocal128Pow(b *testing.B) {
+    rc, _ := NewReciprocal128(prime128)
+    x := UInt128{ 0x1c9e66c000000000, 0xe1b1e5f90f944d6e }
+    for i := 0; i < b.N; i++ {
+        rc.Pow(x, prime128.Sub64(2))
+    }
+}
+
+func BenchmarkBigIntExp(b *testing.B) {
+    x := UInt128{ 0x1c9e66c000000000, 0xe1b1e5f90f944d6e }.BigInt()
+    e := prime128.Sub64(2).BigInt()
+    m := prime128.BigInt()
+    z := new(big.Int)
+    for i := 0; i < b.N; i++ {
+        z.Exp(x, e, m)
+    }
+}
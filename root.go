@@ -0,0 +1,120 @@
+/*
+ * root.go - integer roots of 128-bit integers
+ *
+ * goint128 - go int128 library
+ * Copyright (C) 2020  Mateusz Szpakowski
+ *
+ * This library is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU Lesser General Public
+ * License as published by the Free Software Foundation; either
+ * version 2.1 of the License, or (at your option) any later version.
+ *
+ * This library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+ * Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public
+ * License along with this library; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301  USA
+ */
+
+// Package to operate on 128-bit integers
+package goint128
+
+import (
+    "math"
+)
+
+// return floor of square root of 128-bit unsigned integer
+func (a UInt128) sqrt64() uint64 {
+    if a[1]==0 && a[0]<2 {
+        return a[0]
+    }
+    // if a>=(2^64-1)^2 then root is 2^64-1
+    if a[1]>0xfffffffffffffffe || (a[1]==0xfffffffffffffffe && a[0]!=0) {
+        return 0xffffffffffffffff
+    }
+    // estimate from float point square root is greater than root
+    // (error of estimate is lesser than 2^12)
+    var x uint64 = 0xffffffffffffffff
+    if est := math.Sqrt(a.ToFloat64()); est<18446744073709543424.0 {
+        x = uint64(est) + 8192
+    }
+    // Newton iteration from above: x = (x + a/x) / 2
+    for {
+        if a[1]>=x {
+            // a/x>=2^64, new value is greater than x
+            return x
+        }
+        q, _ := Div64(a[1], a[0], x)
+        y := (x>>1) + (q>>1) + (x&q&1)
+        if y>=x {
+            return x
+        }
+        x = y
+    }
+}
+
+// return floor of square root of 128-bit unsigned integer
+func (a UInt128) Sqrt() UInt128 {
+    return UInt128{ a.sqrt64(), 0 }
+}
+
+// return floor of square root of 128-bit unsigned integer and
+// remainder (a - root*root)
+func (a UInt128) SqrtRem() (UInt128, UInt128) {
+    s := a.sqrt64()
+    var sq UInt128
+    sq[1], sq[0] = Mul64(s, s)
+    return UInt128{ s, 0 }, a.Sub(sq)
+}
+
+// return floor of cube root of 128-bit unsigned integer
+func (a UInt128) Cbrt() UInt128 {
+    return a.Root(3)
+}
+
+// return floor of n-th root of 128-bit unsigned integer. panics if n is zero
+func (a UInt128) Root(n uint) UInt128 {
+    if n==0 {
+        panic("Zero root degree")
+    }
+    if n==1 {
+        return a
+    }
+    if n==2 {
+        return a.Sqrt()
+    }
+    l := uint(a.Len())
+    if l<=n {
+        // a<2^n, root is 0 or 1
+        if l==0 { return UInt128{} }
+        return UInt128{ 1, 0 }
+    }
+    // initial value 2^ceil(l/n) is greater than root
+    x := uint64(1)<<((l+n-1)/n)
+    // estimate from float point root with margin is also greater than root
+    if est := math.Pow(a.ToFloat64(), 1/float64(n)); est<float64(x) {
+        if xe := uint64(est*(1+1e-12)) + 2; xe<x {
+            x = xe
+        }
+    }
+    // Newton iteration from above: x = ((n-1)*x + a/x^(n-1)) / n
+    for {
+        var q uint64
+        if p, err := (UInt128{ x, 0 }).PowChecked(n-1); err==nil {
+            qq, _ := a.DivMod(p)
+            if qq[1]!=0 {
+                // new value is greater than x
+                return UInt128{ x, 0 }
+            }
+            q = qq[0]
+        }
+        y := (uint64(n-1)*x + q) / uint64(n)
+        if y>=x {
+            return UInt128{ x, 0 }
+        }
+        x = y
+    }
+}
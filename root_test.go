@@ -0,0 +1,181 @@
+/*
+ * root_test.go - tests for integer roots of 128-bit integers
+ *
+ * goint128 - go int128 library
+ * Copyright (C) 2020  Mateusz Szpakowski
+ *
+ * This library is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU Lesser General Public
+ * License as published by the Free Software Foundation; either
+ * version 2.1 of the License, or (at your option) any later version.
+ *
+ * This library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+ * Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public
+ * License along with this library; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301  USA
+ */
+
+package goint128
+
+import (
+    "math/big"
+    "math/rand"
+    "testing"
+)
+
+type UInt128SqrtTC struct {
+    a UInt128
+    expected, expRem UInt128
+}
+
+func TestUInt128Sqrt(t *testing.T) {
+    testCases := []UInt128SqrtTC {
+        UInt128SqrtTC{ UInt128{ 0, 0 }, UInt128{ 0, 0 }, UInt128{ 0, 0 } },
+        UInt128SqrtTC{ UInt128{ 1, 0 }, UInt128{ 1, 0 }, UInt128{ 0, 0 } },
+        UInt128SqrtTC{ UInt128{ 2, 0 }, UInt128{ 1, 0 }, UInt128{ 1, 0 } },
+        UInt128SqrtTC{ UInt128{ 3, 0 }, UInt128{ 1, 0 }, UInt128{ 2, 0 } },
+        UInt128SqrtTC{ UInt128{ 4, 0 }, UInt128{ 2, 0 }, UInt128{ 0, 0 } },
+        UInt128SqrtTC{ UInt128{ 99, 0 }, UInt128{ 9, 0 }, UInt128{ 18, 0 } },
+        UInt128SqrtTC{ UInt128{ 0, 1 }, UInt128{ 1<<32, 0 }, UInt128{ 0, 0 } },
+        UInt128SqrtTC{ UInt128{ 0xffffffffffffffff, 0 },
+            UInt128{ 0xffffffff, 0 }, UInt128{ 0x1fffffffe, 0 } },
+        UInt128SqrtTC{ MaxUInt128, UInt128{ 0xffffffffffffffff, 0 },
+            UInt128{ 0xfffffffffffffffe, 1 } },
+        // (2^64-1)^2
+        UInt128SqrtTC{ UInt128{ 1, 0xfffffffffffffffe },
+            UInt128{ 0xffffffffffffffff, 0 }, UInt128{ 0, 0 } },
+        UInt128SqrtTC{ UInt128{ 0, 0xfffffffffffffffe },
+            UInt128{ 0xfffffffffffffffe, 0 }, UInt128{ 0xfffffffffffffffc, 1 } },
+    }
+    for i, tc := range testCases {
+        a := tc.a
+        result := tc.a.Sqrt()
+        if tc.expected!=result {
+            t.Errorf("Result mismatch: %d: sqrt(%v)->%v!=%v",
+                     i, tc.a, tc.expected, result)
+        }
+        result, rem := tc.a.SqrtRem()
+        if tc.expected!=result || tc.expRem!=rem {
+            t.Errorf("Result mismatch: %d: sqrtrem(%v)->%v,%v!=%v,%v",
+                     i, tc.a, tc.expected, tc.expRem, result, rem)
+        }
+        if tc.a!=a {
+            t.Errorf("Argument has been modified: %d: %v!=%v", i, a, tc.a)
+        }
+    }
+}
+
+// check roots at perfect square boundaries: root of s^2-1, s^2 and s^2+2s
+func TestUInt128SqrtBoundaries(t *testing.T) {
+    check := func(s uint64) {
+        var sq UInt128
+        sq[1], sq[0] = Mul64(s, s)
+        if r := sq.Sqrt(); r!=(UInt128{ s, 0 }) {
+            t.Errorf("Result mismatch: sqrt(%v)->%v!=%v", sq, s, r)
+        }
+        if r := sq.Sub64(1).Sqrt(); r!=(UInt128{ s-1, 0 }) {
+            t.Errorf("Result mismatch: sqrt(%v)->%v!=%v", sq.Sub64(1), s-1, r)
+        }
+        last := sq.Add(UInt128{ s, 0 }.Shl(1))
+        if r, rem := last.SqrtRem(); r!=(UInt128{ s, 0 }) || rem!=(UInt128{ s, 0 }.Shl(1)) {
+            t.Errorf("Result mismatch: sqrtrem(%v)->%v!=%v,%v", last, s, r, rem)
+        }
+    }
+    for k:=uint64(0); k<20000; k++ {
+        check(0xffffffffffffffff-k)
+        check(0x8000000000000000+k)
+        check(0x8000000000000000-k)
+        check(0x100000000+k)
+        check(0x100000000-k)
+        check(2+k)
+    }
+    rnd := rand.New(rand.NewSource(11))
+    for i:=0; i<20000; i++ {
+        check(rnd.Uint64() | 2)
+    }
+}
+
+type UInt128RootTC struct {
+    a UInt128
+    n uint
+    expected UInt128
+}
+
+func TestUInt128Root(t *testing.T) {
+    testCases := []UInt128RootTC {
+        UInt128RootTC{ UInt128{ 0, 0 }, 3, UInt128{ 0, 0 } },
+        UInt128RootTC{ UInt128{ 1, 0 }, 3, UInt128{ 1, 0 } },
+        UInt128RootTC{ UInt128{ 7, 0 }, 3, UInt128{ 1, 0 } },
+        UInt128RootTC{ UInt128{ 8, 0 }, 3, UInt128{ 2, 0 } },
+        UInt128RootTC{ UInt128{ 26, 0 }, 3, UInt128{ 2, 0 } },
+        UInt128RootTC{ UInt128{ 27, 0 }, 3, UInt128{ 3, 0 } },
+        UInt128RootTC{ MaxUInt128, 3, UInt128{ 6981463658331, 0 } },
+        // 6981463658331^3 and one less
+        UInt128RootTC{ UInt128{ 0xfefa7450bfb1e4a3, 0xffffffffffbc605d },
+            3, UInt128{ 6981463658331, 0 } },
+        UInt128RootTC{ UInt128{ 0xfefa7450bfb1e4a2, 0xffffffffffbc605d },
+            3, UInt128{ 6981463658330, 0 } },
+        UInt128RootTC{ MaxUInt128, 1, MaxUInt128 },
+        UInt128RootTC{ MaxUInt128, 2, UInt128{ 0xffffffffffffffff, 0 } },
+        UInt128RootTC{ MaxUInt128, 4, UInt128{ 0xffffffff, 0 } },
+        UInt128RootTC{ MaxUInt128, 127, UInt128{ 2, 0 } },
+        UInt128RootTC{ MaxUInt128, 128, UInt128{ 1, 0 } },
+        UInt128RootTC{ MaxUInt128, 1000, UInt128{ 1, 0 } },
+        UInt128RootTC{ UInt128{ 0, 1<<63 }, 127, UInt128{ 2, 0 } },
+        UInt128RootTC{ UInt128{ 0xffffffffffffffff, 1<<63-1 }, 127, UInt128{ 1, 0 } },
+        UInt128RootTC{ UInt128{ 0, 0 }, 1000, UInt128{ 0, 0 } },
+    }
+    for i, tc := range testCases {
+        a := tc.a
+        result := tc.a.Root(tc.n)
+        if tc.expected!=result {
+            t.Errorf("Result mismatch: %d: root(%v,%v)->%v!=%v",
+                     i, tc.a, tc.n, tc.expected, result)
+        }
+        if tc.n==3 {
+            result = tc.a.Cbrt()
+            if tc.expected!=result {
+                t.Errorf("Result mismatch: %d: cbrt(%v)->%v!=%v",
+                         i, tc.a, tc.expected, result)
+            }
+        }
+        if tc.a!=a {
+            t.Errorf("Argument has been modified: %d: %v!=%v", i, a, tc.a)
+        }
+    }
+    // check that root^n <= a < (root+1)^n
+    rnd := rand.New(rand.NewSource(12))
+    for i:=0; i<5000; i++ {
+        a := UInt128{ rnd.Uint64(), rnd.Uint64() }.Shr(uint(rnd.Intn(128)))
+        n := uint(3+rnd.Intn(20))
+        r := a.Root(n)
+        ba := a.BigInt()
+        bn := big.NewInt(int64(n))
+        lo := new(big.Int).Exp(r.BigInt(), bn, nil)
+        hi := new(big.Int).Exp(r.Add64(1).BigInt(), bn, nil)
+        if lo.Cmp(ba)>0 || hi.Cmp(ba)<=0 {
+            t.Errorf("Result mismatch: root(%v,%v)->%v", a, n, r)
+        }
+    }
+    if paniced, _ := getPanic2(func() { MaxUInt128.Root(0) }); !paniced {
+        t.Errorf("Root(0) does not panic")
+    }
+}
+
+func BenchmarkUInt128Sqrt(b *testing.B) {
+    a := UInt128{ 0x1c9e66c000000000, 0xe1b1e5f90f944d6e }
+    for i := 0; i < b.N; i++ {
+        a.Sqrt()
+    }
+}
+
+func BenchmarkUInt128Cbrt(b *testing.B) {
+    a := UInt128{ 0x1c9e66c000000000, 0xe1b1e5f90f944d6e }
+    for i := 0; i < b.N; i++ {
+        a.Cbrt()
+    }
+}
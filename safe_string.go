@@ -0,0 +1,33 @@
+// +build purego appengine
+
+/*
+ * safe_string.go - conversion bytes to string (without package unsafe)
+ *
+ * goint128 - go int128 library
+ * Copyright (C) 2020  Mateusz Szpakowski
+ *
+ * This library is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU Lesser General Public
+ * License as published by the Free Software Foundation; either
+ * version 2.1 of the License, or (at your option) any later version.
+ *
+ * This library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+ * Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public
+ * License along with this library; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301  USA
+ */
+
+package goint128
+
+// true if bytesToString does not copy bytes
+const bytesToStringNoCopy = false
+
+// return copy of bytes as string (used instead of unsafe version if build tag
+// purego or appengine is set).
+func bytesToString(b []byte) string {
+    return string(b)
+}
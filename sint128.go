@@ -0,0 +1,336 @@
+/*
+ * sint128.go - signed int128 routines
+ *
+ * goint128 - go int128 library
+ * Copyright (C) 2020  Mateusz Szpakowski
+ *
+ * This library is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU Lesser General Public
+ * License as published by the Free Software Foundation; either
+ * version 2.1 of the License, or (at your option) any later version.
+ *
+ * This library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+ * Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public
+ * License along with this library; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301  USA
+ */
+
+// Package to operate on 128-bit integers
+package goint128
+
+import (
+    "encoding/binary"
+    "math"
+    "strconv"
+)
+
+// signed 128-bit integer in two's complement form (first low, second high)
+type Int128 [2]uint64
+
+// add 128-bit signed integers
+func (a Int128) Add(b Int128) Int128 {
+    var c Int128
+    var carry uint64
+    c[0], carry = Add64(a[0], b[0], 0)
+    c[1], _ = Add64(a[1], b[1], carry)
+    return c
+}
+
+// subtract 128-bit signed integers
+func (a Int128) Sub(b Int128) Int128 {
+    var c Int128
+    var borrow uint64
+    c[0], borrow = Sub64(a[0], b[0], 0)
+    c[1], _ = Sub64(a[1], b[1], borrow)
+    return c
+}
+
+// negate 128-bit signed integer
+func (a Int128) Neg() Int128 {
+    var c Int128
+    var borrow uint64
+    c[0], borrow = Sub64(0, a[0], 0)
+    c[1], _ = Sub64(0, a[1], borrow)
+    return c
+}
+
+// return sign of integer: -1 if negative, 0 if zero, 1 if positive
+func (a Int128) Sign() int {
+    if int64(a[1])<0 {
+        return -1
+    } else if a[0]==0 && a[1]==0 {
+        return 0
+    }
+    return 1
+}
+
+// return true if zero
+func (a Int128) IsZero() bool {
+    return a[0]==0 && a[1]==0
+}
+
+// return absolute value as unsigned integer (absolute value of
+// the smallest integer fits only in unsigned integer)
+func (a Int128) Abs() UInt128 {
+    if int64(a[1])<0 {
+        return UInt128(a.Neg())
+    }
+    return UInt128(a)
+}
+
+// compare 128-bit signed integer and return 0 if they equal,
+// 1 if first is greater than second, or -1 if first is lesser than second
+func (a Int128) Cmp(b Int128) int {
+    if a[1]==b[1] {
+        if a[0]==b[0] {
+            return 0
+        } else if a[0]>b[0] {
+            return 1
+        } else {
+            return -1
+        }
+    } else if int64(a[1])>int64(b[1]) {
+        return 1
+    } else { // a[1]<b[1]
+        return -1
+    }
+}
+
+// multiply 128-bit signed integers and return lower 128 bits value
+func (a Int128) Mul(b Int128) Int128 {
+    return Int128(UInt128(a).Mul(UInt128(b)))
+}
+
+// multiply 128-bit signed integers and return high and lower product
+// (high part is signed, lower part is unsigned)
+func (a Int128) MulFull(b Int128) (Int128, UInt128) {
+    hi, lo := UInt128(a).MulFull(UInt128(b))
+    // correct high part of unsigned product: subtract other argument
+    // shifted by 128 bits for every negative argument
+    if int64(a[1])<0 {
+        hi = hi.Sub(UInt128(b))
+    }
+    if int64(b[1])<0 {
+        hi = hi.Sub(UInt128(a))
+    }
+    return Int128(hi), lo
+}
+
+// divide 128-bit signed integers and return quotient and remainder.
+// quotient is truncated towards zero, remainder have sign of dividend
+func (a Int128) DivMod(b Int128) (Int128, Int128) {
+    q, r := a.Abs().DivMod(b.Abs())
+    quo, rem := Int128(q), Int128(r)
+    if (int64(a[1])<0) != (int64(b[1])<0) {
+        quo = quo.Neg()
+    }
+    if int64(a[1])<0 {
+        rem = rem.Neg()
+    }
+    return quo, rem
+}
+
+// divide 128-bit signed integers and return quotient (truncated towards zero)
+func (a Int128) Div(b Int128) Int128 {
+    q, _ := a.DivMod(b)
+    return q
+}
+
+// divide 128-bit signed integers and return quotient (same as Div)
+func (a Int128) Quo(b Int128) Int128 {
+    q, _ := a.DivMod(b)
+    return q
+}
+
+// divide 128-bit signed integers and return remainder (with sign of dividend)
+func (a Int128) Rem(b Int128) Int128 {
+    _, r := a.DivMod(b)
+    return r
+}
+
+// shift 128-bit signed integer left by b bits
+func (a Int128) Shl(b uint) Int128 {
+    return Int128(UInt128(a).Shl(b))
+}
+
+// shift 128-bit signed integer right by b bits (arithmetic shift)
+func (a Int128) Shr(b uint) Int128 {
+    if b==0 { return a }
+    if b>=64 {
+        if b>=127 { b = 127 }
+        return Int128{ uint64(int64(a[1])>>(b-64)), uint64(int64(a[1])>>63) }
+    }
+    return Int128{ (a[0]>>b) | (a[1]<<(64-b)), uint64(int64(a[1])>>b) }
+}
+
+// append decimal form of 128-bit signed integer to bytes
+func (a Int128) AppendFormat(dst []byte) []byte {
+    if int64(a[1])<0 {
+        dst = append(dst, '-')
+    }
+    return a.Abs().AppendFormat(dst)
+}
+
+// format 128-bit signed integer to bytes
+func (a Int128) FormatBytes() []byte {
+    return a.AppendFormat(nil)
+}
+
+// format 128-bit signed integer to decimal string (replaces former Format() string)
+func (a Int128) FormatString() string {
+    return string(a.FormatBytes())
+}
+
+// parse signed decimal integer from string and return value, offset of
+// first bad character and error
+func parseInt128(str string) (Int128, int, error) {
+    start := 0
+    neg := false
+    if len(str)!=0 && (str[0]=='-' || str[0]=='+') {
+        neg = str[0]=='-'
+        start = 1
+    }
+    v, offset, err := parseUInt128(str[start:])
+    if err==nil {
+        v2, err2 := uint128ToInt128(v, neg)
+        if err2==nil {
+            return v2, 0, nil
+        }
+        err = err2
+    }
+    if err==strconv.ErrRange {
+        limit := UInt128{ 0xffffffffffffffff, 0x7fffffffffffffff }
+        if neg {
+            limit = UInt128{ 0, 0x8000000000000000 }
+        }
+        offset = decimalRangeOffset(str[start:], limit)
+    }
+    return Int128{}, start+offset, err
+}
+
+// parse signed integer from string and return value and error (nil if no error)
+func ParseInt128(str string) (Int128, error) {
+    out, offset, err := parseInt128(str)
+    if err!=nil {
+        return Int128{}, numError("ParseInt128", str, offset, err)
+    }
+    return out, nil
+}
+
+// parse signed integer from bytes and return value and error (nil if no error)
+func ParseInt128Bytes(str []byte) (Int128, error) {
+    out, offset, err := parseInt128(bytesToString(str))
+    if err!=nil {
+        return Int128{}, numError("ParseInt128Bytes", string(str), offset, err)
+    }
+    return out, nil
+}
+
+// convert absolute value and sign to signed integer with range checking
+func uint128ToInt128(v UInt128, neg bool) (Int128, error) {
+    if neg {
+        if v[1]>1<<63 || (v[1]==1<<63 && v[0]!=0) {
+            return Int128{}, strconv.ErrRange
+        }
+        return Int128(v).Neg(), nil
+    }
+    if v[1]>=1<<63 {
+        return Int128{}, strconv.ErrRange
+    }
+    return Int128(v), nil
+}
+
+// convert 128-signed integer to 64-bit float point value
+func (a Int128) ToFloat64() float64 {
+    if int64(a[1])<0 {
+        return -a.Abs().ToFloat64()
+    }
+    return UInt128(a).ToFloat64()
+}
+
+// convert 64-bit float point value to 128-bit signed integer
+func Float64ToInt128(a float64) (Int128, error) {
+    if math.IsNaN(a) || a >= 170141183460469231731687303715884105728.0 ||
+        a < -170141183460469231731687303715884105728.0 {
+        return Int128{}, strconv.ErrRange
+    }
+    if a<0 {
+        v, err := Float64ToUInt128(-a)
+        return Int128(v).Neg(), err
+    }
+    v, err := Float64ToUInt128(a)
+    return Int128(v), err
+}
+
+// stringer
+
+func (a Int128) String() string {
+    return string(a.FormatBytes())
+}
+
+// marshalling/unmarshaling
+
+func (a Int128) MarshalBinary() (data []byte, err error) {
+    data2 := make([]byte, 16)
+    binary.LittleEndian.PutUint64(data2[0:8], a[0])
+    binary.LittleEndian.PutUint64(data2[8:16], a[1])
+    return data2, nil
+}
+
+func (a *Int128) UnmarshalBinary(data []byte) error {
+    if len(data) < 16 { return ErrDataTooSmall }
+    a[0] = binary.LittleEndian.Uint64(data[0:8])
+    a[1] = binary.LittleEndian.Uint64(data[8:16])
+    return nil
+}
+
+func (a Int128) MarshalText() (text []byte, err error) {
+    return a.FormatBytes(), nil
+}
+
+func (a *Int128) UnmarshalText(text []byte) error {
+    out, offset, err := parseInt128(bytesToString(text))
+    if err!=nil {
+        *a = Int128{}
+        return numError("UnmarshalText", string(text), offset, err)
+    }
+    *a = out
+    return nil
+}
+
+// append JSON form of 128-bit signed integer to bytes (number if value
+// fits in 64-bit signed integer, otherwise quoted number)
+func (a Int128) AppendJSON(dst []byte) []byte {
+    if a[1]==uint64(int64(a[0])>>63) {
+        return a.AppendFormat(dst)
+    }
+    dst = append(dst, '"')
+    dst = a.AppendFormat(dst)
+    return append(dst, '"')
+}
+
+func (a Int128) MarshalJSON() ([]byte, error) {
+    return a.AppendJSON(nil), nil
+}
+
+func (a *Int128) UnmarshalJSON(data []byte) error {
+    dlen := len(data)
+    str := data
+    start := 0
+    if dlen>=2 && (data[0]=='"'||data[0]=='\'') &&
+                    (data[dlen-1]=='"'||data[dlen-1]=='\'') {
+        str = data[1:dlen-1]
+        start = 1
+    }
+    out, offset, err := parseInt128(bytesToString(str))
+    if err!=nil {
+        *a = Int128{}
+        return numError("UnmarshalJSON", string(data), start+offset, err)
+    }
+    *a = out
+    return nil
+}
@@ -0,0 +1,579 @@
+/*
+ * sint128_test.go - tests for signed int128 routines
+ *
+ * goint128 - go int128 library
+ * Copyright (C) 2020  Mateusz Szpakowski
+ *
+ * This library is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU Lesser General Public
+ * License as published by the Free Software Foundation; either
+ * version 2.1 of the License, or (at your option) any later version.
+ *
+ * This library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+ * Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public
+ * License along with this library; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301  USA
+ */
+
+package goint128
+
+import (
+    "bytes"
+    "encoding/json"
+    "math"
+    "strconv"
+    "testing"
+)
+
+type Int128TC struct {
+    a, b Int128
+    expected Int128
+}
+
+func TestInt128Add(t *testing.T) {
+    testCases := []Int128TC {
+        Int128TC{ Int128{ 0xedcba9876f543211, 0xedcba9876f543210 },
+            Int128{ 0x5a5a5a5a5a5a5a5a, 0x5a5a },
+            Int128{ 0x482603e1c9ae8c6b, 0xedcba9876f548c6b } },
+        Int128TC{ Int128{ 0xffffffffffffffff, 0xffffffffffffffff }, Int128{ 1, 0 },
+            Int128{ 0, 0 } },
+    }
+    for i, tc := range testCases {
+        a, b := tc.a, tc.b
+        result := tc.a.Add(tc.b)
+        if tc.expected!=result {
+            t.Errorf("Result mismatch: %d: %v+%v->%v!=%v",
+                     i, tc.a, tc.b, tc.expected, result)
+        }
+        if tc.a!=a || tc.b!=b {
+            t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
+                     i, a, b, tc.a, tc.b)
+        }
+    }
+}
+
+func TestInt128Sub(t *testing.T) {
+    testCases := []Int128TC {
+        Int128TC{ Int128{ 0xedcba9876f543211, 0xedcba9876f543210 },
+            Int128{ 0x5a5a5a5a5a5a5a5a, 0x5a5a },
+            Int128{ 0x93714f2d14f9d7b7, 0xedcba9876f53d7b6 } },
+        Int128TC{ Int128{ 0, 0 }, Int128{ 1, 0 },
+            Int128{ 0xffffffffffffffff, 0xffffffffffffffff } },
+    }
+    for i, tc := range testCases {
+        a, b := tc.a, tc.b
+        result := tc.a.Sub(tc.b)
+        if tc.expected!=result {
+            t.Errorf("Result mismatch: %d: %v-%v->%v!=%v",
+                     i, tc.a, tc.b, tc.expected, result)
+        }
+        if tc.a!=a || tc.b!=b {
+            t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
+                     i, a, b, tc.a, tc.b)
+        }
+    }
+}
+
+type Int128NegTC struct {
+    a Int128
+    expected Int128
+    expAbs UInt128
+    expSign int
+}
+
+func TestInt128NegAbsSign(t *testing.T) {
+    testCases := []Int128NegTC {
+        Int128NegTC{ Int128{ 0, 0 }, Int128{ 0, 0 }, UInt128{ 0, 0 }, 0 },
+        Int128NegTC{ Int128{ 1, 0 }, Int128{ 0xffffffffffffffff, 0xffffffffffffffff },
+            UInt128{ 1, 0 }, 1 },
+        Int128NegTC{ Int128{ 0xffffffffffffffff, 0xffffffffffffffff }, Int128{ 1, 0 },
+            UInt128{ 1, 0 }, -1 },
+        Int128NegTC{ Int128{ 0xedcba9876f543211, 0xedcba9876f543210 },
+            Int128{ 0x1234567890abcdef, 0x1234567890abcdef },
+            UInt128{ 0x1234567890abcdef, 0x1234567890abcdef }, -1 },
+        Int128NegTC{ Int128{ 0, 0x8000000000000000 }, Int128{ 0, 0x8000000000000000 },
+            UInt128{ 0, 0x8000000000000000 }, -1 },
+    }
+    for i, tc := range testCases {
+        a := tc.a
+        result := tc.a.Neg()
+        resultAbs := tc.a.Abs()
+        resultSign := tc.a.Sign()
+        if tc.expected!=result || tc.expAbs!=resultAbs || tc.expSign!=resultSign {
+            t.Errorf("Result mismatch: %d: neg,abs,sign(%v)->%v,%v,%v!=%v,%v,%v",
+                     i, tc.a, tc.expected, tc.expAbs, tc.expSign,
+                     result, resultAbs, resultSign)
+        }
+        if tc.a!=a {
+            t.Errorf("Argument has been modified: %d: %v!=%v", i, a, tc.a)
+        }
+    }
+}
+
+type Int128CmpTC struct {
+    a, b Int128
+    expected int
+}
+
+func TestInt128Cmp(t *testing.T) {
+    testCases := []Int128CmpTC {
+        Int128CmpTC{ Int128{ 3421, 2454 }, Int128{ 831, 78731 }, -1 },
+        Int128CmpTC{ Int128{ 6743, 6841 }, Int128{ 7731121, 1212 }, 1 },
+        Int128CmpTC{ Int128{ 1231, 33411 }, Int128{ 1231, 33411 }, 0 },
+        Int128CmpTC{ Int128{ 1, 0 }, Int128{ 0xffffffffffffffff, 0xffffffffffffffff }, 1 },
+        Int128CmpTC{ Int128{ 0, 0x8000000000000000 }, Int128{ 0, 0 }, -1 },
+        Int128CmpTC{ Int128{ 5, 0xffffffffffffffff }, Int128{ 4, 0xffffffffffffffff }, 1 },
+    }
+    for i, tc := range testCases {
+        a, b := tc.a, tc.b
+        result := tc.a.Cmp(tc.b)
+        if tc.expected!=result {
+            t.Errorf("Result mismatch: %d: cmp(%v,%v)->%v!=%v",
+                     i, tc.a, tc.b, tc.expected, result)
+        }
+        if tc.a!=a || tc.b!=b {
+            t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
+                     i, a, b, tc.a, tc.b)
+        }
+    }
+}
+
+func TestInt128Mul(t *testing.T) {
+    testCases := []Int128TC {
+        Int128TC{ Int128{ 0xedcba9876f543211, 0xedcba9876f543210 },
+            Int128{ 0x5a5a5a5a5a5a5a5a, 0x5a5a },
+            Int128{ 0xbb2187ee512d93fa, 0xe7e7eb7571e848ae } },
+        Int128TC{ Int128{ 0xffffffffffffffff, 0xffffffffffffffff },
+            Int128{ 0xfffffffffffffffd, 0xffffffffffffffff }, Int128{ 3, 0 } },
+    }
+    for i, tc := range testCases {
+        a, b := tc.a, tc.b
+        result := tc.a.Mul(tc.b)
+        if tc.expected!=result {
+            t.Errorf("Result mismatch: %d: %v*%v->%v!=%v",
+                     i, tc.a, tc.b, tc.expected, result)
+        }
+        if tc.a!=a || tc.b!=b {
+            t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
+                     i, a, b, tc.a, tc.b)
+        }
+    }
+}
+
+type Int128MulFTC struct {
+    a, b Int128
+    expectedLo UInt128
+    expectedHi Int128
+}
+
+func TestInt128MulFull(t *testing.T) {
+    testCases := []Int128MulFTC {
+        Int128MulFTC{ Int128{ 0xedcba9876f543211, 0xedcba9876f543210 },
+            Int128{ 0x5a5a5a5a5a5a5a5a, 0x5a5a },
+            UInt128{ 0xbb2187ee512d93fa, 0xe7e7eb7571e848ae },
+            Int128{ 0x2cc6638720baae47, 0xfffffffffffff993 } },
+        Int128MulFTC{ Int128{ 0x1, 0xffffffffc0000000 },
+            Int128{ 0x8888888888888889, 0xfffffffffffff888 },
+            UInt128{ 0x8888888888888889, 0xddddddddbffff888 },
+            Int128{ 0x1dddddddddd, 0x0 } },
+        Int128MulFTC{ Int128{ 0xffffffffffffffff, 0x7fffffffffffffff },
+            Int128{ 0xffffffffffffffff, 0x7fffffffffffffff },
+            UInt128{ 1, 0 }, Int128{ 0xffffffffffffffff, 0x3fffffffffffffff } },
+        Int128MulFTC{ Int128{ 0xffffffffffffffff, 0xffffffffffffffff }, Int128{ 1, 0 },
+            UInt128{ 0xffffffffffffffff, 0xffffffffffffffff },
+            Int128{ 0xffffffffffffffff, 0xffffffffffffffff } },
+    }
+    for i, tc := range testCases {
+        a, b := tc.a, tc.b
+        result, resultLo := tc.a.MulFull(tc.b)
+        if tc.expectedHi!=result || tc.expectedLo!=resultLo {
+            t.Errorf("Result mismatch: %d: mulfull(%v,%v)->%v,%v!=%v,%v",
+                     i, tc.a, tc.b, tc.expectedLo, tc.expectedHi, resultLo, result)
+        }
+        if tc.a!=a || tc.b!=b {
+            t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
+                     i, a, b, tc.a, tc.b)
+        }
+    }
+}
+
+type Int128DivTC struct {
+    a, b Int128
+    expected, expRem Int128
+}
+
+func TestInt128Div(t *testing.T) {
+    testCases := []Int128DivTC {
+        Int128DivTC{ Int128{ 0xedcba9876f543211, 0xedcba9876f543210 },
+            Int128{ 0x5a5a5a5a5a5a5a5a, 0x5a5a },
+            Int128{ 0xffffcc6bb5aa661a, 0xffffffffffffffff },
+            Int128{ 0x75531edaa06328ed, 0xffffffffffffb998 } },
+        Int128DivTC{ Int128{ 0xffffffffffffffff, 0x7fffffffffffffff },
+            Int128{ 0xfffffffffffffffd, 0xffffffffffffffff },
+            Int128{ 0x5555555555555556, 0xd555555555555555 }, Int128{ 1, 0 } },
+        // smallest integer divided by -1
+        Int128DivTC{ Int128{ 0, 0x8000000000000000 },
+            Int128{ 0xffffffffffffffff, 0xffffffffffffffff },
+            Int128{ 0, 0x8000000000000000 }, Int128{ 0, 0 } },
+        Int128DivTC{ Int128{ 0xffffffffffffff9c, 0xffffffffffffffff }, Int128{ 7, 0 },
+            Int128{ 0xfffffffffffffff2, 0xffffffffffffffff },
+            Int128{ 0xfffffffffffffffe, 0xffffffffffffffff } },
+        Int128DivTC{ Int128{ 100, 0 }, Int128{ 0xfffffffffffffff9, 0xffffffffffffffff },
+            Int128{ 0xfffffffffffffff2, 0xffffffffffffffff }, Int128{ 2, 0 } },
+        Int128DivTC{ Int128{ 0xffffffffffffff9c, 0xffffffffffffffff },
+            Int128{ 0xfffffffffffffff9, 0xffffffffffffffff },
+            Int128{ 14, 0 }, Int128{ 0xfffffffffffffffe, 0xffffffffffffffff } },
+        Int128DivTC{ Int128{ 5, 0 }, Int128{ 7, 0 }, Int128{ 0, 0 }, Int128{ 5, 0 } },
+    }
+    for i, tc := range testCases {
+        a, b := tc.a, tc.b
+        result, rem := tc.a.DivMod(tc.b)
+        if tc.expected!=result || tc.expRem!=rem {
+            t.Errorf("Result mismatch: %d: %v/%v->%v,%v!=%v,%v",
+                     i, tc.a, tc.b, tc.expected, tc.expRem, result, rem)
+        }
+        if result = tc.a.Div(tc.b); tc.expected!=result {
+            t.Errorf("Result mismatch: %d: div(%v,%v)->%v!=%v",
+                     i, tc.a, tc.b, tc.expected, result)
+        }
+        if result = tc.a.Quo(tc.b); tc.expected!=result {
+            t.Errorf("Result mismatch: %d: quo(%v,%v)->%v!=%v",
+                     i, tc.a, tc.b, tc.expected, result)
+        }
+        if rem = tc.a.Rem(tc.b); tc.expRem!=rem {
+            t.Errorf("Result mismatch: %d: rem(%v,%v)->%v!=%v",
+                     i, tc.a, tc.b, tc.expRem, rem)
+        }
+        if tc.a!=a || tc.b!=b {
+            t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
+                     i, a, b, tc.a, tc.b)
+        }
+    }
+    paniced, panicStr := getPanic2(func() {
+        Int128{ 5, 0 }.DivMod(Int128{})
+    })
+    if !paniced || panicStr!="Divide by zero" {
+        t.Errorf("Unexpected panic: %v,%v", paniced, panicStr)
+    }
+}
+
+type Int128ShTC struct {
+    a Int128
+    b uint
+    expected Int128
+}
+
+func TestInt128Shl(t *testing.T) {
+    testCases := []Int128ShTC {
+        Int128ShTC{ Int128{ 0x62b71430f1765e8f, 0xb5ed145b3920ca5a }, 3,
+            Int128{ 0x15b8a1878bb2f478, 0xaf68a2d9c90652d3 } },
+        Int128ShTC{ Int128{ 0xf621e52aaa8b880c, 0xb4283ce0fd8464e2 }, 73,
+            Int128{ 0, 0x43ca555517101800 } },
+    }
+    for i, tc := range testCases {
+        a, b := tc.a, tc.b
+        result := tc.a.Shl(tc.b)
+        if tc.expected!=result {
+            t.Errorf("Result mismatch: %d:%v<<%v->%v!=%v",
+                     i, tc.a, tc.b, tc.expected, result)
+        }
+        if tc.a!=a || tc.b!=b {
+            t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
+                     i, a, b, tc.a, tc.b)
+        }
+    }
+}
+
+func TestInt128Shr(t *testing.T) {
+    testCases := []Int128ShTC {
+        Int128ShTC{ Int128{ 0xedcba9876f543211, 0xedcba9876f543210 }, 3,
+            Int128{ 0x1db97530edea8642, 0xfdb97530edea8642 } },
+        Int128ShTC{ Int128{ 0xedcba9876f543211, 0xedcba9876f543210 }, 70,
+            Int128{ 0xffb72ea61dbd50c8, 0xffffffffffffffff } },
+        Int128ShTC{ Int128{ 0xedcba9876f543211, 0xedcba9876f543210 }, 64,
+            Int128{ 0xedcba9876f543210, 0xffffffffffffffff } },
+        Int128ShTC{ Int128{ 0x1234567890abcdef, 0x1234567890abcdef }, 70,
+            Int128{ 0x48d159e242af37, 0 } },
+        Int128ShTC{ Int128{ 0xfffffffffffffffb, 0xffffffffffffffff }, 127,
+            Int128{ 0xffffffffffffffff, 0xffffffffffffffff } },
+        Int128ShTC{ Int128{ 0xfffffffffffffffb, 0xffffffffffffffff }, 200,
+            Int128{ 0xffffffffffffffff, 0xffffffffffffffff } },
+        Int128ShTC{ Int128{ 0xfffffffffffffffb, 0x7fffffffffffffff }, 200,
+            Int128{ 0, 0 } },
+    }
+    for i, tc := range testCases {
+        a, b := tc.a, tc.b
+        result := tc.a.Shr(tc.b)
+        if tc.expected!=result {
+            t.Errorf("Result mismatch: %d: %v>>%v->%v!=%v",
+                     i, tc.a, tc.b, tc.expected, result)
+        }
+        if tc.a!=a || tc.b!=b {
+            t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
+                     i, a, b, tc.a, tc.b)
+        }
+    }
+}
+
+type Int128FmtTC struct {
+    a Int128
+    expected string
+}
+
+func TestInt128Format(t *testing.T) {
+    testCases := []Int128FmtTC {
+        Int128FmtTC{ Int128{ 0, 0 }, "0" },
+        Int128FmtTC{ Int128{ 1, 0 }, "1" },
+        Int128FmtTC{ Int128{ 0xffffffffffffffff, 0xffffffffffffffff }, "-1" },
+        Int128FmtTC{ Int128{ 0, 0x8000000000000000 },
+            "-170141183460469231731687303715884105728" },
+        Int128FmtTC{ Int128{ 0xffffffffffffffff, 0x7fffffffffffffff },
+            "170141183460469231731687303715884105727" },
+        Int128FmtTC{ Int128{ 0xedcba9876f543211, 0xedcba9876f543210 },
+            "-24197857200151252728969465429440056815" },
+    }
+    for i, tc := range testCases {
+        a := tc.a
+        result := tc.a.String()
+        if tc.expected!=result {
+            t.Errorf("Result mismatch: %d: fmt(%v)->%v!=%v",
+                     i, tc.a, tc.expected, result)
+        }
+        resultBytes := tc.a.FormatBytes()
+        if tc.expected!=string(resultBytes) {
+            t.Errorf("Result mismatch: %d: fmtBytes(%v)->%v!=%v",
+                     i, tc.a, tc.expected, result)
+        }
+        if result = tc.a.FormatString(); tc.expected!=result {
+            t.Errorf("Result mismatch: %d: fmtString(%v)->%v!=%v",
+                     i, tc.a, tc.expected, result)
+        }
+        if tc.a!=a {
+            t.Errorf("Argument has been modified: %d: %v!=%v", i, a, tc.a)
+        }
+    }
+}
+
+type Int128ParseTC struct {
+    str string
+    expected Int128
+    expError error
+}
+
+func TestInt128Parse(t *testing.T) {
+    testCases := []Int128ParseTC {
+        Int128ParseTC{ "0", Int128{ 0, 0 }, nil },
+        Int128ParseTC{ "-0", Int128{ 0, 0 }, nil },
+        Int128ParseTC{ "+15", Int128{ 15, 0 }, nil },
+        Int128ParseTC{ "-1", Int128{ 0xffffffffffffffff, 0xffffffffffffffff }, nil },
+        Int128ParseTC{ "-170141183460469231731687303715884105728",
+            Int128{ 0, 0x8000000000000000 }, nil },
+        Int128ParseTC{ "170141183460469231731687303715884105727",
+            Int128{ 0xffffffffffffffff, 0x7fffffffffffffff }, nil },
+        Int128ParseTC{ "-24197857200151252728969465429440056815",
+            Int128{ 0xedcba9876f543211, 0xedcba9876f543210 }, nil },
+        Int128ParseTC{ "-170141183460469231731687303715884105729",
+            Int128{}, strconv.ErrRange },
+        Int128ParseTC{ "170141183460469231731687303715884105728",
+            Int128{}, strconv.ErrRange },
+        Int128ParseTC{ "-340282366920938463463374607431768211456",
+            Int128{}, strconv.ErrRange },
+        Int128ParseTC{ "", Int128{}, strconv.ErrSyntax },
+        Int128ParseTC{ "-", Int128{}, strconv.ErrSyntax },
+        Int128ParseTC{ "--1", Int128{}, strconv.ErrSyntax },
+        Int128ParseTC{ "-342xx", Int128{}, strconv.ErrSyntax },
+    }
+    for i, tc := range testCases {
+        result, err := ParseInt128(tc.str)
+        if tc.expected!=result || !errorMatch(tc.expError, err) {
+            t.Errorf("Result mismatch: %d: parse(%v)->%v,%v!=%v,%v",
+                     i, tc.str, tc.expected, tc.expError, result, err)
+        }
+        result, err = ParseInt128Bytes([]byte(tc.str))
+        if tc.expected!=result || !errorMatch(tc.expError, err) {
+            t.Errorf("Result mismatch: %d: parseBytes(%v)->%v,%v!=%v,%v",
+                     i, tc.str, tc.expected, tc.expError, result, err)
+        }
+    }
+}
+
+func TestInt128ParseNumError(t *testing.T) {
+    _, err := ParseInt128("-170141183460469231731687303715884105729")
+    expected := NumError{ "ParseInt128", "-170141183460469231731687303715884105729",
+        39, strconv.ErrRange }
+    if numErr, ok := err.(*NumError); !ok || *numErr!=expected {
+        t.Errorf("Result mismatch: %v!=%v", expected, err)
+    }
+    _, err = ParseInt128Bytes([]byte("+1701411834604692317316873037158841057280"))
+    expected = NumError{ "ParseInt128Bytes", "+1701411834604692317316873037158841057280",
+        39, strconv.ErrRange }
+    if numErr, ok := err.(*NumError); !ok || *numErr!=expected {
+        t.Errorf("Result mismatch: %v!=%v", expected, err)
+    }
+    _, err = ParseInt128("-12a")
+    expected = NumError{ "ParseInt128", "-12a", 3, strconv.ErrSyntax }
+    if numErr, ok := err.(*NumError); !ok || *numErr!=expected {
+        t.Errorf("Result mismatch: %v!=%v", expected, err)
+    }
+}
+
+type Int128ToFloat64TC struct {
+    value Int128
+    expected float64
+}
+
+func TestInt128ToFloat64(t *testing.T) {
+    testCases := []Int128ToFloat64TC{
+        Int128ToFloat64TC{ Int128{ 0, 0 }, 0.0 },
+        Int128ToFloat64TC{ Int128{ 54930201, 0 }, 54930201.0 },
+        Int128ToFloat64TC{ Int128{ 0xfffffffffcb9d4e7, 0xffffffffffffffff }, -54930201.0 },
+        Int128ToFloat64TC{ Int128{ 0, 0x8000000000000000 },
+                -170141183460469231731687303715884105728.0 },
+    }
+    for i, tc := range testCases {
+        result := tc.value.ToFloat64()
+        if tc.expected!=result {
+            t.Errorf("Result mismatch: %d: tofloat64(%v)->%v!=%v",
+                     i, tc.value, tc.expected, result)
+        }
+    }
+}
+
+type Float64ToInt128TC struct {
+    value float64
+    expected Int128
+    expError error
+}
+
+func TestFloat64ToInt128(t *testing.T) {
+    testCases := []Float64ToInt128TC{
+        Float64ToInt128TC{ 0.0, Int128{ 0, 0 }, nil },
+        Float64ToInt128TC{ 1.7, Int128{ 1, 0 }, nil },
+        Float64ToInt128TC{ -1.7, Int128{ 0xffffffffffffffff, 0xffffffffffffffff }, nil },
+        Float64ToInt128TC{ -75901828515489894894398.0,
+            Int128{ 0x5a879648da000000, 0xffffffffffffefed }, nil },
+        Float64ToInt128TC{ -170141183460469231731687303715884105728.0,
+            Int128{ 0, 0x8000000000000000 }, nil },
+        Float64ToInt128TC{ 170141183460469231731687303715884105728.0,
+            Int128{}, strconv.ErrRange },
+        Float64ToInt128TC{ -340282366920938463463374607431768211456.0,
+            Int128{}, strconv.ErrRange },
+        Float64ToInt128TC{ math.Inf(-1), Int128{}, strconv.ErrRange },
+        Float64ToInt128TC{ math.NaN(), Int128{}, strconv.ErrRange },
+    }
+    for i, tc := range testCases {
+        result, err := Float64ToInt128(tc.value)
+        if tc.expected!=result || !errorMatch(tc.expError, err) {
+            t.Errorf("Result mismatch: %d: toint128(%v)->%v,%v!=%v,%v",
+                     i, tc.value, tc.expected, tc.expError, result, err)
+        }
+    }
+}
+
+func TestInt128MarshalBinary(t *testing.T) {
+    a := Int128{ 0xccaa010203040506, 0xfbaca34c0a04521 }
+    data, err := a.MarshalBinary()
+    if err!=nil {
+        t.Errorf("MarshalBinary returns error: %v", err)
+    }
+    expected := []byte{ 0x06, 0x05, 0x04, 0x03, 0x02, 0x01, 0xaa, 0xcc,
+                    0x21, 0x45, 0xa0, 0xc0, 0x34, 0xca, 0xba, 0xf }
+    if !bytes.Equal(expected, data) {
+        t.Errorf("Result mismatch: marshalbin(%v)->%v!=%v", a, expected, data)
+    }
+    var v Int128
+    if err = v.UnmarshalBinary(data); err!=nil || v!=a {
+        t.Errorf("Result mismatch: unmarshalbin(%v)->%v,%v", data, v, err)
+    }
+    if err = v.UnmarshalBinary(data[:15]); err!=ErrDataTooSmall {
+        t.Errorf("Result mismatch: unmarshalbin(%v)->%v", data[:15], err)
+    }
+}
+
+type Int128MarshalTC struct {
+    value Int128
+    expected []byte
+}
+
+func TestInt128MarshalText(t *testing.T) {
+    testCases := []Int128MarshalTC{
+        Int128MarshalTC{ Int128{ 34954975929367788, 0 }, []byte("34954975929367788") },
+        Int128MarshalTC{ Int128{ 0xfffffffffffffffe, 0xffffffffffffffff }, []byte("-2") },
+    }
+    for i, tc := range testCases {
+        result, err := tc.value.MarshalText()
+        if err!=nil {
+            t.Errorf("MarshalText returns error: %v", err)
+        }
+        if !bytes.Equal(tc.expected, result) {
+            t.Errorf("Result mismatch: %d: marshaltext(%v)->%v!=%v",
+                     i, tc.value, tc.expected, result)
+        }
+        var v Int128
+        err = v.UnmarshalText(tc.expected)
+        if tc.value!=v || err!=nil {
+            t.Errorf("Result mismatch: %d: unmarshaltext(%v)->%v!=%v,%v",
+                     i, tc.expected, tc.value, v, err)
+        }
+    }
+}
+
+func TestInt128MarshalJSON(t *testing.T) {
+    testCases := []Int128MarshalTC{
+        Int128MarshalTC{ Int128{ 34954975929367788, 0 }, []byte("34954975929367788") },
+        Int128MarshalTC{ Int128{ 0x8000000000000000, 0xffffffffffffffff },
+                []byte("-9223372036854775808") },
+        Int128MarshalTC{ Int128{ 0x7fffffffffffffff, 0xffffffffffffffff },
+                []byte("\"-9223372036854775809\"") },
+        Int128MarshalTC{ Int128{ 0x8000000000000000, 0 },
+                []byte("\"9223372036854775808\"") },
+    }
+    for i, tc := range testCases {
+        result, err := tc.value.MarshalJSON()
+        if err!=nil {
+            t.Errorf("MarshalJSON returns error: %v", err)
+        }
+        if !bytes.Equal(tc.expected, result) {
+            t.Errorf("Result mismatch: %d: marshaljson(%v)->%v!=%v",
+                     i, tc.value, tc.expected, result)
+        }
+        result = tc.value.AppendJSON([]byte("x="))
+        if "x="+string(tc.expected)!=string(result) {
+            t.Errorf("Result mismatch: %d: appendJSON(%v)->%v!=%v",
+                     i, tc.value, tc.expected, result)
+        }
+        var v Int128
+        err = v.UnmarshalJSON(tc.expected)
+        if tc.value!=v || err!=nil {
+            t.Errorf("Result mismatch: %d: unmarshaljson(%v)->%v!=%v,%v",
+                     i, tc.expected, tc.value, v, err)
+        }
+    }
+}
+
+type SampleSignedStruct struct {
+    A, B Int128
+}
+
+func TestInt128JSONHandling(t *testing.T) {
+    const sampleText = `{ "A": -134554, "B": "-234499215868989382112354567" }`
+    var out SampleSignedStruct
+    var err error
+    if err = json.Unmarshal([]byte(sampleText), &out); err!=nil {
+        t.Errorf("Unmarshal returns error: %v", err)
+    }
+    expected := SampleSignedStruct{ Int128{134554, 0}.Neg(),
+        Int128(UInt128{17793088829901545735, 12712227}).Neg() }
+    if out!=expected {
+        t.Errorf("Result mismatch: %v", out)
+    }
+    var b []byte
+    b, err = json.Marshal(out)
+    if string(b)!=`{"A":-134554,"B":"-234499215868989382112354567"}` {
+        t.Errorf("Result mismatch: %v", string(b))
+    }
+}
@@ -0,0 +1,633 @@
+/*
+ * uint256.go - unsigned 256-bit integer routines
+ *
+ * goint128 - go int128 library
+ * Copyright (C) 2020  Mateusz Szpakowski
+ *
+ * This library is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU Lesser General Public
+ * License as published by the Free Software Foundation; either
+ * version 2.1 of the License, or (at your option) any later version.
+ *
+ * This library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+ * Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public
+ * License along with this library; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301  USA
+ */
+
+// Package to operate on 128-bit integers
+package goint128
+
+import (
+    "encoding/binary"
+    "fmt"
+    "math"
+    "math/bits"
+    "strconv"
+)
+
+// unsigned 256-bit integer (words from lowest to highest)
+type UInt256 [4]uint64
+
+var MaxUInt256 UInt256 = UInt256{ 0xffffffffffffffff, 0xffffffffffffffff,
+        0xffffffffffffffff, 0xffffffffffffffff }
+
+// convert 128-bit unsigned integer to 256-bit unsigned integer
+func (a UInt128) Widen() UInt256 {
+    return UInt256{ a[0], a[1], 0, 0 }
+}
+
+// convert 256-bit unsigned integer to 128-bit unsigned integer or
+// return ErrOverflow if it does not fit in 128 bits
+func (a UInt256) Narrow() (UInt128, error) {
+    if a[2]!=0 || a[3]!=0 {
+        return UInt128{}, ErrOverflow
+    }
+    return UInt128{ a[0], a[1] }, nil
+}
+
+// make 256-bit unsigned integer from high and low 128-bit parts
+// (for example from result of MulFull)
+func MakeUInt256(hi, lo UInt128) UInt256 {
+    return UInt256{ lo[0], lo[1], hi[0], hi[1] }
+}
+
+// return high and low 128-bit parts of 256-bit unsigned integer
+// (for example to pass them to UInt128DivFull)
+func (a UInt256) Split() (UInt128, UInt128) {
+    return UInt128{ a[2], a[3] }, UInt128{ a[0], a[1] }
+}
+
+// add 256-bit unsigned integers
+func (a UInt256) Add(b UInt256) UInt256 {
+    c, _ := a.AddC(b, 0)
+    return c
+}
+
+// add 256-bit unsigned integers with carry and return output carry
+func (a UInt256) AddC(b UInt256, oldCarry uint64) (UInt256, uint64) {
+    var c UInt256
+    carry := oldCarry
+    c[0], carry = Add64(a[0], b[0], carry)
+    c[1], carry = Add64(a[1], b[1], carry)
+    c[2], carry = Add64(a[2], b[2], carry)
+    c[3], carry = Add64(a[3], b[3], carry)
+    return c, carry
+}
+
+// add 256-bit unsigned integer and 64-bit unsigned integer
+func (a UInt256) Add64(b uint64) UInt256 {
+    c, _ := a.AddC(UInt256{ b, 0, 0, 0 }, 0)
+    return c
+}
+
+// subtract 256-bit unsigned integers
+func (a UInt256) Sub(b UInt256) UInt256 {
+    c, _ := a.SubB(b, 0)
+    return c
+}
+
+// subtract 256-bit unsigned integers with borrow and return output borrow
+func (a UInt256) SubB(b UInt256, oldBorrow uint64) (UInt256, uint64) {
+    var c UInt256
+    borrow := oldBorrow
+    c[0], borrow = Sub64(a[0], b[0], borrow)
+    c[1], borrow = Sub64(a[1], b[1], borrow)
+    c[2], borrow = Sub64(a[2], b[2], borrow)
+    c[3], borrow = Sub64(a[3], b[3], borrow)
+    return c, borrow
+}
+
+// subtract 256-bit unsigned integer and 64-bit unsigned integer
+func (a UInt256) Sub64(b uint64) UInt256 {
+    c, _ := a.SubB(UInt256{ b, 0, 0, 0 }, 0)
+    return c
+}
+
+// add 256-bit unsigned integers and return sum or error if overflow
+func (a UInt256) AddChecked(b UInt256) (UInt256, error) {
+    c, carry := a.AddC(b, 0)
+    if carry!=0 {
+        return UInt256{}, ErrOverflow
+    }
+    return c, nil
+}
+
+// subtract 256-bit unsigned integers and return difference or error if overflow
+func (a UInt256) SubChecked(b UInt256) (UInt256, error) {
+    c, borrow := a.SubB(b, 0)
+    if borrow!=0 {
+        return UInt256{}, ErrOverflow
+    }
+    return c, nil
+}
+
+// compare 256-bit unsigned integers and return 0 if they equal,
+// 1 if first is greater than second, or -1 if first is lesser than second
+func (a UInt256) Cmp(b UInt256) int {
+    for i:=3; i>=0; i-- {
+        if a[i]!=b[i] {
+            if a[i]>b[i] {
+                return 1
+            }
+            return -1
+        }
+    }
+    return 0
+}
+
+// return true if zero
+func (a UInt256) IsZero() bool {
+    return a[0]==0 && a[1]==0 && a[2]==0 && a[3]==0
+}
+
+// multiply 256-bit unsigned integers and return full 512-bit product
+// (words from lowest to highest)
+func (a UInt256) mulFull(b UInt256) [8]uint64 {
+    var c [8]uint64
+    for i:=0; i<4; i++ {
+        var k uint64
+        for j:=0; j<4; j++ {
+            // c[i+j] = a[i]*b[j] + c[i+j] + k
+            hi, lo := Mul64(a[i], b[j])
+            var carry uint64
+            lo, carry = Add64(lo, c[i+j], 0)
+            hi += carry
+            c[i+j], carry = Add64(lo, k, 0)
+            k = hi + carry
+        }
+        c[i+4] = k
+    }
+    return c
+}
+
+// multiply 256-bit unsigned integers and return lower 256 bits of product
+func (a UInt256) Mul(b UInt256) UInt256 {
+    var c UInt256
+    for i:=0; i<4; i++ {
+        var k uint64
+        for j:=0; i+j<4; j++ {
+            hi, lo := Mul64(a[i], b[j])
+            var carry uint64
+            lo, carry = Add64(lo, c[i+j], 0)
+            hi += carry
+            c[i+j], carry = Add64(lo, k, 0)
+            k = hi + carry
+        }
+    }
+    return c
+}
+
+// multiply 256-bit unsigned integer and 64-bit unsigned integer
+// and return lower 256 bits of product
+func (a UInt256) Mul64(b uint64) UInt256 {
+    var c UInt256
+    var k, carry uint64
+    for i:=0; i<4; i++ {
+        var hi uint64
+        hi, c[i] = Mul64(a[i], b)
+        c[i], carry = Add64(c[i], k, 0)
+        k = hi + carry
+    }
+    return c
+}
+
+// multiply 256-bit unsigned integers and return product or error if overflow
+func (a UInt256) MulChecked(b UInt256) (UInt256, error) {
+    c := a.mulFull(b)
+    if c[4]!=0 || c[5]!=0 || c[6]!=0 || c[7]!=0 {
+        return UInt256{}, ErrOverflow
+    }
+    return UInt256{ c[0], c[1], c[2], c[3] }, nil
+}
+
+// shift 256-bit unsigned integer left by b bits
+func (a UInt256) Shl(b uint) UInt256 {
+    var c UInt256
+    if b>=256 {
+        return c
+    }
+    w, s := int(b>>6), b&63
+    for i:=3; i>=w; i-- {
+        c[i] = a[i-w]<<s
+        if s!=0 && i>w {
+            c[i] |= a[i-w-1]>>(64-s)
+        }
+    }
+    return c
+}
+
+// shift 256-bit unsigned integer right by b bits
+func (a UInt256) Shr(b uint) UInt256 {
+    var c UInt256
+    if b>=256 {
+        return c
+    }
+    w, s := int(b>>6), b&63
+    for i:=0; i+w<4; i++ {
+        c[i] = a[i+w]>>s
+        if s!=0 && i+w<3 {
+            c[i] |= a[i+w+1]<<(64-s)
+        }
+    }
+    return c
+}
+
+// bitwise AND of 256-bit unsigned integers
+func (a UInt256) And(b UInt256) UInt256 {
+    return UInt256{ a[0]&b[0], a[1]&b[1], a[2]&b[2], a[3]&b[3] }
+}
+
+// bitwise OR of 256-bit unsigned integers
+func (a UInt256) Or(b UInt256) UInt256 {
+    return UInt256{ a[0]|b[0], a[1]|b[1], a[2]|b[2], a[3]|b[3] }
+}
+
+// bitwise XOR of 256-bit unsigned integers
+func (a UInt256) Xor(b UInt256) UInt256 {
+    return UInt256{ a[0]^b[0], a[1]^b[1], a[2]^b[2], a[3]^b[3] }
+}
+
+// bitwise NOT of 256-bit unsigned integer
+func (a UInt256) Not() UInt256 {
+    return UInt256{ ^a[0], ^a[1], ^a[2], ^a[3] }
+}
+
+// return number of leading zero bits
+func (a UInt256) LeadingZeros() int {
+    for i:=3; i>=0; i-- {
+        if a[i]!=0 {
+            return (3-i)*64 + bits.LeadingZeros64(a[i])
+        }
+    }
+    return 256
+}
+
+// return number of trailing zero bits
+func (a UInt256) TrailingZeros() int {
+    for i:=0; i<4; i++ {
+        if a[i]!=0 {
+            return i*64 + bits.TrailingZeros64(a[i])
+        }
+    }
+    return 256
+}
+
+// return minimum number of bits required to represent integer
+func (a UInt256) Len() int {
+    return 256 - a.LeadingZeros()
+}
+
+// get bit of integer
+func (a UInt256) Bit(i uint) uint {
+    if i>=256 { return 0 }
+    return uint((a[i>>6]>>(i&63))&1)
+}
+
+// divide 256-bit unsigned integer by 64-bit value and
+// return 256-bit quotient and 64-bit remainder
+func (a UInt256) Div64(b uint64) (UInt256, uint64) {
+    if b==0 {
+        panic("Divide by zero")
+    }
+    var q UInt256
+    var rem uint64
+    for i:=3; i>=0; i-- {
+        q[i], rem = Div64(rem, a[i], b)
+    }
+    return q, rem
+}
+
+// divide 256-bit unsigned integers and return quotient and remainder
+// (Knuth algorithm D)
+func (a UInt256) DivMod(b UInt256) (UInt256, UInt256) {
+    n := 4
+    for n>0 && b[n-1]==0 {
+        n--
+    }
+    if n==0 {
+        panic("Divide by zero")
+    }
+    if n==1 {
+        q, rem := a.Div64(b[0])
+        return q, UInt256{ rem, 0, 0, 0 }
+    }
+    if a.Cmp(b)<0 {
+        return UInt256{}, a
+    }
+    // normalize divisor (move highest bit to highest position of top word)
+    s := uint(bits.LeadingZeros64(b[n-1]))
+    vn := b.Shl(s)
+    var un [5]uint64
+    ashl := a.Shl(s)
+    copy(un[:4], ashl[:])
+    un[4] = a[3]>>(64-s)
+    var q UInt256
+    for j:=4-n; j>=0; j-- {
+        // estimate quotient digit from two highest words
+        var qhat, rhat uint64
+        rhatOver := false
+        if un[j+n]>=vn[n-1] {
+            qhat = math.MaxUint64
+            var carry uint64
+            rhat, carry = Add64(un[j+n-1], vn[n-1], 0)
+            rhatOver = carry!=0
+        } else {
+            qhat, rhat = Div64(un[j+n], un[j+n-1], vn[n-1])
+        }
+        // correct estimate: at most two times
+        for !rhatOver {
+            phi, plo := Mul64(qhat, vn[n-2])
+            if phi<rhat || (phi==rhat && plo<=un[j+n-2]) {
+                break
+            }
+            qhat--
+            var carry uint64
+            rhat, carry = Add64(rhat, vn[n-1], 0)
+            rhatOver = carry!=0
+        }
+        // multiply and subtract
+        var k, borrow uint64
+        for i:=0; i<n; i++ {
+            phi, plo := Mul64(qhat, vn[i])
+            var carry uint64
+            plo, carry = Add64(plo, k, 0)
+            k = phi + carry
+            un[i+j], borrow = Sub64(un[i+j], plo, borrow)
+        }
+        un[j+n], borrow = Sub64(un[j+n], k, borrow)
+        if borrow!=0 {
+            // estimate was greater by one: add divisor back
+            qhat--
+            var carry uint64
+            for i:=0; i<n; i++ {
+                un[i+j], carry = Add64(un[i+j], vn[i], carry)
+            }
+            un[j+n] += carry
+        }
+        q[j] = qhat
+    }
+    // denormalize remainder (it is lesser than divisor and fits in n words)
+    var r UInt256
+    copy(r[:n], un[:n])
+    return q, r.Shr(s)
+}
+
+// divide 256-bit unsigned integers and return quotient, remainder and
+// error (ErrDivideByZero if divisor is zero)
+func (a UInt256) DivModChecked(b UInt256) (UInt256, UInt256, error) {
+    if b.IsZero() {
+        return UInt256{}, UInt256{}, ErrDivideByZero
+    }
+    q, r := a.DivMod(b)
+    return q, r, nil
+}
+
+// divide 256-bit unsigned integers and return quotient
+func (a UInt256) Div(b UInt256) UInt256 {
+    q, _ := a.DivMod(b)
+    return q
+}
+
+// divide 256-bit unsigned integers and return remainder
+func (a UInt256) Mod(b UInt256) UInt256 {
+    _, r := a.DivMod(b)
+    return r
+}
+
+// append decimal form of 256-bit unsigned integer to bytes
+func (a UInt256) AppendFormat(dst []byte) []byte {
+    var chars [80]byte
+    i := len(chars)
+    // split into 19-digit chunks
+    for a[1]!=0 || a[2]!=0 || a[3]!=0 {
+        var rem uint64
+        a, rem = a.Div64(pow10_19)
+        i = putUint64Digits(chars[:], i, rem, 19)
+    }
+    i = putUint64Digits(chars[:], i, a[0], 0)
+    return append(dst, chars[i:]...)
+}
+
+// format 256-bit unsigned integer to bytes
+func (a UInt256) FormatBytes() []byte {
+    return a.AppendFormat(nil)
+}
+
+// append 256-bit unsigned integer in given base (2..36) to bytes.
+// if upper is true then digits above 9 are upper-case letters.
+// if number has fewer digits than width then it is padded by zeroes.
+func (a UInt256) AppendBaseWidth(dst []byte, base int, upper bool, width int) []byte {
+    if base<2 || base>36 {
+        panic("Illegal base")
+    }
+    digits := lowerDigits
+    if upper { digits = upperDigits }
+    var chars [256]byte
+    i := len(chars)
+    b := uint64(base)
+    if base&(base-1)==0 {
+        // power of two - use shifts
+        shift := uint(bits.TrailingZeros64(b))
+        mask := b-1
+        for a[1]!=0 || a[2]!=0 || a[3]!=0 || a[0]>=b {
+            i--
+            chars[i] = digits[a[0]&mask]
+            a = a.Shr(shift)
+        }
+    } else {
+        // divide by greatest power of base that fits in 64-bit
+        bb, n := b, 1
+        for bb <= math.MaxUint64/b {
+            bb *= b
+            n++
+        }
+        for a[1]!=0 || a[2]!=0 || a[3]!=0 {
+            var rem uint64
+            a, rem = a.Div64(bb)
+            for j:=0; j<n; j++ {
+                i--
+                chars[i] = digits[rem%b]
+                rem /= b
+            }
+        }
+        for a[0]>=b {
+            i--
+            chars[i] = digits[a[0]%b]
+            a[0] /= b
+        }
+    }
+    i--
+    chars[i] = digits[a[0]]
+    for pad := width-(len(chars)-i); pad>0; pad-- {
+        dst = append(dst, '0')
+    }
+    return append(dst, chars[i:]...)
+}
+
+// append 256-bit unsigned integer in given base (2..36) to bytes
+func (a UInt256) AppendBase(dst []byte, base int) []byte {
+    return a.AppendBaseWidth(dst, base, false, 0)
+}
+
+// format 256-bit unsigned integer to string in given base (2..36)
+func (a UInt256) FormatBase(base int) string {
+    return string(a.AppendBaseWidth(nil, base, false, 0))
+}
+
+// implements fmt.Formatter. verbs and flags are same as in UInt128.Format
+func (a UInt256) Format(f fmt.State, verb rune) {
+    formatInteger(f, verb, false, false, a, "UInt256")
+}
+
+// parse unsigned integer from string in given base and return value,
+// offset of first bad character and error. base, prefix and underscores
+// are handled by baseDigitScanner
+func parseUInt256Base(str string, base int) (UInt256, int, error) {
+    var s baseDigitScanner
+    if err := s.init(str, base); err!=nil {
+        return UInt256{}, 0, err
+    }
+    b := s.base
+    var out UInt256
+    for {
+        k, ok, err := s.next()
+        if err!=nil {
+            return UInt256{}, s.pos, err
+        } else if !ok {
+            break
+        }
+        // multiply by base and add digit
+        for j:=0; j<4; j++ {
+            var hi, carry uint64
+            hi, out[j] = Mul64(out[j], b)
+            out[j], carry = Add64(out[j], k, 0)
+            k = hi + carry
+        }
+        if k!=0 {
+            return UInt256{}, s.pos, strconv.ErrRange
+        }
+    }
+    return out, 0, nil
+}
+
+// parse unsigned decimal integer from string and return value and error
+// (nil if no error)
+func ParseUInt256(str string) (UInt256, error) {
+    out, offset, err := parseUInt256Base(str, 10)
+    if err!=nil {
+        return UInt256{}, numError("ParseUInt256", str, offset, err)
+    }
+    return out, nil
+}
+
+// parse unsigned decimal integer from bytes and return value and error
+// (nil if no error)
+func ParseUInt256Bytes(str []byte) (UInt256, error) {
+    out, offset, err := parseUInt256Base(bytesToString(str), 10)
+    if err!=nil {
+        return UInt256{}, numError("ParseUInt256Bytes", string(str), offset, err)
+    }
+    return out, nil
+}
+
+// parse unsigned integer from string in given base and return value and error
+// (nil if no error). rules are same as in ParseUInt128Base
+func ParseUInt256Base(str string, base int) (UInt256, error) {
+    out, offset, err := parseUInt256Base(str, base)
+    if err!=nil {
+        return UInt256{}, numError("ParseUInt256Base", str, offset, err)
+    }
+    return out, nil
+}
+
+// implements fmt.Scanner. verbs are same as in UInt128.Scan
+func (a *UInt256) Scan(state fmt.ScanState, verb rune) error {
+    str, base, err := scanInteger(state, verb, false)
+    if err!=nil {
+        return err
+    }
+    out, offset, err := parseUInt256Base(str, base)
+    if err!=nil {
+        return numError("Scan", str, offset, err)
+    }
+    *a = out
+    return nil
+}
+
+// stringer
+
+func (a UInt256) String() string {
+    return string(a.FormatBytes())
+}
+
+// marshalling/unmarshaling
+
+func (a UInt256) MarshalBinary() (data []byte, err error) {
+    data2 := make([]byte, 32)
+    binary.LittleEndian.PutUint64(data2[0:8], a[0])
+    binary.LittleEndian.PutUint64(data2[8:16], a[1])
+    binary.LittleEndian.PutUint64(data2[16:24], a[2])
+    binary.LittleEndian.PutUint64(data2[24:32], a[3])
+    return data2, nil
+}
+
+func (a *UInt256) UnmarshalBinary(data []byte) error {
+    if len(data) < 32 { return ErrDataTooSmall }
+    a[0] = binary.LittleEndian.Uint64(data[0:8])
+    a[1] = binary.LittleEndian.Uint64(data[8:16])
+    a[2] = binary.LittleEndian.Uint64(data[16:24])
+    a[3] = binary.LittleEndian.Uint64(data[24:32])
+    return nil
+}
+
+func (a UInt256) MarshalText() (text []byte, err error) {
+    return a.FormatBytes(), nil
+}
+
+func (a *UInt256) UnmarshalText(text []byte) error {
+    out, offset, err := parseUInt256Base(bytesToString(text), 10)
+    if err!=nil {
+        *a = UInt256{}
+        return numError("UnmarshalText", string(text), offset, err)
+    }
+    *a = out
+    return nil
+}
+
+// append JSON form of 256-bit unsigned integer to bytes (number if value
+// fits in 64-bit, otherwise quoted number)
+func (a UInt256) AppendJSON(dst []byte) []byte {
+    if a[1]==0 && a[2]==0 && a[3]==0 {
+        return a.AppendFormat(dst)
+    }
+    dst = append(dst, '"')
+    dst = a.AppendFormat(dst)
+    return append(dst, '"')
+}
+
+func (a UInt256) MarshalJSON() ([]byte, error) {
+    return a.AppendJSON(nil), nil
+}
+
+func (a *UInt256) UnmarshalJSON(data []byte) error {
+    dlen := len(data)
+    str := data
+    start := 0
+    if dlen>=2 && (data[0]=='"'||data[0]=='\'') &&
+                    (data[dlen-1]=='"'||data[dlen-1]=='\'') {
+        str = data[1:dlen-1]
+        start = 1
+    }
+    out, offset, err := parseUInt256Base(bytesToString(str), 10)
+    if err!=nil {
+        *a = UInt256{}
+        return numError("UnmarshalJSON", string(data), start+offset, err)
+    }
+    *a = out
+    return nil
+}
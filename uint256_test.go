@@ -0,0 +1,377 @@
+/*
+ * uint256_test.go - tests for unsigned 256-bit integer routines
+ *
+ * goint128 - go int128 library
+ * Copyright (C) 2020  Mateusz Szpakowski
+ *
+ * This library is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU Lesser General Public
+ * License as published by the Free Software Foundation; either
+ * version 2.1 of the License, or (at your option) any later version.
+ *
+ * This library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+ * Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public
+ * License along with this library; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301  USA
+ */
+
+package goint128
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+    "math/big"
+    "math/rand"
+    "strconv"
+    "testing"
+)
+
+// convert 256-bit unsigned integer to big integer
+func uint256ToBig(a UInt256) *big.Int {
+    x := UInt128{ a[2], a[3] }.BigInt()
+    x.Lsh(x, 128)
+    return x.Or(x, UInt128{ a[0], a[1] }.BigInt())
+}
+
+// convert lowest 256 bits of big integer to 256-bit unsigned integer
+func uint256FromBig(x *big.Int) UInt256 {
+    var a UInt256
+    t := new(big.Int).Set(x)
+    mask := new(big.Int).SetUint64(0xffffffffffffffff)
+    for i:=0; i<4; i++ {
+        a[i] = new(big.Int).And(t, mask).Uint64()
+        t.Rsh(t, 64)
+    }
+    return a
+}
+
+// random 256-bit value with random length and words of special values
+func randUInt256(rnd *rand.Rand) UInt256 {
+    var a UInt256
+    for i:=0; i<4; i++ {
+        switch rnd.Intn(6) {
+        case 0:
+            a[i] = 0
+        case 1:
+            a[i] = 0xffffffffffffffff
+        default:
+            a[i] = rnd.Uint64()
+        }
+    }
+    return a.Shr(uint(rnd.Intn(256)))
+}
+
+func TestUInt256Conversions(t *testing.T) {
+    a := UInt128{ 0x1122334455667788, 0x99aabbccddeeff00 }
+    if w := a.Widen(); w!=(UInt256{ 0x1122334455667788, 0x99aabbccddeeff00, 0, 0 }) {
+        t.Errorf("Result mismatch: widen(%v)->%v", a, w)
+    }
+    if n, err := a.Widen().Narrow(); n!=a || err!=nil {
+        t.Errorf("Result mismatch: narrow(%v)->%v,%v", a, n, err)
+    }
+    if n, err := (UInt256{ 1, 2, 3, 0 }).Narrow(); n!=(UInt128{}) || err!=ErrOverflow {
+        t.Errorf("Result mismatch: narrow->%v,%v", n, err)
+    }
+    if n, err := (UInt256{ 1, 2, 0, 4 }).Narrow(); n!=(UInt128{}) || err!=ErrOverflow {
+        t.Errorf("Result mismatch: narrow->%v,%v", n, err)
+    }
+    hi, lo := MaxUInt128.MulFull(a)
+    v := MakeUInt256(hi, lo)
+    if v!=MaxUInt128.Widen().Mul(a.Widen()) {
+        t.Errorf("Result mismatch: makeuint256(%v,%v)->%v", hi, lo, v)
+    }
+    if hi2, lo2 := v.Split(); hi2!=hi || lo2!=lo {
+        t.Errorf("Result mismatch: split(%v)->%v,%v", v, hi2, lo2)
+    }
+}
+
+type UInt256DivTC struct {
+    a, b UInt256
+    expected, expRem UInt256
+}
+
+func TestUInt256DivMod(t *testing.T) {
+    testCases := []UInt256DivTC {
+        UInt256DivTC{ UInt256{ 58, 0, 0, 0 }, UInt256{ 7, 0, 0, 0 },
+            UInt256{ 8, 0, 0, 0 }, UInt256{ 2, 0, 0, 0 } },
+        UInt256DivTC{ MaxUInt256, UInt256{ 1, 0, 0, 0 }, MaxUInt256, UInt256{} },
+        UInt256DivTC{ MaxUInt256, MaxUInt256, UInt256{ 1, 0, 0, 0 }, UInt256{} },
+        UInt256DivTC{ UInt256{ 5, 0, 0, 0 }, MaxUInt256, UInt256{}, UInt256{ 5, 0, 0, 0 } },
+        UInt256DivTC{ MaxUInt256, UInt256{ 0, 1, 0, 0 },
+            UInt256{ 0xffffffffffffffff, 0xffffffffffffffff, 0xffffffffffffffff, 0 },
+            UInt256{ 0xffffffffffffffff, 0, 0, 0 } },
+        UInt256DivTC{ MaxUInt256, UInt256{ 0xffffffffffffffff, 0xffffffffffffffff, 0, 0 },
+            UInt256{ 1, 0, 1, 0 }, UInt256{} },
+        // (2^256-1)/(2^192+1) = 2^64-1, remainder 2^192-2^64
+        UInt256DivTC{ MaxUInt256, UInt256{ 1, 0, 0, 1 },
+            UInt256{ 0xffffffffffffffff, 0, 0, 0 },
+            UInt256{ 0, 0xffffffffffffffff, 0xffffffffffffffff, 0 } },
+        // estimated quotient digit must be corrected
+        UInt256DivTC{ UInt256{ 0, 0, 0, 0x8000000000000000 },
+            UInt256{ 1, 0, 0x8000000000000000, 0 },
+            UInt256{ 0xffffffffffffffff, 0, 0, 0 },
+            UInt256{ 1, 0xffffffffffffffff, 0x7fffffffffffffff, 0 } },
+    }
+    for i, tc := range testCases {
+        a, b := tc.a, tc.b
+        result, resultRem := tc.a.DivMod(tc.b)
+        if tc.expected!=result || tc.expRem!=resultRem {
+            t.Errorf("Result mismatch: %d: divmod(%v,%v)->%v,%v!=%v,%v",
+                     i, tc.a, tc.b, tc.expected, tc.expRem, result, resultRem)
+        }
+        if tc.a!=a || tc.b!=b {
+            t.Errorf("Argument has been modified: %d: %v,%v!=%v,%v",
+                     i, a, b, tc.a, tc.b)
+        }
+    }
+    if paniced, panicStr := getPanic2(func() { MaxUInt256.DivMod(UInt256{}) });
+        !paniced || panicStr!="Divide by zero" {
+        t.Errorf("Result mismatch: panic->%v,%v", paniced, panicStr)
+    }
+    if _, _, err := MaxUInt256.DivModChecked(UInt256{}); err!=ErrDivideByZero {
+        t.Errorf("Result mismatch: divmodchecked->%v", err)
+    }
+}
+
+// compare arithmetic with big integers
+func TestUInt256Bit(t *testing.T) {
+    a := UInt256{ 1, 0, 0, 0x8000000000000000 }
+    for _, i := range []uint { 256, 257, 319, 320, 511, 1<<20 } {
+        if result := a.Bit(i); result!=0 {
+            t.Errorf("Result mismatch: bit(%v,%v)->0!=%v", a, i, result)
+        }
+    }
+    if a.Bit(0)!=1 || a.Bit(1)!=0 || a.Bit(255)!=1 || a.Bit(254)!=0 {
+        t.Errorf("Result mismatch: bit(%v)->%v,%v,%v,%v", a, a.Bit(0), a.Bit(1),
+                 a.Bit(255), a.Bit(254))
+    }
+}
+
+func TestUInt256Big(t *testing.T) {
+    rnd := rand.New(rand.NewSource(22))
+    mod := new(big.Int).Lsh(big.NewInt(1), 256)
+    for i:=0; i<5000; i++ {
+        a, b := randUInt256(rnd), randUInt256(rnd)
+        ba, bb := uint256ToBig(a), uint256ToBig(b)
+        expected := new(big.Int).Add(ba, bb)
+        if result, err := a.AddChecked(b); uint256FromBig(expected)!=a.Add(b) ||
+            (expected.Cmp(mod)>=0)!=(err==ErrOverflow) ||
+            (err==nil && result!=a.Add(b)) {
+            t.Errorf("Result mismatch: add(%v,%v)->%v!=%v,%v", a, b, expected, result, err)
+        }
+        expected.Sub(ba, bb)
+        if result, err := a.SubChecked(b); uint256FromBig(expected)!=a.Sub(b) ||
+            (expected.Sign()<0)!=(err==ErrOverflow) || (err==nil && result!=a.Sub(b)) {
+            t.Errorf("Result mismatch: sub(%v,%v)->%v!=%v,%v", a, b, expected, result, err)
+        }
+        expected.Mul(ba, bb)
+        if result, err := a.MulChecked(b); uint256FromBig(expected)!=a.Mul(b) ||
+            (expected.Cmp(mod)>=0)!=(err==ErrOverflow) || (err==nil && result!=a.Mul(b)) {
+            t.Errorf("Result mismatch: mul(%v,%v)->%v!=%v,%v", a, b, expected, result, err)
+        }
+        expected.Mul(ba, new(big.Int).SetUint64(b[0]))
+        if result := a.Mul64(b[0]); uint256FromBig(expected)!=result {
+            t.Errorf("Result mismatch: mul64(%v,%v)->%v!=%v", a, b[0], expected, result)
+        }
+        if result := a.Cmp(b); ba.Cmp(bb)!=result {
+            t.Errorf("Result mismatch: cmp(%v,%v)->%v!=%v", a, b, ba.Cmp(bb), result)
+        }
+        shift := uint(rnd.Intn(260))
+        expected.Lsh(ba, shift)
+        if result := a.Shl(shift); uint256FromBig(expected)!=result {
+            t.Errorf("Result mismatch: shl(%v,%v)->%v!=%v", a, shift, expected, result)
+        }
+        expected.Rsh(ba, shift)
+        if result := a.Shr(shift); uint256FromBig(expected)!=result {
+            t.Errorf("Result mismatch: shr(%v,%v)->%v!=%v", a, shift, expected, result)
+        }
+        if a.Len()!=ba.BitLen() || a.Bit(shift)!=ba.Bit(int(shift)) {
+            t.Errorf("Result mismatch: len/bit(%v)->%v,%v", a, a.Len(), a.Bit(shift))
+        }
+        if b.IsZero() {
+            continue
+        }
+        expected, expRem := new(big.Int).QuoRem(ba, bb, new(big.Int))
+        if result, resultRem := a.DivMod(b); expected.Cmp(uint256ToBig(result))!=0 ||
+            expRem.Cmp(uint256ToBig(resultRem))!=0 {
+            t.Errorf("Result mismatch: divmod(%v,%v)->%v,%v!=%v,%v",
+                     a, b, expected, expRem, result, resultRem)
+        }
+        if a.String()!=ba.String() || a.FormatBase(16)!=ba.Text(16) ||
+            a.FormatBase(36)!=ba.Text(36) {
+            t.Errorf("Result mismatch: format(%v)->%v", ba, a)
+        }
+        if result, err := ParseUInt256(ba.String()); result!=a || err!=nil {
+            t.Errorf("Result mismatch: parse(%v)->%v,%v", ba, result, err)
+        }
+    }
+}
+
+type UInt256ParseTC struct {
+    str string
+    base int
+    expected UInt256
+    expError error
+    offset int
+}
+
+func TestParseUInt256(t *testing.T) {
+    testCases := []UInt256ParseTC {
+        UInt256ParseTC{ "0", 10, UInt256{}, nil, 0 },
+        UInt256ParseTC{ "115792089237316195423570985008687907853269984665640564039457584007913129639935",
+            10, MaxUInt256, nil, 0 },
+        UInt256ParseTC{ "115792089237316195423570985008687907853269984665640564039457584007913129639936",
+            10, UInt256{}, strconv.ErrRange, 77 },
+        UInt256ParseTC{ "1157920892373161954235709850086879078532699846656405640394575840079131296399350",
+            10, UInt256{}, strconv.ErrRange, 78 },
+        UInt256ParseTC{ "340282366920938463463374607431768211456", 10, UInt256{ 0, 0, 1, 0 }, nil, 0 },
+        UInt256ParseTC{ "12x4", 10, UInt256{}, strconv.ErrSyntax, 2 },
+        UInt256ParseTC{ "", 10, UInt256{}, strconv.ErrSyntax, 0 },
+        UInt256ParseTC{ "0x1_0000_0000_0000_0000_0000_0000_0000_0000", 0,
+            UInt256{ 0, 0, 1, 0 }, nil, 0 },
+        UInt256ParseTC{ "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+            16, MaxUInt256, nil, 0 },
+        UInt256ParseTC{ "1ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+            16, UInt256{}, strconv.ErrRange, 64 },
+        UInt256ParseTC{ "0x_1", 0, UInt256{ 1, 0, 0, 0 }, nil, 0 },
+        UInt256ParseTC{ "1__0", 0, UInt256{}, strconv.ErrSyntax, 2 },
+        UInt256ParseTC{ "12_", 0, UInt256{}, strconv.ErrSyntax, 2 },
+        UInt256ParseTC{ "_12", 0, UInt256{}, strconv.ErrSyntax, 0 },
+        UInt256ParseTC{ "0b102", 0, UInt256{}, strconv.ErrSyntax, 4 },
+        UInt256ParseTC{ "0x", 0, UInt256{}, strconv.ErrSyntax, 1 },
+        UInt256ParseTC{ "1_0", 10, UInt256{}, strconv.ErrSyntax, 1 },
+    }
+    for i, tc := range testCases {
+        result, err := ParseUInt256Base(tc.str, tc.base)
+        if tc.expected!=result || !errorMatch(tc.expError, err) {
+            t.Errorf("Result mismatch: %d: parse(%q,%d)->%v,%v!=%v,%v",
+                     i, tc.str, tc.base, tc.expected, tc.expError, result, err)
+        }
+        if numErr, ok := err.(*NumError); ok && numErr.Offset!=tc.offset {
+            t.Errorf("Offset mismatch: %d: parse(%q,%d)->%v!=%v",
+                     i, tc.str, tc.base, tc.offset, numErr.Offset)
+        }
+        // syntax errors must be same as in ParseUInt128Base
+        if tc.expError==strconv.ErrSyntax {
+            _, err128 := ParseUInt128Base(tc.str, tc.base)
+            if numErr, ok := err128.(*NumError); !ok || numErr.Offset!=tc.offset ||
+                numErr.Err!=tc.expError {
+                t.Errorf("Result mismatch: %d: parse128(%q,%d)->%v,%v!=%v",
+                         i, tc.str, tc.base, tc.expError, tc.offset, err128)
+            }
+        }
+        if tc.base==10 {
+            result, err = ParseUInt256Bytes([]byte(tc.str))
+            if tc.expected!=result || !errorMatch(tc.expError, err) {
+                t.Errorf("Result mismatch: %d: parsebytes(%q)->%v,%v!=%v,%v",
+                         i, tc.str, tc.expected, tc.expError, result, err)
+            }
+        }
+    }
+}
+
+type UInt256FormatterTC struct {
+    format string
+    a UInt256
+    expected string
+}
+
+func TestUInt256Formatter(t *testing.T) {
+    testCases := []UInt256FormatterTC {
+        UInt256FormatterTC{ "%d", UInt256{ 255, 0, 1<<36, 0 },
+            "23384026197294446691258957323460528314494920687871" },
+        UInt256FormatterTC{ "%x", UInt256{ 255, 0, 1<<36, 0 },
+            "1000000000000000000000000000000000000000ff" },
+        UInt256FormatterTC{ "%#X", UInt256{ 255, 0, 1<<36, 0 },
+            "0X1000000000000000000000000000000000000000FF" },
+        UInt256FormatterTC{ "%#v", UInt256{ 255, 0, 1<<36, 0 },
+            "0x1000000000000000000000000000000000000000ff" },
+        UInt256FormatterTC{ "%55d", UInt256{ 255, 0, 1<<36, 0 },
+            "     23384026197294446691258957323460528314494920687871" },
+        UInt256FormatterTC{ "%d", MaxUInt256, "1157920892373161954235709850086879078532699846" +
+            "65640564039457584007913129639935" },
+        UInt256FormatterTC{ "%z", UInt256{ 12, 0, 0, 0 }, "%!z(goint128.UInt256=12)" },
+    }
+    for i, tc := range testCases {
+        a := tc.a
+        result := fmt.Sprintf(tc.format, tc.a)
+        if tc.expected!=result {
+            t.Errorf("Result mismatch: %d: Sprintf(%q,%v)->%q!=%q",
+                     i, tc.format, tc.a, tc.expected, result)
+        }
+        if tc.a!=a {
+            t.Errorf("Argument has been modified: %d: %v!=%v", i, a, tc.a)
+        }
+    }
+    if result := fmt.Sprintf("%b", MaxUInt256); result!=MaxUInt256.FormatBase(2) ||
+        len(result)!=256 {
+        t.Errorf("Result mismatch: Sprintf(%%b)->%q", result)
+    }
+    var b UInt256
+    if _, err := fmt.Sscanf("0x1000000000000000000000000000000000000000ff", "%v", &b);
+        err!=nil || b!=(UInt256{ 255, 0, 1<<36, 0 }) {
+        t.Errorf("Result mismatch: Sscanf->%v,%v", b, err)
+    }
+}
+
+func TestUInt256Marshal(t *testing.T) {
+    a := UInt256{ 0x1122334455667788, 0x99aabbccddeeff00, 0x0102030405060708, 0xf0e0d0c0b0a09080 }
+    data, _ := a.MarshalBinary()
+    var b UInt256
+    if err := b.UnmarshalBinary(data); err!=nil || b!=a || len(data)!=32 {
+        t.Errorf("Result mismatch: binary->%v,%v,%v", data, b, err)
+    }
+    if err := b.UnmarshalBinary(data[:31]); err!=ErrDataTooSmall {
+        t.Errorf("Result mismatch: binary->%v", err)
+    }
+    text, _ := a.MarshalText()
+    b = UInt256{}
+    if err := b.UnmarshalText(text); err!=nil || b!=a {
+        t.Errorf("Result mismatch: text->%s,%v,%v", text, b, err)
+    }
+    type jsonStruct struct {
+        A, B UInt256
+    }
+    js := jsonStruct{ UInt256{ 1234, 0, 0, 0 }, a }
+    data, err := json.Marshal(js)
+    expected := []byte(`{"A":1234,"B":"` + a.String() + `"}`)
+    if err!=nil || !bytes.Equal(expected, data) {
+        t.Errorf("Result mismatch: json->%s,%v", data, err)
+    }
+    var js2 jsonStruct
+    if err = json.Unmarshal(data, &js2); err!=nil || js2!=js {
+        t.Errorf("Result mismatch: json->%v,%v", js2, err)
+    }
+    if err = b.UnmarshalJSON([]byte(`"12a"`)); !errorMatch(strconv.ErrSyntax, err) ||
+        err.(*NumError).Offset!=3 || b!=(UInt256{}) {
+        t.Errorf("Result mismatch: json->%v,%v", b, err)
+    }
+}
+
+func BenchmarkUInt256Mul(b *testing.B) {
+    x := UInt256{ 0x1c9e66c000000000, 0xe1b1e5f90f944d6e, 0x8c261ad7409395f0, 0xf7a96e }
+    y := UInt256{ 0x8c261ad7409395f0, 0xf7a96e0000000000, 0x1c9e66c000000000, 0 }
+    for i := 0; i < b.N; i++ {
+        x.Mul(y)
+    }
+}
+
+func BenchmarkUInt256DivMod(b *testing.B) {
+    x := UInt256{ 0x1c9e66c000000000, 0xe1b1e5f90f944d6e, 0x8c261ad7409395f0, 0xf7a96e }
+    y := UInt256{ 0x8c261ad7409395f0, 0xf7a96e0000000000, 0x1c9e66, 0 }
+    for i := 0; i < b.N; i++ {
+        x.DivMod(y)
+    }
+}
+
+func BenchmarkUInt256Format(b *testing.B) {
+    x := UInt256{ 0x1c9e66c000000000, 0xe1b1e5f90f944d6e, 0x8c261ad7409395f0, 0xf7a96e }
+    for i := 0; i < b.N; i++ {
+        x.FormatBytes()
+    }
+}
@@ -0,0 +1,39 @@
+// +build !purego,!appengine
+
+/*
+ * unsafe_string.go - conversion bytes to string without copying
+ *
+ * goint128 - go int128 library
+ * Copyright (C) 2020  Mateusz Szpakowski
+ *
+ * This library is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU Lesser General Public
+ * License as published by the Free Software Foundation; either
+ * version 2.1 of the License, or (at your option) any later version.
+ *
+ * This library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+ * Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public
+ * License along with this library; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301  USA
+ */
+
+package goint128
+
+import "unsafe"
+
+// true if bytesToString does not copy bytes
+const bytesToStringNoCopy = true
+
+// return string that shares memory with bytes (without allocation and copying).
+// used only to call string parsers from bytes parsers, that avoids an allocation
+// per call (see BenchmarkParseUInt128BytesCopy). aliasing rules:
+// the string must not be retained after call (errors must hold string(b) copy),
+// and bytes must not be modified while string is used.
+// with purego or appengine build tag safe version is used (see safe_string.go).
+func bytesToString(b []byte) string {
+    return *(*string)(unsafe.Pointer(&b))
+}
@@ -0,0 +1,73 @@
+/*
+ * unsafe_string_test.go - tests for conversion bytes to string without copying
+ *
+ * goint128 - go int128 library
+ * Copyright (C) 2020  Mateusz Szpakowski
+ *
+ * This library is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU Lesser General Public
+ * License as published by the Free Software Foundation; either
+ * version 2.1 of the License, or (at your option) any later version.
+ *
+ * This library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+ * Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public
+ * License along with this library; if not, write to the Free Software
+ * Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301  USA
+ */
+
+package goint128
+
+import (
+    "testing"
+)
+
+func TestBytesToString(t *testing.T) {
+    b := []byte("1234x")
+    if result := bytesToString(b); result!="1234x" {
+        t.Errorf("Result mismatch: bytesToString(%v)->%v", b, result)
+    }
+    if result := bytesToString(nil); result!="" {
+        t.Errorf("Result mismatch: bytesToString(nil)->%v", result)
+    }
+    // error must not share memory with input
+    _, err := ParseUInt128Bytes(b)
+    b[0] = '9'
+    if numErr, ok := err.(*NumError); !ok || numErr.Num!="1234x" {
+        t.Errorf("Result mismatch: parse->%v", err)
+    }
+}
+
+func TestParseBytesAllocs(t *testing.T) {
+    if !bytesToStringNoCopy {
+        t.Skip("bytesToString copies bytes (purego or appengine build tag)")
+    }
+    b := []byte("340282366920938463463374607431768211455")
+    allocs := testing.AllocsPerRun(100, func() {
+        ParseUInt128Bytes(b)
+    })
+    if allocs!=0 {
+        t.Errorf("Result mismatch: allocs %v!=0", allocs)
+    }
+}
+
+// parsing bytes by bytesToString
+func BenchmarkParseUInt128Bytes(b *testing.B) {
+    str := []byte("340282366920938463463374607431768211455")
+    b.ReportAllocs()
+    for i := 0; i < b.N; i++ {
+        ParseUInt128Bytes(str)
+    }
+}
+
+// parsing bytes by copying to string
+func BenchmarkParseUInt128BytesCopy(b *testing.B) {
+    str := []byte("340282366920938463463374607431768211455")
+    b.ReportAllocs()
+    for i := 0; i < b.N; i++ {
+        ParseUInt128(string(str))
+    }
+}